@@ -1,259 +1,785 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
-	"sync"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/alchmst333/SRE_Healthcheck/pkg/healthcheck"
 )
 
-// Configuration struct to hold endpoint details
-type Configuration struct {
-	Name    string            `yaml:"name"`
-	Url     string            `yaml:"url"`
-	Method  string            `yaml:"method,omitempty"`
-	Headers map[string]string `yaml:"headers,omitempty"`
+// logRotation configures the rotation of the log file. Rotation is disabled
+// when MaxSize is 0.
+type logRotation struct {
+	MaxSize    int // Megabytes
+	MaxBackups int
+	MaxAge     int // Days
+	Compress   bool
 }
 
-// Availability struct to track UP and DOWN counts and latency metrics
-type Availability struct {
-	SuccessCount int
-	FailureCount int
-	TotalLatency time.Duration
-	MinLatency   time.Duration
-	MaxLatency   time.Duration
-}
+// Logger function to set up logging to the given target in the given format
+func logger(target, logFilePath, format, syslogAddr string, rotation logRotation) (io.WriteCloser, error) {
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("unsupported log format '%s'", format)
+	}
 
-// Function to extract domain from URL
-func extractDomain(rawUrl string) string {
-	parsedUrl, err := url.Parse(rawUrl)
+	file, err := openLogTarget(target, logFilePath, syslogAddr, rotation)
 	if err != nil {
-		log.Printf("Invalid URL '%s': %v", rawUrl, err)
-		return "invalid_domain"
+		return nil, err
+	}
+
+	if format == "json" {
+		// Route the standard logger through a JSON handler so every line is a JSON object
+		slog.SetDefault(slog.New(slog.NewJSONHandler(file, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == slog.TimeKey {
+					a.Key = "timestamp"
+				}
+				return a
+			},
+		})))
+		return file, nil
+	}
+
+	log.SetOutput(file)
+	log.SetFlags(log.LstdFlags | log.Lshortfile) // Includes date, time, and file info
+	if target != logTargetFile {
+		log.SetFlags(log.Lshortfile) // Syslog and the journal timestamp every line
 	}
-	return parsedUrl.Host
+	return file, nil
 }
 
-// Function to get file data from a given file path
-func GetFileDataFromFlag(filePath string) []byte {
-	// Read the file data
-	data, err := os.ReadFile(filePath)
+// serveHTTP starts an HTTP server exposing the metrics on /metrics, the
+// status API under /api/ and the health of the monitor on /healthz and /readyz
+func serveHTTP(addr string, scheduler *healthcheck.Scheduler, metrics *healthcheck.Metrics) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/api/", scheduler.APIHandler())
+	mux.Handle("/healthz", scheduler.HealthHandler())
+	mux.Handle("/readyz", scheduler.HealthHandler())
+
+	go func() {
+		log.Printf("Serving metrics and status API on %s", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Fatalf("HTTP server failed: %v", err)
+		}
+	}()
+}
+
+func main() {
+	// `healthcheck report` summarizes the stored history instead of monitoring
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := runReport(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Exit with exitCode once the deferred calls have flushed and closed the
+	// logs, outputs and telemetry
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// Define all command-line flags at the beginning
+	configFilePath := flag.String("file", "./sample.yml", "Path, glob pattern (e.g., 'configs/*.yml') or URL of the configuration file")
+	configRefresh := flag.Duration("config-refresh", time.Minute, "How often a configuration fetched from a URL is checked for changes")
+	configHeaders := headerFlag{}
+	flag.Var(configHeaders, "config-header", "Header sent when fetching the configuration from a URL, as 'Name: value'. Can be repeated")
+	allowRemoteExec := flag.Bool("allow-remote-exec", false, "Accept exec checks in a configuration fetched from a URL")
+	allowRemoteEnv := flag.Bool("allow-remote-env", false, "Interpolate ${VAR} environment variable references in a configuration fetched from a URL")
+	configFormat := flag.String("format", "", "Configuration file format: yaml, json or toml (default: detected from the file extension)")
+	logFilePath := flag.String("log", "./healthcheck.log", "Path to the log file")
+	logTarget := flag.String("log-target", logTargetFile, "Where logs are written: file, syslog or journald")
+	syslogAddr := flag.String("syslog-addr", "", "Remote syslog server for --log-target syslog, as udp://host:port or tcp://host:port (default: the local syslog daemon)")
+	logFormat := flag.String("log-format", "text", "Log format: text or json")
+	logMaxSize := flag.Int("log-max-size", 100, "Size in megabytes at which the log file is rotated. Rotation is disabled when 0")
+	logMaxBackups := flag.Int("log-max-backups", 5, "Number of rotated log files to keep. All are kept when 0")
+	logMaxAge := flag.Int("log-max-age", 0, "Days to keep rotated log files. Kept regardless of age when 0")
+	logCompress := flag.Bool("log-compress", true, "Gzip rotated log files")
+	checkInterval := flag.Duration("interval", 15*time.Second, "Health check interval (e.g., 15s, 1m)")
+	latencyThreshold := flag.Duration("latency", 500*time.Millisecond, "Latency threshold for UP status (e.g., 500ms, 1s)")
+	timeout := flag.Duration("timeout", healthcheck.DefaultTimeout, "Default timeout for each check (e.g., 1s, 5s)")
+	concurrency := flag.Int("concurrency", 0, "Maximum number of checks running at the same time. Unlimited when 0")
+	jitter := flag.Duration("jitter", 0, "Maximum random delay added before every check to avoid synchronized load (e.g., 2s). Capped at half the check interval")
+	stagger := flag.Bool("stagger", false, "Spread the checks of the endpoints across their interval instead of running them all at once")
+	maxIdleConns := flag.Int("max-idle-conns", healthcheck.DefaultMaxIdleConns, "Maximum number of idle HTTP connections kept open across all hosts")
+	maxIdleConnsPerHost := flag.Int("max-idle-conns-per-host", healthcheck.DefaultMaxIdleConnsPerHost, "Maximum number of idle HTTP connections kept open per host")
+	metricsListen := flag.String("metrics-listen", "", "Address to serve Prometheus metrics, the status API and health probes on (e.g., :9090). Disabled when empty")
+	stateFile := flag.String("state-file", "", "Path to a JSON file where availability is persisted across restarts. Disabled when empty")
+	dbPath := flag.String("db", "", "Path to a SQLite database storing every check result, e.g. healthcheck.db. Disabled when empty")
+	csvPath := flag.String("csv", "", "Path to a CSV file receiving one row per endpoint at every summary. Disabled when empty")
+	statusPageDir := flag.String("status-page-dir", "", "Directory to write an HTML status page to after every summary. Disabled when empty")
+	watchConfig := flag.Bool("watch", true, "Reload the configuration when the file changes")
+	tui := flag.Bool("tui", false, "Show a live dashboard of every endpoint in the terminal instead of printing summaries")
+	once := flag.Bool("once", false, "Run a single check cycle, print the results and exit non-zero if any endpoint is DOWN")
+	failSeverity := flag.String("fail-severity", healthcheck.SeverityInfo, "Least severity of the DOWN endpoints that make --once exit non-zero: critical, warning or info")
+	statsdAddr := flag.String("statsd-addr", "", "StatsD/DogStatsD host:port to send check metrics to over UDP (e.g., localhost:8125). Disabled when empty")
+	influxOutput := flag.String("influx-output", "", "File or InfluxDB write URL to send check results to in line protocol every cycle. Disabled when empty")
+	influxToken := flag.String("influx-token", os.Getenv("INFLUX_TOKEN"), "InfluxDB API token for --influx-output URLs (default: $INFLUX_TOKEN)")
+	remoteWriteURL := flag.String("remote-write-url", "", "Prometheus remote_write URL to push check results to every cycle (e.g., http://mimir:9009/api/v1/push). Disabled when empty")
+	remoteWriteHeaders := headerFlag{}
+	flag.Var(remoteWriteHeaders, "remote-write-header", "Header sent with remote_write requests, as 'Name: value'. Can be repeated")
+	remoteWriteLabels := labelFlag{}
+	flag.Var(remoteWriteLabels, "remote-write-label", "Label added to every series pushed with remote_write, as 'name=value'. Can be repeated")
+	cloudWatchNamespace := flag.String("cloudwatch-namespace", "", "AWS CloudWatch namespace to publish Up, Latency and Availability metrics to every cycle (e.g., Healthcheck). Disabled when empty")
+	cloudWatchRegion := flag.String("cloudwatch-region", "", "AWS region of --cloudwatch-namespace (default: from AWS_REGION or the shared AWS configuration)")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP/HTTP collector URL to export check traces and metrics to (e.g., http://localhost:4318). Also enabled by OTEL_EXPORTER_OTLP_ENDPOINT")
+	kubernetes := flag.Bool("kubernetes", false, "Discover endpoints from the Services and Ingresses of a Kubernetes cluster, in addition to the configuration file")
+	kubernetesAPI := flag.String("kubernetes-api", "", "Kubernetes API server URL, e.g. http://127.0.0.1:8001 for kubectl proxy (default: the in-cluster service account). The token is read from $KUBERNETES_TOKEN")
+	kubernetesNamespace := flag.String("kubernetes-namespace", "", "Only discover objects in this namespace (default: all namespaces)")
+	kubernetesSelector := flag.String("kubernetes-selector", "", "Only discover objects matching this label selector (e.g., team=payments)")
+	kubernetesAnnotation := flag.String("kubernetes-annotation", "", "Only discover objects carrying this annotation (e.g., healthcheck.io/scrape)")
+	srvNames := stringsFlag{}
+	flag.Var(&srvNames, "srv", "DNS SRV name whose targets are checked, e.g. _http._tcp.api.example.com. Can be repeated")
+	srvPath := flag.String("srv-path", "/", "HTTP path checked on the targets of _http and _https SRV names")
+	srvResolver := flag.String("srv-resolver", "", "DNS server to resolve SRV names with, as host[:port] (default: the system resolver)")
+	discoveryInterval := flag.Duration("discovery-interval", 30*time.Second, "How often discovered endpoints are refreshed")
+	pluginDir := flag.String("plugin-dir", "", "Directory of checker plugins: every executable named healthcheck-<type> provides that check type. Disabled when empty")
+	heartbeatURL := flag.String("heartbeat-url", "", "URL requested after every summary so a dead man's switch service (e.g., healthchecks.io) alerts when the monitor stops. Disabled when empty")
+	region := flag.String("region", "local", "Region this monitor runs in, labeling its results for --coordinator-url and --coordinator")
+	coordinatorURL := flag.String("coordinator-url", "", "Run as a probe agent: base URL of the coordinator's status API the results are sent to after every summary (e.g., http://coordinator:9090). Disabled when empty")
+	coordinator := flag.Bool("coordinator", false, "Accept results from probe agents on POST /api/probes and alert when an endpoint is DOWN from some regions only. Requires --metrics-listen")
+	probeToken := flag.String("probe-token", os.Getenv("PROBE_TOKEN"), "Bearer token shared by probe agents and their coordinator (default: $PROBE_TOKEN)")
+	coordinatorInsecure := flag.Bool("coordinator-insecure", false, "Let --coordinator accept probe reports from anyone without --probe-token")
+	apiToken := flag.String("api-token", os.Getenv("HEALTHCHECK_API_TOKEN"), "Bearer token required to add or remove silences through the status API, which refuses changes without one (default: $HEALTHCHECK_API_TOKEN)")
+	leaderElection := flag.String("leader-election", "", "Run as one of an HA pair where only the leader sends alerts, electing it with a file, consul or kubernetes lock. Disabled when empty")
+	leaderLock := flag.String("leader-lock", "", "Lock of --leader-election: a file path, a Consul KV key or a Kubernetes lease as namespace/name (default: healthcheck.lock in the temporary directory, service/healthcheck/leader or default/healthcheck)")
+	leaderID := flag.String("leader-id", healthcheck.DefaultIdentity(), "Identity of this instance in the leader lock")
+	leaderTTL := flag.Duration("leader-ttl", healthcheck.DefaultLeaderTTL, "How long the leader holds the lock without renewing it before the standby takes over. At least 10s with Consul")
+	consulAddr := flag.String("consul-addr", envOr("CONSUL_HTTP_ADDR", healthcheck.DefaultConsulAddress), "Consul HTTP API address for --leader-election consul (default: $CONSUL_HTTP_ADDR or the local agent). The token is read from $CONSUL_HTTP_TOKEN")
+	webhookURL := flag.String("webhook-url", "", "Slack-compatible webhook URL notified when an endpoint goes DOWN or recovers")
+	flag.Parse()
+
+	// Validate that the config file path is provided
+	if *configFilePath == "" {
+		fmt.Println("Error: Configuration file path must be provided using the --file flag.")
+		flag.Usage()
+		os.Exit(1)
+	}
+	switch *failSeverity {
+	case healthcheck.SeverityCritical, healthcheck.SeverityWarning, healthcheck.SeverityInfo:
+	default:
+		fmt.Printf("Error: invalid --fail-severity %q, expected critical, warning or info.\n", *failSeverity)
+		os.Exit(1)
+	}
+	switch *leaderElection {
+	case "", healthcheck.ElectionFile, healthcheck.ElectionConsul, healthcheck.ElectionKubernetes:
+	default:
+		fmt.Printf("Error: invalid --leader-election %q, expected file, consul or kubernetes.\n", *leaderElection)
+		os.Exit(1)
+	}
+	if *coordinator && *metricsListen == "" {
+		fmt.Println("Error: --coordinator requires --metrics-listen to receive probe reports.")
+		os.Exit(1)
+	}
+	if *coordinator && *probeToken == "" && !*coordinatorInsecure {
+		fmt.Println("Error: --coordinator requires --probe-token, or --coordinator-insecure to accept probe reports from anyone.")
+		os.Exit(1)
+	}
+
+	// Initialize logger
+	logFile, err := logger(*logTarget, *logFilePath, *logFormat, *syslogAddr, logRotation{
+		MaxSize:    *logMaxSize,
+		MaxBackups: *logMaxBackups,
+		MaxAge:     *logMaxAge,
+		Compress:   *logCompress,
+	})
 	if err != nil {
-		log.Fatalf("Failed to read file '%s': %v", filePath, err)
+		fmt.Printf("Error initializing logger: %v\n", err)
+		os.Exit(1)
 	}
-	return data
-}
+	defer logFile.Close()
 
-// Function to parse YAML contents into a slice of Configuration
-func parser(data []byte) []Configuration {
-	var requests []Configuration
+	// Register the plugin check types before the configuration references them
+	if *pluginDir != "" {
+		types, err := healthcheck.LoadPlugins(*pluginDir)
+		if err != nil {
+			fmt.Printf("Error loading plugins: %v\n", err)
+			os.Exit(1)
+		}
+		log.Printf("Loaded %d checker plugins from %s: %s", len(types), *pluginDir, strings.Join(types, ", "))
+	}
 
-	// Unmarshal YAML data into the requests slice
-	err := yaml.Unmarshal(data, &requests)
+	// Retrieve and parse the configuration, from a file or a URL
+	var remote *healthcheck.RemoteConfig
+	var config *healthcheck.Config
+	if healthcheck.IsRemote(*configFilePath) {
+		remote = healthcheck.NewRemoteConfig(*configFilePath, configHeaders, *configFormat)
+		remote.AllowExec = *allowRemoteExec
+		remote.AllowEnv = *allowRemoteEnv
+		config, err = remote.Fetch(context.Background())
+	} else {
+		config, err = healthcheck.LoadFormat(*configFilePath, *configFormat)
+	}
 	if err != nil {
-		log.Fatalf("Error parsing YAML: %v", err)
+		log.Fatalf("Error loading configuration: %v", err)
 	}
+	requests := config.Endpoints
 
-	return requests
-}
+	// Log the domains and URLs being monitored
+	log.Println("Domains and URLs being monitored:")
+	for _, req := range requests {
+		log.Printf("- Domain: %s, URL: %s", req.Domain(), req.Url)
+	}
+	log.Println()
 
-// Function to check endpoint health with latency metrics
-func checkEndpointHealth(req Configuration, avail *Availability, latencyThreshold time.Duration) {
-	// Set default method to GET if not specified
-	method := req.Method
-	if method == "" {
-		method = "GET"
+	checker := healthcheck.NewChecker(*latencyThreshold, *timeout)
+	if httpChecker, ok := checker.Get(healthcheck.TypeHTTP).(*healthcheck.HTTPChecker); ok {
+		httpChecker.MaxIdleConns = *maxIdleConns
+		httpChecker.MaxIdleConnsPerHost = *maxIdleConnsPerHost
 	}
+	if transaction, ok := checker.Get(healthcheck.TypeTransaction).(*healthcheck.TransactionChecker); ok {
+		transaction.HTTP.MaxIdleConns = *maxIdleConns
+		transaction.HTTP.MaxIdleConnsPerHost = *maxIdleConnsPerHost
+	}
+
+	scheduler := healthcheck.NewScheduler(requests, checker, *checkInterval)
+	scheduler.ConfigLoaded(nil)
+
+	// Elect the instance sending alerts if running as an HA pair
+	if *leaderElection != "" {
+		elector, err := leaderElector(*leaderElection, *leaderLock, *leaderID, *consulAddr, *kubernetesAPI)
+		if err != nil {
+			log.Fatalf("Error configuring leader election: %v", err)
+		}
+		scheduler.Election = healthcheck.NewElection(elector, *leaderTTL)
+	}
+	if *logFormat == "json" {
+		// Check results go to the log as JSON records, the text report still goes to stdout
+		scheduler.Sinks = []healthcheck.ResultSink{
+			&healthcheck.ConsoleSink{Summary: os.Stdout},
+			&healthcheck.JSONSink{Logger: slog.Default()},
+		}
+	}
+	if *statusPageDir != "" {
+		scheduler.Sinks = append(scheduler.Sinks, &healthcheck.StatusPageSink{Dir: *statusPageDir})
+	}
+	scheduler.Concurrency = *concurrency
+	scheduler.Jitter = *jitter
+	scheduler.Stagger = *stagger
 
-	// Create HTTP request
-	httpReq, err := http.NewRequest(method, req.Url, nil)
+	// Maintenance windows from the config file, extended at runtime through the silences API
+	maintenance, err := healthcheck.NewMaintenance(config.Maintenance)
 	if err != nil {
-		log.Printf("Error creating request for %s: %v", req.Url, err)
-		avail.FailureCount++
-		return
+		log.Fatalf("Error loading maintenance windows: %v", err)
 	}
+	scheduler.Maintenance = maintenance
+	scheduler.APIToken = *apiToken
 
-	// Add headers if any
-	for key, value := range req.Headers {
-		httpReq.Header.Set(key, value)
+	// Restore availability from a previous run if requested
+	if *stateFile != "" {
+		if err := scheduler.Store.LoadFile(*stateFile); err != nil {
+			log.Fatalf("Error loading state: %v", err)
+		}
+		scheduler.StateFile = *stateFile
 	}
 
-	// Initialize HTTP client with timeout
-	client := &http.Client{
-		Timeout: 1 * time.Second, // Adjust as needed
+	// Append cycle summaries to a CSV file if requested
+	if *csvPath != "" {
+		scheduler.Sinks = append(scheduler.Sinks, healthcheck.NewCSVSink(*csvPath))
 	}
 
-	// Measure latency
-	startTime := time.Now()
-	resp, err := client.Do(httpReq)
-	latency := time.Since(startTime)
+	// Store the history of every check if requested
+	var history *healthcheck.History
+	if *dbPath != "" {
+		history, err = healthcheck.OpenHistory(*dbPath)
+		if err != nil {
+			log.Fatalf("Error opening history database: %v", err)
+		}
+		defer history.Close()
+		scheduler.Sinks = append(scheduler.Sinks, history)
+	}
+
+	// Compare the results of every region if running as a coordinator
+	if *coordinator {
+		scheduler.Regions = healthcheck.NewRegionTracker()
+		scheduler.Region = *region
+		scheduler.ProbeToken = *probeToken
+	}
 
+	// Expose Prometheus metrics and the status API if requested
+	if *metricsListen != "" {
+		metrics := healthcheck.NewMetrics()
+		scheduler.Sinks = append(scheduler.Sinks, metrics)
+		serveHTTP(*metricsListen, scheduler, metrics)
+	}
+
+	// Emit DogStatsD metrics if requested
+	if *statsdAddr != "" {
+		statsd, err := healthcheck.NewStatsD(*statsdAddr)
+		if err != nil {
+			log.Fatalf("Error configuring StatsD: %v", err)
+		}
+		defer statsd.Close()
+		scheduler.Sinks = append(scheduler.Sinks, statsd)
+	}
+
+	// Write line protocol for InfluxDB if requested
+	if *influxOutput != "" {
+		scheduler.Sinks = append(scheduler.Sinks, healthcheck.NewInfluxWriter(*influxOutput, *influxToken))
+	}
+
+	// Push time series with Prometheus remote_write if requested
+	if *remoteWriteURL != "" {
+		scheduler.Sinks = append(scheduler.Sinks, healthcheck.NewRemoteWriter(*remoteWriteURL, remoteWriteHeaders, remoteWriteLabels))
+	}
+
+	// Publish CloudWatch metrics if requested
+	if *cloudWatchNamespace != "" {
+		cloudWatch, err := healthcheck.NewCloudWatch(context.Background(), *cloudWatchNamespace, *cloudWatchRegion)
+		if err != nil {
+			log.Fatalf("Error configuring CloudWatch: %v", err)
+		}
+		scheduler.Sinks = append(scheduler.Sinks, cloudWatch)
+	}
+
+	// Publish to the cloud monitoring services of the outputs section
+	outputs, err := config.Outputs.Sinks(context.Background())
 	if err != nil {
-		log.Printf("DOWN: %s (%s) - Error: %v", req.Name, req.Url, err)
-		log.Println("Error occurred, check your connection or the target URL.")
-		avail.FailureCount++
-		return
+		log.Fatalf("Error configuring outputs: %v", err)
+	}
+	scheduler.Sinks = append(scheduler.Sinks, outputs...)
+	for _, sink := range outputs {
+		if closer, ok := sink.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+
+	// Report the results to a coordinator if running as a probe agent
+	if *coordinatorURL != "" {
+		scheduler.Sinks = append(scheduler.Sinks, healthcheck.NewProbeSink(*coordinatorURL, *region, *probeToken))
+	}
+
+	// Ping a dead man's switch after every summary if requested
+	if *heartbeatURL != "" {
+		scheduler.Sinks = append(scheduler.Sinks, healthcheck.NewHeartbeat(*heartbeatURL))
+	}
+
+	// Send alerts on state transitions if requested
+	sets, err := notifierSets(config, *webhookURL, scheduler)
+	if err != nil {
+		log.Fatalf("Error configuring alerting: %v", err)
+	}
+	scheduler.Alerter = alerter(config, sets)
+	if scheduler.Alerter != nil && scheduler.Election != nil {
+		scheduler.Alerter.Leader = scheduler.Election.Leader
 	}
-	defer resp.Body.Close()
 
-	// Determine UP or DOWN
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 && latency < latencyThreshold {
-		log.Printf("UP: %s (%s) - Status: %d, Latency: %v", req.Name, req.Url, resp.StatusCode, latency)
-		avail.SuccessCount++
-		avail.TotalLatency += latency
+	// Send a periodic availability digest if configured
+	digest, err := digester(config, sets, history)
+	if err != nil {
+		log.Fatalf("Error configuring digest: %v", err)
+	}
+
+	// Handle graceful termination by cancelling in-flight checks
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-		// Update MinLatency
-		if avail.MinLatency == 0 || latency < avail.MinLatency {
-			avail.MinLatency = latency
+	// Export traces and metrics to an OpenTelemetry collector if requested
+	otel, shutdownTelemetry, err := telemetry(ctx, *otlpEndpoint)
+	if err != nil {
+		log.Fatalf("Error configuring OpenTelemetry export: %v", err)
+	}
+	if otel != nil {
+		scheduler.Telemetry = otel
+		defer flushTelemetry(shutdownTelemetry)
+	}
+
+	// Discover endpoints in addition to the configuration file if requested
+	var sources []healthcheck.Discoverer
+	if *kubernetes {
+		kube, err := kubernetesDiscoverer(*kubernetesAPI)
+		if err != nil {
+			log.Fatalf("Error configuring Kubernetes discovery: %v", err)
 		}
-		// Update MaxLatency
-		if latency > avail.MaxLatency {
-			avail.MaxLatency = latency
+		kube.Namespace = *kubernetesNamespace
+		kube.LabelSelector = *kubernetesSelector
+		kube.Annotation = *kubernetesAnnotation
+		sources = append(sources, kube)
+	}
+	for _, name := range srvNames {
+		srv := healthcheck.NewSRVDiscoverer(name)
+		srv.Path = *srvPath
+		srv.Resolver = *srvResolver
+		sources = append(sources, srv)
+	}
+	var discovery *healthcheck.Discovery
+	if len(sources) > 0 {
+		discovery = healthcheck.NewDiscovery(scheduler, *discoveryInterval, sources...)
+		discovery.Refresh(ctx)
+		go discovery.Run(ctx)
+	}
+
+	if digest != nil {
+		if scheduler.Election != nil {
+			digest.Leader = scheduler.Election.Leader
 		}
+		go digest.Run(ctx)
+	}
+
+	// Campaign before the first checks so the leader alerts from the start,
+	// and resign on shutdown
+	elected := make(chan struct{})
+	if scheduler.Election != nil {
+		scheduler.Election.Campaign(ctx)
+		go func() {
+			scheduler.Election.Run(ctx)
+			close(elected)
+		}()
 	} else {
-		log.Printf("DOWN: %s (%s) - Status: %d, Latency: %v", req.Name, req.Url, resp.StatusCode, latency)
-		avail.FailureCount++
+		close(elected)
 	}
-}
 
-// Function to log availability percentages and detailed metrics per URL
-func logAvailability(requests []Configuration, availability map[string]*Availability) {
-	// Iterate over each request (each endpoint)
-	for _, req := range requests {
-		stats := availability[req.Url] // Keyed by full URL
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		log.Printf("Received signal %s. Exiting program.", sig)
+		cancel()
+	}()
+
+	// In one-shot mode run a single cycle and report the outcome through the exit code
+	if *once {
+		if !runOnce(ctx, scheduler, *failSeverity) {
+			exitCode = 1
+		}
+		return
+	}
 
-		total := stats.SuccessCount + stats.FailureCount
-		if total == 0 {
-			fmt.Printf("%s (%s) has no availability data yet.\n", req.Name, req.Url)
-			continue
+	// Reload the configuration on SIGHUP and, if enabled, when the file or URL changes
+	reload := func() { reloadConfig(scheduler, discovery, *configFilePath, *configFormat) }
+	if remote != nil {
+		reload = func() { reloadRemote(ctx, scheduler, discovery, remote) }
+	}
+	hups := make(chan os.Signal, 1)
+	signal.Notify(hups, syscall.SIGHUP)
+	go func() {
+		for range hups {
+			log.Println("Received SIGHUP, reloading configuration...")
+			reload()
+		}
+	}()
+	switch {
+	case *watchConfig && remote != nil:
+		go remote.Watch(ctx, *configRefresh,
+			func(config *healthcheck.Config) { applyConfig(scheduler, discovery, config) },
+			func(err error) { log.Printf("Error refreshing configuration, keeping current endpoints: %v", err) })
+	case *watchConfig:
+		if err := healthcheck.WatchConfigFiles(ctx, config.Sources, reload); err != nil {
+			log.Printf("Unable to watch configuration file, reload with SIGHUP instead: %v", err)
 		}
+	}
 
-		percentage := (float64(stats.SuccessCount) / float64(total)) * 100
-		percentage = float64(int(percentage + 0.5)) // Round to nearest whole number
+	if *tui {
+		// The dashboard replaces the printed summaries
+		for _, sink := range scheduler.Sinks {
+			if console, ok := sink.(*healthcheck.ConsoleSink); ok {
+				console.Summary = nil
+			}
+		}
+		if err := runDashboard(ctx, cancel, scheduler); err != nil {
+			log.Printf("Error running dashboard: %v", err)
+		}
+	} else {
+		scheduler.Run(ctx)
+	}
+	<-elected
+	log.Println("Shutdown complete.")
+}
 
-		// Print the availability percentage and detailed metrics per URL
-		fmt.Printf("%s (%s) has %d%% availability percentage\n", req.Name, req.Url, int(percentage))
-		fmt.Printf("   Total Checks: %d\n", total)
-		fmt.Printf("   Successful Checks: %d\n", stats.SuccessCount)
-		fmt.Printf("   Failed Checks: %d\n", stats.FailureCount)
-		if stats.SuccessCount > 0 {
-			fmt.Printf("   Average Latency: %v\n", time.Duration(int64(stats.TotalLatency)/int64(stats.SuccessCount)))
-		} else {
-			fmt.Printf("   Average Latency: N/A\n")
+// leaderElector returns the elector of the given backend on lock, or on
+// the default lock of the backend when empty
+func leaderElector(backend, lock, identity, consulAddr, kubernetesAPI string) (healthcheck.Elector, error) {
+	switch backend {
+	case healthcheck.ElectionConsul:
+		if lock == "" {
+			lock = "service/healthcheck/leader"
+		}
+		return healthcheck.NewConsulLock(consulAddr, os.Getenv("CONSUL_HTTP_TOKEN"), lock, identity), nil
+	case healthcheck.ElectionKubernetes:
+		if lock == "" {
+			lock = "default/healthcheck"
 		}
-		if stats.MinLatency > 0 {
-			fmt.Printf("   Minimum Latency: %v\n", stats.MinLatency)
+		namespace, name, found := strings.Cut(lock, "/")
+		if !found || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid Kubernetes lease '%s', expected namespace/name", lock)
 		}
-		if stats.MaxLatency > 0 {
-			fmt.Printf("   Maximum Latency: %v\n", stats.MaxLatency)
+		kube, err := kubernetesDiscoverer(kubernetesAPI)
+		if err != nil {
+			return nil, err
 		}
+		return healthcheck.NewKubernetesLease(kube, namespace, name, identity), nil
+	default:
+		if lock == "" {
+			lock = filepath.Join(os.TempDir(), "healthcheck.lock")
+		}
+		return healthcheck.NewFileLock(lock, identity), nil
+	}
+}
+
+// alerter builds the Alerter from the notifier sets and the alert routes of
+// the configuration, or returns nil when no notifier is configured
+func alerter(config *healthcheck.Config, sets map[string][]healthcheck.Notifier) *healthcheck.Alerter {
+	defaults := sets[healthcheck.DefaultNotifiers]
+	if len(defaults) == 0 && len(config.AlertRoutes) == 0 {
+		return nil
+	}
+	alerter := healthcheck.NewAlerter(defaults...)
+
+	resolve := func(names []string) []healthcheck.Notifier {
+		var resolved []healthcheck.Notifier
+		for _, name := range names {
+			resolved = append(resolved, sets[name]...)
+		}
+		return resolved
 	}
-	fmt.Println()
+	for _, route := range config.AlertRoutes {
+		routed := healthcheck.Route{AlertRoute: route, Notifiers: resolve(route.Notifiers)}
+		for _, escalation := range route.Escalations {
+			routed.EscalationNotifiers = append(routed.EscalationNotifiers, resolve(escalation.Notifiers))
+		}
+		alerter.Routes = append(alerter.Routes, routed)
+	}
+	return alerter
 }
 
-// Logger function to set up logging to a file
-func logger(logFilePath string) (*os.File, error) {
-	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+// notifierSets builds the default notifier set and the named sets of the
+// alerting section. webhookURL, when set, replaces the webhook of the default set.
+func notifierSets(config *healthcheck.Config, webhookURL string, scheduler *healthcheck.Scheduler) (map[string][]healthcheck.Notifier, error) {
+	defaults, err := notifiers(config.Alerting.NotifierConfig, webhookURL, scheduler)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file '%s': %v", logFilePath, err)
+		return nil, err
+	}
+	sets := map[string][]healthcheck.Notifier{healthcheck.DefaultNotifiers: defaults}
+	for name, set := range config.Alerting.Notifiers {
+		if sets[name], err = notifiers(set, "", scheduler); err != nil {
+			return nil, fmt.Errorf("notifier '%s': %v", name, err)
+		}
 	}
+	return sets, nil
+}
 
-	log.SetOutput(file)
-	log.SetFlags(log.LstdFlags | log.Lshortfile) // Includes date, time, and file info
-	return file, nil
+// digester builds the Digester of the digest section, or returns nil when
+// none is configured. The digest is compiled from the history database.
+func digester(config *healthcheck.Config, sets map[string][]healthcheck.Notifier, history *healthcheck.History) (*healthcheck.Digester, error) {
+	if config.Digest == nil {
+		return nil, nil
+	}
+	if history == nil {
+		return nil, fmt.Errorf("the digest is compiled from the history database, set --db")
+	}
+	var recipients []healthcheck.DigestNotifier
+	for _, name := range config.Digest.Notifiers {
+		for _, notifier := range sets[name] {
+			if recipient, ok := notifier.(healthcheck.DigestNotifier); ok {
+				recipients = append(recipients, recipient)
+			}
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no webhook_url or email in the digest notifiers")
+	}
+	return healthcheck.NewDigester(*config.Digest, history, recipients)
 }
 
-func main() {
-	// Define all command-line flags at the beginning
-	configFilePath := flag.String("file", "./sample.yml", "Path to the YAML configuration file")
-	logFilePath := flag.String("log", "./healthcheck.log", "Path to the log file")
-	checkInterval := flag.Duration("interval", 15*time.Second, "Health check interval (e.g., 15s, 1m)")
-	latencyThreshold := flag.Duration("latency", 500*time.Millisecond, "Latency threshold for UP status (e.g., 500ms, 1s)")
-	flag.Parse()
+// notifiers builds the notifiers of a notifier set. webhookURL, when set,
+// replaces the webhook of the set.
+func notifiers(set healthcheck.NotifierConfig, webhookURL string, scheduler *healthcheck.Scheduler) ([]healthcheck.Notifier, error) {
+	var notifiers []healthcheck.Notifier
 
-	// Validate that the config file path is provided
-	if *configFilePath == "" {
-		fmt.Println("Error: Configuration file path must be provided using the --file flag.")
-		flag.Usage()
-		os.Exit(1)
+	if webhookURL == "" {
+		webhookURL = set.WebhookURL
+	}
+	if webhookURL != "" {
+		notifiers = append(notifiers, healthcheck.NewWebhookNotifier(webhookURL))
 	}
 
-	// Initialize logger
-	logFile, err := logger(*logFilePath)
-	if err != nil {
-		fmt.Printf("Error initializing logger: %v\n", err)
-		os.Exit(1)
+	if set.TeamsWebhookURL != "" {
+		notifiers = append(notifiers, healthcheck.NewTeamsNotifier(set.TeamsWebhookURL))
+	}
+	if set.DiscordWebhookURL != "" {
+		notifiers = append(notifiers, healthcheck.NewDiscordNotifier(set.DiscordWebhookURL))
 	}
-	defer logFile.Close()
 
-	// Retrieve and parse the YAML configuration
-	yamlData := GetFileDataFromFlag(*configFilePath)
-	requests := parser(yamlData)
+	if set.Email != nil {
+		email, err := healthcheck.NewEmailNotifier(*set.Email)
+		if err != nil {
+			return nil, err
+		}
+		email.Status = scheduler.Status
+		notifiers = append(notifiers, email)
+	}
 
-	// Log the domains and URLs being monitored
-	log.Println("Domains and URLs being monitored:")
-	for _, req := range requests {
-		domain := extractDomain(req.Url)
-		log.Printf("- Domain: %s, URL: %s", domain, req.Url)
+	if set.Opsgenie != nil {
+		opsgenie, err := healthcheck.NewOpsgenieNotifier(*set.Opsgenie)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, opsgenie)
 	}
-	log.Println()
 
-	// Initialize availability tracking per URL
-	availability := make(map[string]*Availability)
-	for _, req := range requests {
-		if _, exists := availability[req.Url]; !exists {
-			availability[req.Url] = &Availability{}
+	if set.VictorOps != nil {
+		victorOps, err := healthcheck.NewVictorOpsNotifier(*set.VictorOps)
+		if err != nil {
+			return nil, err
 		}
+		notifiers = append(notifiers, victorOps)
 	}
 
-	// Handle graceful termination
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	if set.Datadog != nil {
+		datadog, err := healthcheck.NewDatadogEventNotifier(*set.Datadog)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, datadog)
+	}
 
-	// Create a ticker to run the checks at the specified interval
-	ticker := time.NewTicker(*checkInterval)
-	defer ticker.Stop()
+	return notifiers, nil
+}
 
-	// Initial health check before entering the loop
-	log.Println("Starting initial health check...")
-	var wg sync.WaitGroup
-	wg.Add(len(requests))
-	for _, req := range requests {
-		go func(r Configuration) {
-			defer wg.Done()
-			checkEndpointHealth(r, availability[r.Url], *latencyThreshold)
-		}(req)
-	}
-	wg.Wait()
-	logAvailability(requests, availability)
-
-	// Infinite loop to keep checking the endpoints at the specified interval
-	for {
-		select {
-		case <-ticker.C:
-			log.Println("Starting new health check cycle...")
-			var wg sync.WaitGroup
-			wg.Add(len(requests))
-			for _, req := range requests {
-				go func(r Configuration) {
-					defer wg.Done()
-					checkEndpointHealth(r, availability[r.Url], *latencyThreshold)
-				}(req)
-			}
-			wg.Wait() // Wait for all health checks to complete
-			logAvailability(requests, availability) // Log after all checks
-		case sig := <-sigs:
-			log.Printf("Received signal %s. Exiting program.", sig)
-			os.Exit(0)
+// runOnce runs a single check cycle and reports whether every endpoint of at
+// least the given severity is UP
+func runOnce(ctx context.Context, scheduler *healthcheck.Scheduler, failSeverity string) bool {
+	healthy := true
+	for _, result := range scheduler.RunCycle(ctx) {
+		if result.Skipped != "" {
+			fmt.Printf("SKIPPED: %s (%s), depends on %s\n", result.Endpoint.Name, result.Endpoint.Url, result.Skipped)
+			continue
+		}
+		switch {
+		case result.Up:
+		case result.Endpoint.AtLeast(failSeverity):
+			healthy = false
+			fmt.Printf("DOWN: %s (%s)\n", result.Endpoint.Name, result.Endpoint.Url)
+		default:
+			fmt.Printf("DOWN: %s (%s), ignored with severity %s\n", result.Endpoint.Name, result.Endpoint.Url, result.Endpoint.Severity)
+		}
+	}
+	if scheduler.StateFile != "" {
+		if err := scheduler.Store.SaveFile(scheduler.StateFile); err != nil {
+			log.Printf("Error saving state: %v", err)
+		}
+	}
+	return healthy
+}
+
+// reloadConfig re-reads the configuration file and applies it to the
+// scheduler. The current endpoints are kept if the file is invalid.
+func reloadConfig(scheduler *healthcheck.Scheduler, discovery *healthcheck.Discovery, configFilePath, configFormat string) {
+	config, err := healthcheck.LoadFormat(configFilePath, configFormat)
+	if err != nil {
+		log.Printf("Error reloading configuration, keeping current endpoints: %v", err)
+		scheduler.ConfigLoaded(err)
+		return
+	}
+	applyConfig(scheduler, discovery, config)
+}
+
+// reloadRemote fetches the configuration from its URL and applies it if it
+// changed since the last fetch
+func reloadRemote(ctx context.Context, scheduler *healthcheck.Scheduler, discovery *healthcheck.Discovery, remote *healthcheck.RemoteConfig) {
+	config, err := remote.Fetch(ctx)
+	switch {
+	case err != nil:
+		log.Printf("Error reloading configuration, keeping current endpoints: %v", err)
+		scheduler.ConfigLoaded(err)
+	case config == nil:
+		log.Println("Configuration is unchanged")
+	default:
+		applyConfig(scheduler, discovery, config)
+	}
+}
+
+// applyConfig applies a reloaded configuration to the scheduler. With
+// discovery enabled the file endpoints are merged with the discovered ones.
+func applyConfig(scheduler *healthcheck.Scheduler, discovery *healthcheck.Discovery, config *healthcheck.Config) {
+	if scheduler.Maintenance != nil {
+		if err := scheduler.Maintenance.SetWindows(config.Maintenance); err != nil {
+			log.Printf("Error reloading configuration, keeping current endpoints: %v", err)
+			scheduler.ConfigLoaded(err)
+			return
 		}
 	}
-	
+	scheduler.ConfigLoaded(nil)
+	if discovery != nil {
+		discovery.SetStatic(config.Endpoints)
+		return
+	}
+	scheduler.Reload(config.Endpoints)
+}
+
+// kubernetesDiscoverer connects to the given API server, or to the cluster
+// the program runs in when none is given
+func kubernetesDiscoverer(apiServer string) (*healthcheck.KubernetesDiscoverer, error) {
+	if apiServer == "" {
+		return healthcheck.NewInClusterDiscoverer()
+	}
+	return healthcheck.NewKubernetesDiscoverer(apiServer, os.Getenv("KUBERNETES_TOKEN")), nil
+}
+
+// envOr returns the value of an environment variable, or def when unset
+func envOr(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// headerFlag collects repeated 'Name: value' flags into a header map
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	return fmt.Sprint(map[string]string(h))
+}
+
+func (h headerFlag) Set(value string) error {
+	name, val, found := strings.Cut(value, ":")
+	if !found || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected 'Name: value', got '%s'", value)
+	}
+	h[strings.TrimSpace(name)] = strings.TrimSpace(val)
+	return nil
+}
+
+// labelFlag collects repeated 'name=value' flags into a label map
+type labelFlag map[string]string
+
+func (l labelFlag) String() string {
+	return fmt.Sprint(map[string]string(l))
+}
+
+func (l labelFlag) Set(value string) error {
+	name, val, found := strings.Cut(value, "=")
+	if !found || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected 'name=value', got '%s'", value)
+	}
+	l[strings.TrimSpace(name)] = strings.TrimSpace(val)
+	return nil
+}
+
+// stringsFlag collects repeated flags into a list
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
 }
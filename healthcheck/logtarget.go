@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Log targets supported by --log-target
+const (
+	logTargetFile     = "file"
+	logTargetSyslog   = "syslog"
+	logTargetJournald = "journald"
+)
+
+// logTag identifies the program in syslog and the journal
+const logTag = "healthcheck"
+
+// Syslog severities, also used as journal priorities
+const (
+	priorityErr     = 3
+	priorityWarning = 4
+	priorityNotice  = 5
+	priorityInfo    = 6
+)
+
+// logPriority maps a log line to a severity: DOWN results, warnings and
+// flapping are warnings, other errors are errors, state changes are notices
+// and everything else is informational
+func logPriority(line string) int {
+	switch {
+	case strings.Contains(line, "DOWN:"), strings.Contains(line, "WARNING:"), strings.Contains(line, "FLAPPING:"):
+		return priorityWarning
+	case strings.Contains(line, "Error"), strings.Contains(line, "failed"):
+		return priorityErr
+	case strings.Contains(line, "STATE CHANGE:"):
+		return priorityNotice
+	default:
+		return priorityInfo
+	}
+}
+
+// openLogTarget returns the writer log lines are sent to
+func openLogTarget(target, logFilePath, syslogAddr string, rotation logRotation) (io.WriteCloser, error) {
+	switch target {
+	case logTargetFile:
+		return openLogFile(logFilePath, rotation)
+	case logTargetSyslog:
+		return openSyslog(syslogAddr)
+	case logTargetJournald:
+		return openJournal()
+	default:
+		return nil, fmt.Errorf("unsupported log target '%s', expected file, syslog or journald", target)
+	}
+}
+
+// openLogFile opens the log file, rotated once it reaches the configured size
+func openLogFile(logFilePath string, rotation logRotation) (io.WriteCloser, error) {
+	// Open the file up front so an unusable path fails at startup
+	f, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file '%s': %v", logFilePath, err)
+	}
+	if rotation.MaxSize == 0 {
+		return f, nil
+	}
+
+	// The rotating writer reopens the file itself
+	f.Close()
+	return &lumberjack.Logger{
+		Filename:   logFilePath,
+		MaxSize:    rotation.MaxSize,
+		MaxBackups: rotation.MaxBackups,
+		MaxAge:     rotation.MaxAge,
+		Compress:   rotation.Compress,
+		LocalTime:  true,
+	}, nil
+}
@@ -0,0 +1,18 @@
+//go:build windows || plan9
+
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// openSyslog is not supported on this platform
+func openSyslog(addr string) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("syslog is not supported on this platform")
+}
+
+// openJournal is not supported on this platform
+func openJournal() (io.WriteCloser, error) {
+	return nil, fmt.Errorf("the systemd journal is not supported on this platform")
+}
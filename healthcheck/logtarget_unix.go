@@ -0,0 +1,105 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// journalSocket is where systemd-journald receives native protocol messages
+const journalSocket = "/run/systemd/journal/socket"
+
+// syslogWriter sends every log line to syslog with the severity of its content
+type syslogWriter struct {
+	*syslog.Writer
+}
+
+// openSyslog connects to the local syslog daemon, or to a remote one when
+// addr is set as udp://host:port or tcp://host:port
+func openSyslog(addr string) (io.WriteCloser, error) {
+	var network, raddr string
+	if addr != "" {
+		u, err := url.Parse(addr)
+		if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, fmt.Errorf("invalid syslog address '%s', expected udp://host:port or tcp://host:port", addr)
+		}
+		network, raddr = u.Scheme, u.Host
+	}
+
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_DAEMON, logTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %v", err)
+	}
+	return syslogWriter{w}, nil
+}
+
+func (w syslogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	var err error
+	switch logPriority(line) {
+	case priorityErr:
+		err = w.Err(line)
+	case priorityWarning:
+		err = w.Warning(line)
+	case priorityNotice:
+		err = w.Notice(line)
+	default:
+		err = w.Info(line)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// journalWriter sends every log line to the systemd journal over its native
+// protocol, with the severity of its content as PRIORITY
+type journalWriter struct {
+	conn *net.UnixConn
+}
+
+// openJournal connects to the local systemd journal
+func openJournal() (io.WriteCloser, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the systemd journal: %v", err)
+	}
+	return journalWriter{conn}, nil
+}
+
+func (w journalWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+
+	var msg bytes.Buffer
+	journalField(&msg, "PRIORITY", strconv.Itoa(logPriority(line)))
+	journalField(&msg, "SYSLOG_IDENTIFIER", logTag)
+	journalField(&msg, "MESSAGE", line)
+	if _, err := w.conn.Write(msg.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w journalWriter) Close() error {
+	return w.conn.Close()
+}
+
+// journalField appends a field in the journal native format. Values
+// containing newlines are sent length-prefixed.
+func journalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", name, value)
+		return
+	}
+	buf.WriteString(name + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alchmst333/SRE_Healthcheck/pkg/healthcheck"
+)
+
+// runReport implements `healthcheck report`, printing the availability,
+// outages and latency of every endpoint from the history database
+func runReport(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	dbPath := flags.String("db", "./healthcheck.db", "Path to the SQLite history database written with --db")
+	since := flags.String("since", "24h", "Start of the reported period, as a duration ago (e.g., 24h, 7d) or an RFC3339 time")
+	format := flags.String("format", "table", "Output format: table, json or csv")
+	incidents := flags.Bool("incidents", false, "List every incident, a period of consecutive failed checks, instead of the per-endpoint summary")
+	flags.Parse(args)
+
+	start, err := parseSince(*since, time.Now())
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(*dbPath); err != nil {
+		return fmt.Errorf("history database '%s' not found, run the checker with --db first: %v", *dbPath, err)
+	}
+
+	history, err := healthcheck.OpenHistory(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer history.Close()
+
+	if *incidents {
+		return writeIncidents(history, start, *format)
+	}
+
+	reports, err := history.Report(context.Background(), start)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		return writeReportTable(os.Stdout, reports, start)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	case "csv":
+		return writeReportCSV(os.Stdout, reports)
+	default:
+		return fmt.Errorf("unsupported report format '%s', expected table, json or csv", *format)
+	}
+}
+
+// parseSince parses a duration before now, with an optional d suffix for
+// days, or an absolute RFC3339 time
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since '%s', expected a duration such as 24h or 7d, or an RFC3339 time", value)
+	}
+	return now.Add(-d), nil
+}
+
+// writeReportTable prints one aligned row per endpoint
+func writeReportTable(w io.Writer, reports []healthcheck.HistoryReport, since time.Time) error {
+	if len(reports) == 0 {
+		_, err := fmt.Fprintf(w, "No checks recorded since %s.\n", since.Format(time.RFC3339))
+		return err
+	}
+
+	fmt.Fprintf(w, "Report since %s\n\n", since.Format(time.RFC3339))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tAVAILABILITY\tCHECKS\tFAILED\tOUTAGES\tMTTR\tMTBF\tLONGEST OUTAGE\tP50\tP95\tP99")
+	for _, r := range reports {
+		longest := formatSeconds(r.LongestOutageSeconds)
+		if r.Ongoing {
+			longest += " (ongoing)"
+		}
+		fmt.Fprintf(tw, "%s\t%.2f%%\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Name, r.Availability, r.TotalChecks, r.FailedChecks, r.Outages,
+			formatSeconds(r.MTTRSeconds), formatSeconds(r.MTBFSeconds), longest,
+			formatMs(r.P50LatencyMs), formatMs(r.P95LatencyMs), formatMs(r.P99LatencyMs))
+	}
+	return tw.Flush()
+}
+
+// writeReportCSV prints a header and one row per endpoint
+func writeReportCSV(w io.Writer, reports []healthcheck.HistoryReport) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"name", "url", "first_check", "last_check", "total_checks", "failed_checks", "maintenance_failures",
+		"availability_pct", "outages", "mttr_seconds", "mtbf_seconds", "longest_outage_seconds", "ongoing_outage",
+		"p50_latency_ms", "p95_latency_ms", "p99_latency_ms"})
+	for _, r := range reports {
+		writer.Write([]string{
+			r.Name, r.Url, r.First.Format(time.RFC3339), r.Last.Format(time.RFC3339),
+			strconv.Itoa(r.TotalChecks), strconv.Itoa(r.FailedChecks), strconv.Itoa(r.MaintenanceFailures),
+			strconv.FormatFloat(r.Availability, 'f', 2, 64), strconv.Itoa(r.Outages),
+			strconv.FormatFloat(r.MTTRSeconds, 'f', 3, 64), strconv.FormatFloat(r.MTBFSeconds, 'f', 3, 64),
+			strconv.FormatFloat(r.LongestOutageSeconds, 'f', 3, 64),
+			strconv.FormatBool(r.Ongoing),
+			strconv.FormatFloat(r.P50LatencyMs, 'f', 3, 64), strconv.FormatFloat(r.P95LatencyMs, 'f', 3, 64),
+			strconv.FormatFloat(r.P99LatencyMs, 'f', 3, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeIncidents prints the incident timeline since start in the given format
+func writeIncidents(history *healthcheck.History, start time.Time, format string) error {
+	incidents, err := history.Incidents(context.Background(), start)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "table":
+		if len(incidents) == 0 {
+			_, err := fmt.Fprintf(os.Stdout, "No incidents since %s.\n", start.Format(time.RFC3339))
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "Incidents since %s\n\n", start.Format(time.RFC3339))
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSTART\tEND\tDURATION\tFAILED\tERROR")
+		for _, incident := range incidents {
+			end := "ongoing"
+			if incident.End != nil {
+				end = incident.End.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", incident.Name, incident.Start.Local().Format(time.DateTime), end,
+				formatSeconds(incident.DurationSeconds), incident.FailedChecks, incident.Error)
+		}
+		return tw.Flush()
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(incidents)
+	case "csv":
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write([]string{"name", "url", "start", "end", "duration_seconds", "failed_checks", "error"})
+		for _, incident := range incidents {
+			var end string
+			if incident.End != nil {
+				end = incident.End.Format(time.RFC3339)
+			}
+			writer.Write([]string{incident.Name, incident.Url, incident.Start.Format(time.RFC3339), end,
+				strconv.FormatFloat(incident.DurationSeconds, 'f', 3, 64), strconv.Itoa(incident.FailedChecks), incident.Error})
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unsupported report format '%s', expected table, json or csv", format)
+	}
+}
+
+// formatSeconds formats a duration in seconds for the table, or - when zero
+func formatSeconds(seconds float64) string {
+	if seconds == 0 {
+		return "-"
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+// formatMs formats a latency in milliseconds for the table, or - when zero
+func formatMs(ms float64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return time.Duration(ms * float64(time.Millisecond)).Round(10 * time.Microsecond).String()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/alchmst333/SRE_Healthcheck/pkg/healthcheck"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// telemetry sets up OTLP/HTTP export of check spans and metrics. It is enabled
+// by the --otlp-endpoint flag or the standard OTEL_EXPORTER_OTLP_ENDPOINT
+// environment variable, and returns nil otherwise. The returned function
+// flushes and stops the exporters.
+func telemetry(ctx context.Context, endpoint string) (*healthcheck.Telemetry, func(context.Context) error, error) {
+	if endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return nil, nil, nil
+	}
+
+	var traceOptions []otlptracehttp.Option
+	var metricOptions []otlpmetrichttp.Option
+	if endpoint != "" {
+		traceOptions = append(traceOptions, otlptracehttp.WithEndpointURL(endpoint+"/v1/traces"))
+		metricOptions = append(metricOptions, otlpmetrichttp.WithEndpointURL(endpoint+"/v1/metrics"))
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOptions...)
+	if err != nil {
+		return nil, nil, err
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOptions...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName("healthcheck")),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+
+	shutdown := func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}
+
+	t, err := healthcheck.NewTelemetry(tracerProvider, meterProvider)
+	if err != nil {
+		shutdown(ctx)
+		return nil, nil, err
+	}
+	return t, shutdown, nil
+}
+
+// flushTelemetry exports pending spans and metrics before exiting
+func flushTelemetry(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Printf("Error flushing OpenTelemetry data: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alchmst333/SRE_Healthcheck/pkg/healthcheck"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// dashboardRefresh is how often the dashboard is redrawn
+const dashboardRefresh = time.Second
+
+var (
+	titleStyle   = lipgloss.NewStyle().Bold(true)
+	headerStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("8"))
+	mutedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	statusStyles = map[healthcheck.Status]lipgloss.Style{
+		healthcheck.StatusUp:       lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2")),
+		healthcheck.StatusDown:     lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")),
+		healthcheck.StatusFlapping: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3")),
+		healthcheck.StatusDegraded: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("5")),
+		healthcheck.StatusSkipped:  mutedStyle,
+		healthcheck.StatusUnknown:  mutedStyle,
+	}
+)
+
+// sparkBlocks are the bar heights of a latency sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// dashboardRow is the rendered state of a single endpoint
+type dashboardRow struct {
+	status   healthcheck.EndpointStatus
+	reported healthcheck.Status
+	recent   []time.Duration
+}
+
+// dashboard is a bubbletea model showing the live state of every endpoint
+type dashboard struct {
+	scheduler *healthcheck.Scheduler
+	rows      []dashboardRow
+	updated   time.Time
+}
+
+type refreshMsg time.Time
+
+// runDashboard runs the scheduler in the background and shows the dashboard
+// until the user quits or ctx is cancelled. The scheduler is then stopped and
+// its final report written before returning.
+func runDashboard(ctx context.Context, cancel context.CancelFunc, scheduler *healthcheck.Scheduler) error {
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	model := &dashboard{scheduler: scheduler}
+	model.refresh()
+	_, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+
+	cancel()
+	<-done
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func (d *dashboard) Init() tea.Cmd {
+	return refresh()
+}
+
+func (d *dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return d, tea.Quit
+		}
+	case refreshMsg:
+		d.refresh()
+		return d, refresh()
+	}
+	return d, nil
+}
+
+func (d *dashboard) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s\n\n", titleStyle.Render("Service Status"),
+		mutedStyle.Render(fmt.Sprintf("updated %s, press q to quit", d.updated.Format("15:04:05"))))
+
+	nameWidth := len("ENDPOINT")
+	for _, row := range d.rows {
+		nameWidth = max(nameWidth, len(row.status.Name))
+	}
+
+	fmt.Fprintln(&b, headerStyle.Render(fmt.Sprintf("%-8s  %-*s  %7s  %9s  %9s  %9s  %s",
+		"STATUS", nameWidth, "ENDPOINT", "AVAIL", "AVG", "P95", "CHECKS", "RECENT LATENCY")))
+	for _, row := range d.rows {
+		availability := "-"
+		if row.status.TotalChecks > 0 {
+			availability = fmt.Sprintf("%d%%", row.status.Availability)
+		}
+		status := statusStyles[row.reported].Render(fmt.Sprintf("%-8s", row.reported))
+		fmt.Fprintf(&b, "%s  %-*s  %7s  %9s  %9s  %9d  %s\n",
+			status, nameWidth, row.status.Name, availability,
+			formatMillis(row.status.AverageLatencyMs), formatMillis(row.status.P95LatencyMs),
+			row.status.TotalChecks, sparkline(row.recent))
+	}
+	return b.String()
+}
+
+// refresh reloads the state of every endpoint from the scheduler
+func (d *dashboard) refresh() {
+	statuses := d.scheduler.Status()
+	d.rows = make([]dashboardRow, len(statuses))
+	for i, status := range statuses {
+		d.rows[i] = dashboardRow{
+			status:   status,
+			reported: d.scheduler.State.Get(status.Url).Reported(),
+			recent:   d.scheduler.Store.Get(status.Url).Recent.Values(),
+		}
+	}
+	d.updated = time.Now()
+}
+
+// refresh schedules the next redraw
+func refresh() tea.Cmd {
+	return tea.Tick(dashboardRefresh, func(t time.Time) tea.Msg { return refreshMsg(t) })
+}
+
+// sparkline draws latencies as block characters scaled to the largest one.
+// Failed checks, recorded as zero, are drawn as a red cross.
+func sparkline(latencies []time.Duration) string {
+	var largest time.Duration
+	for _, latency := range latencies {
+		largest = max(largest, latency)
+	}
+
+	var b strings.Builder
+	for _, latency := range latencies {
+		if latency == 0 {
+			b.WriteString(statusStyles[healthcheck.StatusDown].Render("×"))
+			continue
+		}
+		level := int(float64(latency) / float64(largest) * float64(len(sparkBlocks)-1))
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// formatMillis formats a latency in milliseconds, or "-" when there is none
+func formatMillis(ms float64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", ms)
+}
@@ -0,0 +1,387 @@
+package healthcheck
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Alert describes a change in the state of an endpoint
+type Alert struct {
+	Endpoint Configuration
+	Previous Status
+	Current  Status
+	// Result is the check that caused the transition
+	Result Result
+	// ConsecutiveFailures is the number of failed checks in a row, including this one
+	ConsecutiveFailures int
+	// Reason, when set, describes an alert that is not a state transition,
+	// such as an exhausted error budget
+	Reason string
+}
+
+// Opens reports whether the alert opens an incident for its endpoint in
+// incident management notifiers. Repeated DOWN alerts open it again, which
+// these services deduplicate.
+func (a Alert) Opens() bool {
+	return a.Current == StatusDown
+}
+
+// Resolves reports whether the alert closes the incident of its endpoint
+func (a Alert) Resolves() bool {
+	return a.Current == StatusUp && (a.Previous == StatusDown || a.Reason == "")
+}
+
+// NotifierConfig configures a set of notifiers alerts are sent to together
+type NotifierConfig struct {
+	WebhookURL string           `yaml:"webhook_url,omitempty"`
+	Email      *EmailConfig     `yaml:"email,omitempty"`
+	Opsgenie   *OpsgenieConfig  `yaml:"opsgenie,omitempty"`
+	VictorOps  *VictorOpsConfig `yaml:"victorops,omitempty"`
+	// Datadog posts an event for every alert
+	Datadog *DatadogConfig `yaml:"datadog,omitempty"`
+	// Microsoft Teams and Discord webhooks receive alerts as cards and embeds
+	TeamsWebhookURL   string `yaml:"teams_webhook_url,omitempty"`
+	DiscordWebhookURL string `yaml:"discord_webhook_url,omitempty"`
+}
+
+// AlertingConfig is the alerting section of the configuration file. Its
+// notifiers receive the alerts no route matches, and Notifiers defines named
+// sets that alert routes refer to.
+type AlertingConfig struct {
+	NotifierConfig `yaml:",inline"`
+	Notifiers      map[string]NotifierConfig `yaml:"notifiers,omitempty"`
+}
+
+// expandEnv interpolates environment variables into the alerting secrets
+func (c *AlertingConfig) expandEnv(env envLookup) error {
+	if err := c.NotifierConfig.expandEnv(env); err != nil {
+		return err
+	}
+	for name, notifier := range c.Notifiers {
+		if err := notifier.expandEnv(env); err != nil {
+			return fmt.Errorf("notifier '%s': %v", name, err)
+		}
+		c.Notifiers[name] = notifier
+	}
+	return nil
+}
+
+// expandEnv interpolates environment variables into the notifier secrets
+func (c *NotifierConfig) expandEnv(env envLookup) error {
+	fields := []*string{&c.WebhookURL, &c.TeamsWebhookURL, &c.DiscordWebhookURL}
+	if c.Email != nil {
+		fields = append(fields, &c.Email.Username, &c.Email.Password)
+	}
+	if c.Opsgenie != nil {
+		fields = append(fields, &c.Opsgenie.APIKey)
+	}
+	if c.VictorOps != nil {
+		fields = append(fields, &c.VictorOps.URL, &c.VictorOps.RoutingKey)
+	}
+	if c.Datadog != nil {
+		fields = append(fields, &c.Datadog.APIKey)
+	}
+	for _, field := range fields {
+		expanded, err := expandEnv(*field, env)
+		if err != nil {
+			return err
+		}
+		*field = expanded
+	}
+	return nil
+}
+
+// Notifier delivers alerts to an external system
+type Notifier interface {
+	Notify(a Alert) error
+}
+
+// Alerter sends alerts to the notifiers of the routes matching the endpoint,
+// or to the default notifiers when no route matches
+type Alerter struct {
+	Notifiers []Notifier
+	// Routes are evaluated in order, see AlertRoute
+	Routes []Route
+	// Logger receives notification errors. Defaults to the standard logger.
+	Logger *log.Logger
+	// Leader, when set, reports whether this instance leads its HA pair.
+	// Alerts are tracked by both instances but only sent by the leader, and
+	// a standby taking over sends the DOWN alerts of ongoing outages.
+	Leader func() bool
+
+	mu sync.Mutex
+	// notified is when a DOWN alert was last sent for an endpoint URL on a
+	// route or one of its escalations, to repeat it every RepeatInterval and
+	// to send the recovery to the escalations that were notified
+	notified map[routeKey]time.Time
+	// downSince is when each endpoint that is DOWN went down
+	downSince map[string]time.Time
+	// held are the DOWN alerts the standby or a maintenance window didn't
+	// send, sent by Remind once this instance leads and the endpoint is
+	// still DOWN outside the window
+	held map[routeKey]Alert
+}
+
+// Route is an alert route with the notifiers it sends to
+type Route struct {
+	AlertRoute
+	Notifiers []Notifier
+	// EscalationNotifiers are the notifiers of each of AlertRoute.Escalations
+	EscalationNotifiers [][]Notifier
+}
+
+// routeKey identifies an endpoint on a route, or on one of its escalations
+// when escalation is above 0. The default notifiers are route -1.
+type routeKey struct {
+	route      int
+	escalation int
+	url        string
+}
+
+// NewAlerter returns an Alerter sending alerts to the given notifiers
+func NewAlerter(notifiers ...Notifier) *Alerter {
+	return &Alerter{
+		Notifiers: notifiers,
+		Logger:    log.Default(),
+	}
+}
+
+// Send delivers an alert to every notifier of the matching routes. An
+// endpoint leaving the UNKNOWN state only alerts when it goes DOWN. The
+// recovery of an escalated outage is also sent to the escalation notifiers.
+func (a *Alerter) Send(alert Alert) {
+	if alert.Previous == StatusUnknown && alert.Current == StatusUp {
+		return
+	}
+
+	a.mu.Lock()
+	a.init()
+	url := alert.Endpoint.Url
+	standby := a.standby()
+	var escalated []routeKey
+	switch {
+	case alert.Current == StatusDown && alert.Previous != StatusDown:
+		// Alerts with a reason, such as a regional outage, don't change the
+		// state of the endpoint, which may already be DOWN
+		if alert.Reason == "" {
+			a.downSince[url] = alert.Result.Time
+		}
+	case alert.Current != StatusDown:
+		delete(a.downSince, url)
+		for key := range a.notified {
+			if key.url != url {
+				continue
+			}
+			if key.escalation > 0 {
+				escalated = append(escalated, key)
+			}
+			delete(a.notified, key)
+		}
+		for key := range a.held {
+			if key.url == url {
+				delete(a.held, key)
+			}
+		}
+	}
+	routes := a.match(alert.Endpoint)
+	if alert.Current == StatusDown && alert.Reason == "" {
+		for _, route := range routes {
+			key := routeKey{route: route, url: url}
+			if standby {
+				a.held[key] = alert
+			} else {
+				a.notified[key] = alert.Result.Time
+			}
+		}
+	}
+	a.mu.Unlock()
+
+	for _, route := range routes {
+		a.notify(a.notifiers(route), alert)
+	}
+	for _, key := range escalated {
+		a.notify(a.escalationNotifiers(key), alert)
+	}
+}
+
+// Hold records an alert suppressed by a maintenance window. A DOWN alert is
+// sent by Remind if the endpoint is still DOWN once the window is over, and
+// discarded if the endpoint recovers within it.
+func (a *Alerter) Hold(alert Alert) {
+	if alert.Reason != "" {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.init()
+	url := alert.Endpoint.Url
+	switch {
+	case alert.Current == StatusDown && alert.Previous != StatusDown:
+		a.downSince[url] = alert.Result.Time
+		for _, route := range a.match(alert.Endpoint) {
+			a.held[routeKey{route: route, url: url}] = alert
+		}
+	case alert.Current != StatusDown:
+		delete(a.downSince, url)
+		for key := range a.held {
+			if key.url == url {
+				delete(a.held, key)
+			}
+		}
+	}
+}
+
+// Remind is called with every check of an endpoint that stays DOWN. It
+// repeats the DOWN alert on the matching routes whose repeat interval has
+// elapsed since the last one, and escalates it to the notifiers of the
+// escalations whose delay has elapsed since the endpoint went down. The
+// DOWN alerts held back by a maintenance window, or while this instance was
+// the standby, are sent first.
+func (a *Alerter) Remind(alert Alert) {
+	now := alert.Result.Time
+	url := alert.Endpoint.Url
+
+	a.mu.Lock()
+	a.init()
+	since, ok := a.downSince[url]
+	if !ok {
+		since = now
+		a.downSince[url] = now
+	}
+	if a.standby() {
+		a.mu.Unlock()
+		return
+	}
+	var due, held []routeKey
+	var downAlerts []Alert
+	for _, route := range a.match(alert.Endpoint) {
+		key := routeKey{route: route, url: url}
+		if down, ok := a.held[key]; ok {
+			delete(a.held, key)
+			a.notified[key] = now
+			held = append(held, key)
+			downAlerts = append(downAlerts, down)
+		}
+		interval := a.repeatInterval(route)
+		if interval > 0 && now.Sub(a.notified[key]) >= interval {
+			a.notified[key] = now
+			due = append(due, key)
+		}
+		if route < 0 {
+			continue
+		}
+		for i, escalation := range a.Routes[route].Escalations {
+			key := routeKey{route: route, escalation: i + 1, url: url}
+			if _, sent := a.notified[key]; !sent && now.Sub(since) >= escalation.After {
+				a.notified[key] = now
+				due = append(due, key)
+			}
+		}
+	}
+	a.mu.Unlock()
+
+	for i, key := range held {
+		if window := downAlerts[i].Result.Maintenance; window != "" {
+			a.Logger.Printf("Sending the DOWN alert for %s (%s) held back by maintenance window '%s'", alert.Endpoint.Name, url, window)
+		} else {
+			a.Logger.Printf("Sending the DOWN alert for %s (%s) held back while this instance was the standby", alert.Endpoint.Name, url)
+		}
+		a.notify(a.notifiers(key.route), downAlerts[i])
+	}
+	if len(due) == 0 {
+		return
+	}
+	downFor := now.Sub(since).Round(time.Second)
+	for _, key := range due {
+		reminder := alert
+		if key.escalation > 0 {
+			reminder.Reason = fmt.Sprintf("escalated after being DOWN for %s, %d consecutive failures", downFor, alert.ConsecutiveFailures)
+		} else {
+			reminder.Reason = fmt.Sprintf("still DOWN after %s, %d consecutive failures", downFor, alert.ConsecutiveFailures)
+		}
+		if alert.Result.Err != nil {
+			reminder.Reason += fmt.Sprintf(", Error: %v", alert.Result.Err)
+		}
+
+		if key.escalation > 0 {
+			a.notify(a.escalationNotifiers(key), reminder)
+		} else {
+			a.notify(a.notifiers(key.route), reminder)
+		}
+	}
+}
+
+// init allocates the alert state. The caller holds a.mu.
+func (a *Alerter) init() {
+	if a.notified == nil {
+		a.notified = make(map[routeKey]time.Time)
+		a.downSince = make(map[string]time.Time)
+		a.held = make(map[routeKey]Alert)
+	}
+}
+
+// standby reports whether this instance is the standby of its HA pair
+func (a *Alerter) standby() bool {
+	return a.Leader != nil && !a.Leader()
+}
+
+// match returns the index of the routes an endpoint's alerts go to, or -1
+// for the default notifiers when no route matches
+func (a *Alerter) match(req Configuration) []int {
+	var routes []int
+	for i, route := range a.Routes {
+		if !route.Matches(req) {
+			continue
+		}
+		routes = append(routes, i)
+		if !route.Continue {
+			return routes
+		}
+	}
+	if len(routes) == 0 {
+		routes = append(routes, -1)
+	}
+	return routes
+}
+
+// notifiers returns the notifiers of a route index from match
+func (a *Alerter) notifiers(route int) []Notifier {
+	if route < 0 {
+		return a.Notifiers
+	}
+	return a.Routes[route].Notifiers
+}
+
+// escalationNotifiers returns the notifiers of the escalation of a routeKey
+func (a *Alerter) escalationNotifiers(key routeKey) []Notifier {
+	notifiers := a.Routes[key.route].EscalationNotifiers
+	if key.escalation > len(notifiers) {
+		return nil
+	}
+	return notifiers[key.escalation-1]
+}
+
+// repeatInterval returns the repeat interval of a route index from match
+func (a *Alerter) repeatInterval(route int) time.Duration {
+	if route < 0 {
+		return 0
+	}
+	return a.Routes[route].RepeatInterval
+}
+
+// notify sends an alert to each notifier, logging failures. Standby
+// instances don't send alerts.
+func (a *Alerter) notify(notifiers []Notifier, alert Alert) {
+	if len(notifiers) > 0 && a.standby() {
+		a.Logger.Printf("Alert for %s (%s) not sent by the standby instance", alert.Endpoint.Name, alert.Endpoint.Url)
+		return
+	}
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(alert); err != nil {
+			a.Logger.Printf("Failed to send alert for %s (%s): %v", alert.Endpoint.Name, alert.Endpoint.Url, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package healthcheck
+
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingNotifier keeps the alerts it is sent
+type recordingNotifier struct {
+	alerts []Alert
+}
+
+func (n *recordingNotifier) Notify(a Alert) error {
+	n.alerts = append(n.alerts, a)
+	return nil
+}
+
+// reasons returns the reason of every alert sent, with the state of the
+// transitions that have none
+func (n *recordingNotifier) reasons() []string {
+	var reasons []string
+	for _, a := range n.alerts {
+		reason := a.Reason
+		if reason == "" {
+			reason = string(a.Current)
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// expectAlerts fails unless the notifier was sent as many alerts as prefixes,
+// each reason starting with its prefix
+func expectAlerts(t *testing.T, name string, n *recordingNotifier, prefixes ...string) {
+	t.Helper()
+	reasons := n.reasons()
+	if len(reasons) != len(prefixes) {
+		t.Fatalf("%s: got alerts %q, want %q", name, reasons, prefixes)
+	}
+	for i, prefix := range prefixes {
+		if !strings.HasPrefix(reasons[i], prefix) {
+			t.Fatalf("%s: got alerts %q, want %q", name, reasons, prefixes)
+		}
+	}
+}
+
+// transition returns the alert of an endpoint changing state at the given time
+func transition(req Configuration, previous, current Status, at time.Time) Alert {
+	return Alert{
+		Endpoint: req,
+		Previous: previous,
+		Current:  current,
+		Result:   Result{Endpoint: req, Up: current == StatusUp, Time: at},
+	}
+}
+
+// stillDown returns the alert Remind is called with by a check of an endpoint
+// that stays DOWN
+func stillDown(req Configuration, at time.Time) Alert {
+	return transition(req, StatusDown, StatusDown, at)
+}
+
+func TestAlerterMaintenanceHold(t *testing.T) {
+	notifier := &recordingNotifier{}
+	alerter := NewAlerter(notifier)
+	alerter.Logger = log.New(io.Discard, "", 0)
+	start := time.Now()
+
+	// DOWN during the window and still DOWN once it's over
+	down := Configuration{Name: "down", Url: "https://down.example.com"}
+	held := transition(down, StatusUp, StatusDown, start)
+	held.Result.Maintenance = "deploy"
+	alerter.Hold(held)
+	expectAlerts(t, "during the window", notifier)
+
+	alerter.Remind(stillDown(down, start.Add(10*time.Minute)))
+	expectAlerts(t, "after the window", notifier, string(StatusDown))
+	if got := notifier.alerts[0]; got.Previous != StatusUp || !got.Result.Time.Equal(start) {
+		t.Errorf("got the alert of %s at %v, want the held transition at %v", got.Previous, got.Result.Time, start)
+	}
+	alerter.Remind(stillDown(down, start.Add(20*time.Minute)))
+	expectAlerts(t, "later reminder", notifier, string(StatusDown))
+
+	// DOWN and recovered within the window
+	recovered := Configuration{Name: "recovered", Url: "https://recovered.example.com"}
+	alerter.Hold(transition(recovered, StatusUp, StatusDown, start))
+	alerter.Hold(transition(recovered, StatusDown, StatusUp, start.Add(time.Minute)))
+	alerter.Send(transition(recovered, StatusUp, StatusDown, start.Add(time.Hour)))
+	alerter.Remind(stillDown(recovered, start.Add(time.Hour+time.Minute)))
+	expectAlerts(t, "new outage", notifier, string(StatusDown), string(StatusDown))
+	if got := notifier.alerts[1].Result.Time; !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("got the alert of %v, want the new outage only", got)
+	}
+}
+
+func TestAlerterStandbyTakeover(t *testing.T) {
+	notifier := &recordingNotifier{}
+	leader := false
+	alerter := NewAlerter(notifier)
+	alerter.Logger = log.New(io.Discard, "", 0)
+	alerter.Leader = func() bool { return leader }
+	req := Configuration{Name: "api", Url: "https://api.example.com"}
+	start := time.Now()
+
+	alerter.Send(transition(req, StatusUp, StatusDown, start))
+	alerter.Remind(stillDown(req, start.Add(time.Minute)))
+	expectAlerts(t, "standby", notifier)
+
+	// The new leader sends the DOWN alert of the ongoing outage once
+	leader = true
+	alerter.Remind(stillDown(req, start.Add(2*time.Minute)))
+	expectAlerts(t, "takeover", notifier, string(StatusDown))
+	alerter.Remind(stillDown(req, start.Add(3*time.Minute)))
+	alerter.Send(transition(req, StatusDown, StatusUp, start.Add(4*time.Minute)))
+	expectAlerts(t, "recovery", notifier, string(StatusDown), string(StatusUp))
+
+	// An outage over before the takeover isn't alerted
+	leader = false
+	alerter.Send(transition(req, StatusUp, StatusDown, start.Add(5*time.Minute)))
+	alerter.Send(transition(req, StatusDown, StatusUp, start.Add(6*time.Minute)))
+	leader = true
+	alerter.Send(transition(req, StatusUp, StatusDown, start.Add(7*time.Minute)))
+	alerter.Remind(stillDown(req, start.Add(8*time.Minute)))
+	expectAlerts(t, "later outage", notifier, string(StatusDown), string(StatusUp), string(StatusDown))
+}
+
+func TestAlerterEscalation(t *testing.T) {
+	primary, team, manager := &recordingNotifier{}, &recordingNotifier{}, &recordingNotifier{}
+	alerter := &Alerter{
+		Logger: log.New(io.Discard, "", 0),
+		Routes: []Route{{
+			AlertRoute: AlertRoute{
+				RepeatInterval: 30 * time.Minute,
+				Escalations:    []AlertEscalation{{After: 15 * time.Minute}, {After: time.Hour}},
+			},
+			Notifiers:           []Notifier{primary},
+			EscalationNotifiers: [][]Notifier{{team}, {manager}},
+		}},
+	}
+	req := Configuration{Name: "api", Url: "https://api.example.com"}
+	start := time.Now()
+
+	alerter.Send(transition(req, StatusUp, StatusDown, start))
+	// A regional outage alert doesn't restart the outage
+	regional := transition(req, StatusUp, StatusDown, start.Add(5*time.Minute))
+	regional.Reason = "regional outage, 2/3 regions DOWN"
+	alerter.Send(regional)
+	alerter.Remind(stillDown(req, start.Add(10*time.Minute)))
+	expectAlerts(t, "primary", primary, string(StatusDown), "regional outage")
+	expectAlerts(t, "team", team)
+
+	alerter.Remind(stillDown(req, start.Add(15*time.Minute)))
+	alerter.Remind(stillDown(req, start.Add(20*time.Minute)))
+	expectAlerts(t, "team", team, "escalated after being DOWN for 15m0s")
+
+	alerter.Remind(stillDown(req, start.Add(30*time.Minute)))
+	alerter.Remind(stillDown(req, start.Add(time.Hour)))
+	expectAlerts(t, "primary", primary, string(StatusDown), "regional outage", "still DOWN after 30m0s", "still DOWN after 1h0m0s")
+	expectAlerts(t, "manager", manager, "escalated after being DOWN for 1h0m0s")
+
+	// The recovery goes to every escalation that was notified
+	alerter.Send(transition(req, StatusDown, StatusUp, start.Add(70*time.Minute)))
+	expectAlerts(t, "team", team, "escalated", string(StatusUp))
+	expectAlerts(t, "manager", manager, "escalated", string(StatusUp))
+
+	// The escalation delays of a new outage start over
+	restart := start.Add(2 * time.Hour)
+	alerter.Send(transition(req, StatusUp, StatusDown, restart))
+	alerter.Remind(stillDown(req, restart.Add(10*time.Minute)))
+	expectAlerts(t, "team", team, "escalated", string(StatusUp))
+	alerter.Remind(stillDown(req, restart.Add(15*time.Minute)))
+	expectAlerts(t, "team", team, "escalated", string(StatusUp), "escalated after being DOWN for 15m0s")
+}
@@ -0,0 +1,128 @@
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// TypeAMQP is an AMQP 0-9-1 broker check, e.g. RabbitMQ
+const TypeAMQP = "amqp"
+
+// AMQPChecker checks that an AMQP broker accepts a connection to the virtual
+// host of the URL and opens a channel on it
+type AMQPChecker struct {
+	// LatencyThreshold is the maximum latency until the channel is open for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the check unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeAMQP, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewAMQPChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewAMQPChecker returns an AMQPChecker using the given latency threshold and default timeout
+func NewAMQPChecker(latencyThreshold, timeout time.Duration) *AMQPChecker {
+	return &AMQPChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check connects, opens a channel and closes the connection, measuring the
+// latency until the channel was open
+func (c *AMQPChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	config := amqp.Config{
+		Locale:     "en_US",
+		Properties: amqp.Table{"connection_name": "healthcheck"},
+	}
+	if req.Auth != nil {
+		username, password, err := req.Auth.basicCredentials()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		config.SASL = []amqp.Authentication{&amqp.PlainAuth{Username: username, Password: password}}
+	}
+	if req.TLS != nil {
+		tlsConfig, err := req.TLS.load()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		config.TLSClientConfig = tlsConfig
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+
+	// The client has no context support, the connection is closed to abandon
+	// the check instead
+	var mu sync.Mutex
+	var netConn net.Conn
+	config.Dial = func(network, addr string) (net.Conn, error) {
+		conn, err := dialService(ctx, req, addr, false)
+		mu.Lock()
+		defer mu.Unlock()
+		netConn = conn
+		return conn, err
+	}
+	done := make(chan error, 1)
+
+	startTime := time.Now()
+	go func() {
+		conn, err := amqp.DialConfig(amqpUrl(req.Url), config)
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		channel, err := conn.Channel()
+		if err == nil {
+			channel.Close()
+		}
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		mu.Lock()
+		if netConn != nil {
+			netConn.Close()
+		}
+		mu.Unlock()
+		err = ctx.Err()
+	}
+	result.Latency = time.Since(startTime)
+
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if netConn != nil {
+		result.RemoteAddr = netConn.RemoteAddr().String()
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// amqpUrl returns the AMQP URI of an endpoint URL, accepting a plain host[:port]
+func amqpUrl(rawUrl string) string {
+	if !strings.Contains(rawUrl, "://") {
+		return "amqp://" + rawUrl
+	}
+	return rawUrl
+}
@@ -0,0 +1,148 @@
+package healthcheck
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Defaults of latency anomaly detection
+const (
+	DefaultAnomalySensitivity = 3.0
+	DefaultAnomalyAlpha       = 0.1
+	DefaultAnomalyWarmup      = 20
+	DefaultAnomalyConsecutive = 3
+)
+
+// anomalyMinIncrease is the least relative increase over the baseline mean
+// that counts as an anomaly, so a very stable endpoint isn't flagged for a
+// jitter of a few milliseconds
+const anomalyMinIncrease = 0.2
+
+// AnomalyConfig enables latency anomaly detection on an endpoint. The latency
+// of successful checks is compared to a baseline, an exponentially weighted
+// moving average (EWMA) of the latency and its variance, and the endpoint is
+// DEGRADED while it is consistently slower than usual, even below its latency
+// threshold.
+type AnomalyConfig struct {
+	// Sensitivity is the number of standard deviations above the baseline a
+	// latency must be to be anomalous (default: 3)
+	Sensitivity float64 `yaml:"sensitivity,omitempty"`
+	// Alpha is the weight of a new check in the baseline, between 0 and 1
+	// (default: 0.1). Smaller values make the baseline slower to adapt.
+	Alpha float64 `yaml:"alpha,omitempty"`
+	// Warmup is the number of checks the baseline is learned from before
+	// anomalies are detected (default: 20)
+	Warmup int `yaml:"warmup,omitempty"`
+	// Consecutive is the number of anomalous checks in a row that make the
+	// endpoint DEGRADED, and of normal checks that recover it (default: 3)
+	Consecutive int `yaml:"consecutive,omitempty"`
+	// MinDeviation is the least latency increase over the baseline that
+	// counts as an anomaly
+	MinDeviation time.Duration `yaml:"min_deviation,omitempty"`
+}
+
+// validate checks the anomaly detection settings
+func (c AnomalyConfig) validate() error {
+	if c.Sensitivity < 0 || c.Warmup < 0 || c.Consecutive < 0 || c.MinDeviation < 0 {
+		return fmt.Errorf("anomaly settings can't be negative")
+	}
+	if c.Alpha < 0 || c.Alpha >= 1 {
+		return fmt.Errorf("anomaly alpha must be between 0 and 1")
+	}
+	return nil
+}
+
+func (c AnomalyConfig) sensitivity() float64 {
+	if c.Sensitivity > 0 {
+		return c.Sensitivity
+	}
+	return DefaultAnomalySensitivity
+}
+
+func (c AnomalyConfig) alpha() float64 {
+	if c.Alpha > 0 {
+		return c.Alpha
+	}
+	return DefaultAnomalyAlpha
+}
+
+func (c AnomalyConfig) warmup() int {
+	if c.Warmup > 0 {
+		return c.Warmup
+	}
+	return DefaultAnomalyWarmup
+}
+
+func (c AnomalyConfig) consecutive() int {
+	if c.Consecutive > 0 {
+		return c.Consecutive
+	}
+	return DefaultAnomalyConsecutive
+}
+
+// LatencyBaseline is the EWMA of the latency of an endpoint and of its
+// variance, in milliseconds
+type LatencyBaseline struct {
+	Mean     float64
+	Variance float64
+	Samples  int
+
+	anomalies int // Consecutive anomalous checks
+	normal    int // Consecutive normal checks
+}
+
+// StdDev returns the standard deviation of the baseline in milliseconds
+func (b LatencyBaseline) StdDev() float64 {
+	return math.Sqrt(b.Variance)
+}
+
+// limit returns the latency in milliseconds above which a check is anomalous
+func (b LatencyBaseline) limit(config AnomalyConfig) float64 {
+	deviation := max(config.sensitivity()*b.StdDev(), anomalyMinIncrease*b.Mean, milliseconds(config.MinDeviation))
+	return b.Mean + deviation
+}
+
+// observe compares the latency of a successful check to the baseline.
+// Anomalous latencies are kept out of the baseline so a sustained slowdown
+// stays flagged.
+func (b *LatencyBaseline) observe(config AnomalyConfig, latency time.Duration) {
+	ms := milliseconds(latency)
+	if b.Samples >= config.warmup() && ms > b.limit(config) {
+		b.anomalies++
+		b.normal = 0
+		return
+	}
+	b.normal++
+	b.anomalies = 0
+
+	if b.Samples == 0 {
+		b.Mean = ms
+	} else {
+		// Incremental EWMA of the mean and variance
+		alpha := config.alpha()
+		diff := ms - b.Mean
+		increment := alpha * diff
+		b.Mean += increment
+		b.Variance = (1 - alpha) * (b.Variance + diff*increment)
+	}
+	b.Samples++
+}
+
+// updateDegraded feeds the latency of a successful check to the baseline. The
+// endpoint becomes DEGRADED after the configured number of anomalous checks in
+// a row and recovers after as many normal ones.
+func (s *EndpointState) updateDegraded(config AnomalyConfig, latency time.Duration) {
+	s.Baseline.observe(config, latency)
+	switch {
+	case !s.Degraded && s.Baseline.anomalies >= config.consecutive():
+		s.Degraded = true
+	case s.Degraded && s.Baseline.normal >= config.consecutive():
+		s.Degraded = false
+	}
+}
+
+// anomalyReason describes the latency of a check compared to the baseline
+func anomalyReason(latency time.Duration, baseline LatencyBaseline) string {
+	return fmt.Sprintf("latency %v vs baseline %.1fms ± %.1fms", latency.Round(time.Millisecond), baseline.Mean, baseline.StdDev())
+}
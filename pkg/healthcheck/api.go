@@ -0,0 +1,294 @@
+package healthcheck
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// EndpointStatus is the live state of an endpoint served by the status API
+type EndpointStatus struct {
+	EndpointSummary
+	Domain               string    `json:"domain"`
+	Status               Status    `json:"status"`
+	Since                time.Time `json:"since"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes"`
+	Flapping             bool      `json:"flapping"`
+	StateChange          float64   `json:"state_change_pct"`
+	// Degraded is set while the latency is anomalous compared to the
+	// baseline, for endpoints with anomaly detection
+	Degraded          bool    `json:"degraded"`
+	BaselineLatencyMs float64 `json:"baseline_latency_ms,omitempty"`
+	// Skipped is set while the endpoint isn't checked because the endpoint
+	// it depends on is DOWN
+	Skipped   bool   `json:"skipped"`
+	DependsOn string `json:"depends_on,omitempty"`
+	// SHA256 is the checksum of the last response body, for endpoints
+	// detecting content changes
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Status returns the live state, counters and latency stats of every endpoint
+func (s *Scheduler) Status() []EndpointStatus {
+	endpoints := s.Endpoints()
+	summaries := Summarize(endpoints, s.Store.Snapshot())
+
+	statuses := make([]EndpointStatus, len(endpoints))
+	for i, req := range endpoints {
+		state := s.State.Get(req.Url)
+		statuses[i] = EndpointStatus{
+			EndpointSummary:      summaries[i],
+			Domain:               req.Domain(),
+			Status:               state.Status,
+			Since:                state.Since,
+			ConsecutiveFailures:  state.ConsecutiveFailures,
+			ConsecutiveSuccesses: state.ConsecutiveSuccesses,
+			Flapping:             state.Flapping,
+			StateChange:          state.StateChange,
+			Degraded:             state.Degraded,
+			BaselineLatencyMs:    state.Baseline.Mean,
+			Skipped:              state.Skipped,
+			DependsOn:            req.DependsOn,
+			SHA256:               state.Checksum,
+		}
+	}
+	return statuses
+}
+
+// Incidents returns the recent and ongoing incidents of the endpoints, or of
+// the endpoint with the given name when set, newest first
+func (s *Scheduler) Incidents(name string) []Incident {
+	availability := s.Store.Snapshot()
+	incidents := []Incident{}
+	for _, req := range s.Endpoints() {
+		if name != "" && req.Name != name {
+			continue
+		}
+		incidents = append(incidents, availability[req.Url].Incidents.Timeline()...)
+	}
+	slices.SortStableFunc(incidents, func(a, b Incident) int {
+		return b.Start.Compare(a.Start)
+	})
+	return incidents
+}
+
+// APIHandler returns an http.Handler serving the status API:
+//
+//	GET    /api/status            state of every endpoint
+//	GET    /api/endpoints/{name}  state of a single endpoint
+//	GET    /api/tags              combined availability per tag
+//	GET    /api/incidents         incidents of every endpoint, newest first
+//	GET    /api/silences          maintenance windows and silences
+//	POST   /api/silences          add a silence
+//	DELETE /api/silences/{id}     remove a silence
+//	GET    /api/regions           state of every endpoint per region
+//	POST   /api/probes            report results from a probe agent
+func (s *Scheduler) APIHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"endpoints": s.Status(),
+		})
+	})
+
+	mux.HandleFunc("GET /api/endpoints/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		for _, status := range s.Status() {
+			if status.Name == name {
+				writeJSON(w, http.StatusOK, status)
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "endpoint not found"})
+	})
+
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"tags": SummarizeTags(s.Endpoints(), s.Store.Snapshot()),
+		})
+	})
+
+	mux.HandleFunc("GET /api/incidents", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"incidents": s.Incidents(r.URL.Query().Get("endpoint")),
+		})
+	})
+
+	mux.HandleFunc("GET /api/silences", func(w http.ResponseWriter, r *http.Request) {
+		if s.Maintenance == nil {
+			writeJSON(w, http.StatusOK, map[string]any{"silences": []MaintenanceWindow{}})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"silences": s.Maintenance.Windows()})
+	})
+
+	mux.HandleFunc("POST /api/silences", func(w http.ResponseWriter, r *http.Request) {
+		if s.Maintenance == nil {
+			writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "maintenance windows are not enabled"})
+			return
+		}
+
+		if !s.authorizeSilences(w, r) {
+			return
+		}
+
+		var req silenceRequest
+		if !readJSON(w, r, maxSilenceRequestSize, &req) {
+			return
+		}
+		silence, err := req.window()
+		if err == nil {
+			silence, err = s.Maintenance.AddSilence(silence)
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, silence)
+	})
+
+	mux.HandleFunc("DELETE /api/silences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeSilences(w, r) {
+			return
+		}
+		if s.Maintenance == nil || !s.Maintenance.RemoveSilence(r.PathValue("id")) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "silence not found"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /api/regions", func(w http.ResponseWriter, r *http.Request) {
+		if s.Regions == nil {
+			writeJSON(w, http.StatusOK, map[string]any{"endpoints": []RegionalEndpoint{}})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"endpoints": s.Regions.Snapshot()})
+	})
+
+	mux.HandleFunc("POST /api/probes", func(w http.ResponseWriter, r *http.Request) {
+		if s.Regions == nil {
+			writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "probe reports are not accepted, run with --coordinator"})
+			return
+		}
+		if s.ProbeToken != "" && !bearer(r, s.ProbeToken) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid probe token"})
+			return
+		}
+
+		var report ProbeReport
+		if !readJSON(w, r, maxProbeReportSize, &report) {
+			return
+		}
+		if report.Region == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "region is required"})
+			return
+		}
+		s.ReceiveProbes(report)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+// maxSilenceRequestSize bounds the body of POST /api/silences
+const maxSilenceRequestSize = 64 << 10
+
+// MaxSilenceDuration is the longest silence the API accepts. Longer
+// maintenance belongs in the config file.
+const MaxSilenceDuration = 7 * 24 * time.Hour
+
+// authorizeSilences checks the bearer token of a request changing the
+// silences. Changes are refused when no token is configured. On failure it
+// writes the error response and returns false.
+func (s *Scheduler) authorizeSilences(w http.ResponseWriter, r *http.Request) bool {
+	switch {
+	case s.APIToken == "":
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "silences can't be changed, run with --api-token"})
+		return false
+	case !bearer(r, s.APIToken):
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid API token"})
+		return false
+	}
+	return true
+}
+
+// bearer reports whether the request presents the given bearer token
+func bearer(r *http.Request, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) == 1
+}
+
+// silenceRequest is the body of POST /api/silences. Start defaults to now and
+// the end can be given directly or as a duration such as "2h". A silence
+// without endpoints or tags mutes every endpoint, so it requires All.
+type silenceRequest struct {
+	Name      string    `json:"name"`
+	Endpoints []string  `json:"endpoints"`
+	Tags      []string  `json:"tags"`
+	All       bool      `json:"all"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Duration  string    `json:"duration"`
+}
+
+// window converts the request into a maintenance window
+func (r silenceRequest) window() (MaintenanceWindow, error) {
+	if len(r.Endpoints) == 0 && len(r.Tags) == 0 && !r.All {
+		return MaintenanceWindow{}, fmt.Errorf("a silence without endpoints or tags mutes every endpoint, set \"all\": true to confirm")
+	}
+	window := MaintenanceWindow{
+		Name:      r.Name,
+		Endpoints: r.Endpoints,
+		Tags:      r.Tags,
+		Start:     r.Start,
+		End:       r.End,
+	}
+	if window.Start.IsZero() {
+		window.Start = time.Now()
+	}
+	if r.Duration != "" {
+		duration, err := time.ParseDuration(r.Duration)
+		if err != nil {
+			return MaintenanceWindow{}, err
+		}
+		window.Duration = duration
+	}
+	end := window.End
+	if end.IsZero() {
+		end = window.Start.Add(window.Duration)
+	}
+	if end.Sub(window.Start) > MaxSilenceDuration {
+		return MaintenanceWindow{}, fmt.Errorf("silences are limited to %s", MaxSilenceDuration)
+	}
+	if window.Name == "" {
+		window.Name = "silence"
+	}
+	return window, nil
+}
+
+// readJSON decodes a JSON request body of at most limit bytes into v. On
+// failure it writes the error response and returns false.
+func readJSON(w http.ResponseWriter, r *http.Request, limit int64, v any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(v)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("request body is larger than %d bytes", limit)})
+	case err != nil:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return err == nil
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,122 @@
+package healthcheck
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+)
+
+// maxBodySize caps how much of a response body is read for assertions
+const maxBodySize = 1 << 20
+
+// wantsBody reports whether any assertion needs the response body
+func (c Configuration) wantsBody() bool {
+	return c.ExpectBodyContains != "" || c.ExpectBodyRegex != "" || len(c.ExpectBodyNotContains) > 0
+}
+
+// assertBody checks the response body against the endpoint's content assertions
+func assertBody(req Configuration, body []byte) error {
+	if req.ExpectBodyContains != "" && !bytes.Contains(body, []byte(req.ExpectBodyContains)) {
+		return fmt.Errorf("response body does not contain %q", req.ExpectBodyContains)
+	}
+
+	if req.ExpectBodyRegex != "" {
+		re, err := compiled(req.bodyRegex, req.ExpectBodyRegex)
+		if err != nil {
+			return fmt.Errorf("invalid expect_body_regex %q: %v", req.ExpectBodyRegex, err)
+		}
+		if !re.Match(body) {
+			return fmt.Errorf("response body does not match %q", req.ExpectBodyRegex)
+		}
+	}
+
+	for _, keyword := range req.ExpectBodyNotContains {
+		if bytes.Contains(body, []byte(keyword)) {
+			return fmt.Errorf("response body contains %q", keyword)
+		}
+	}
+
+	return nil
+}
+
+// assertHeaders checks the response headers against the endpoint's header
+// assertions. A header sent several times passes if any of its values matches.
+func assertHeaders(req Configuration, header http.Header) error {
+	for name, expected := range req.ExpectHeaders {
+		values := header.Values(name)
+		if len(values) == 0 {
+			return fmt.Errorf("response has no %s header", name)
+		}
+		if !slices.Contains(values, expected) {
+			return fmt.Errorf("response header %s is %q, expected %q", name, values[0], expected)
+		}
+	}
+
+	for name, pattern := range req.ExpectHeadersRegex {
+		re, err := compiled(req.headersRegex[name], pattern)
+		if err != nil {
+			return fmt.Errorf("invalid expect_headers_regex %q for %s: %v", pattern, name, err)
+		}
+		values := header.Values(name)
+		if len(values) == 0 {
+			return fmt.Errorf("response has no %s header", name)
+		}
+		if !slices.ContainsFunc(values, re.MatchString) {
+			return fmt.Errorf("response header %s is %q, expected to match %q", name, values[0], pattern)
+		}
+	}
+
+	return nil
+}
+
+// compileAssertions compiles the regular expressions of the response
+// assertions of the endpoint and its transaction steps, so invalid ones are
+// reported with the configuration rather than on every check
+func (c *Configuration) compileAssertions() error {
+	var err error
+	if c.bodyRegex, c.headersRegex, err = compilePatterns(c.ExpectBodyRegex, c.ExpectHeadersRegex); err != nil {
+		return err
+	}
+	for i := range c.Steps {
+		step := &c.Steps[i]
+		if step.bodyRegex, step.headersRegex, err = compilePatterns(step.ExpectBodyRegex, step.ExpectHeadersRegex); err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// compilePatterns compiles a body regex and header regexes, when set
+func compilePatterns(body string, headers map[string]string) (*regexp.Regexp, map[string]*regexp.Regexp, error) {
+	var bodyRegex *regexp.Regexp
+	if body != "" {
+		re, err := regexp.Compile(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid expect_body_regex %q: %v", body, err)
+		}
+		bodyRegex = re
+	}
+	var headersRegex map[string]*regexp.Regexp
+	for name, pattern := range headers {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid expect_headers_regex %q for %s: %v", pattern, name, err)
+		}
+		if headersRegex == nil {
+			headersRegex = make(map[string]*regexp.Regexp, len(headers))
+		}
+		headersRegex[name] = re
+	}
+	return bodyRegex, headersRegex, nil
+}
+
+// compiled returns the expression compiled with the configuration, or
+// compiles it for endpoints that weren't parsed from one
+func compiled(re *regexp.Regexp, pattern string) (*regexp.Regexp, error) {
+	if re != nil && re.String() == pattern {
+		return re, nil
+	}
+	return regexp.Compile(pattern)
+}
@@ -0,0 +1,97 @@
+package healthcheck
+
+import "time"
+
+// Availability struct to track UP and DOWN counts and latency metrics
+type Availability struct {
+	SuccessCount int
+	FailureCount int
+	// SlowCount is the number of failures that were latency threshold
+	// breaches rather than hard failures. They are included in FailureCount.
+	SlowCount int
+	// MaintenanceFailures counts failures during maintenance windows. They
+	// are kept out of FailureCount so planned work doesn't lower availability.
+	MaintenanceFailures int
+	TotalLatency        time.Duration
+	MinLatency          time.Duration
+	MaxLatency          time.Duration
+	// Latencies holds the latency of every check that received a response,
+	// including slow ones, so percentiles show tail behavior
+	Latencies LatencyHistogram
+	// Phases accumulates the latency breakdown of HTTP checks that received a response
+	Phases PhaseStats
+	// Transfers accumulates the response sizes and download times of the same checks
+	Transfers TransferStats
+	// Recent holds the latest latencies, with failed checks as zero
+	Recent RecentLatencies
+	// Windows tracks outcomes over time for rolling availability
+	Windows RollingWindows
+	// Incidents tracks the periods of consecutive failures
+	Incidents IncidentStats
+	// Causes counts the failures by cause
+	Causes FailureCauses
+}
+
+// Record updates the counters and latency metrics with a check result
+func (a *Availability) Record(r Result) {
+	if !r.Up && r.Maintenance != "" {
+		a.MaintenanceFailures++
+		return
+	}
+
+	a.Windows.Add(r.Time, r.Up)
+	a.Incidents.record(r)
+	if r.Err == nil || r.StatusCode != 0 {
+		a.Latencies.Add(r.Latency)
+		if r.Phases.TTFB > 0 {
+			a.Phases.Add(r.Phases)
+			a.Transfers.Add(r)
+		}
+	}
+
+	if !r.Up {
+		a.FailureCount++
+		if r.Slow {
+			a.SlowCount++
+		}
+		a.Causes.add(r.Cause())
+		a.Recent.Add(0)
+		return
+	}
+	a.Recent.Add(r.Latency)
+
+	a.SuccessCount++
+	a.TotalLatency += r.Latency
+
+	// Update MinLatency
+	if a.MinLatency == 0 || r.Latency < a.MinLatency {
+		a.MinLatency = r.Latency
+	}
+	// Update MaxLatency
+	if r.Latency > a.MaxLatency {
+		a.MaxLatency = r.Latency
+	}
+}
+
+// Total returns the number of checks recorded
+func (a Availability) Total() int {
+	return a.SuccessCount + a.FailureCount
+}
+
+// Percentage returns the share of successful checks rounded to the nearest whole number
+func (a Availability) Percentage() int {
+	total := a.Total()
+	if total == 0 {
+		return 0
+	}
+	percentage := (float64(a.SuccessCount) / float64(total)) * 100
+	return int(percentage + 0.5)
+}
+
+// AverageLatency returns the mean latency of successful checks
+func (a Availability) AverageLatency() time.Duration {
+	if a.SuccessCount == 0 {
+		return 0
+	}
+	return time.Duration(int64(a.TotalLatency) / int64(a.SuccessCount))
+}
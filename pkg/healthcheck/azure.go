@@ -0,0 +1,179 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// DefaultAzureNamespace is the namespace of the custom metrics written to
+// Azure Monitor
+const DefaultAzureNamespace = "Healthcheck"
+
+// azureMonitorScope is the token scope of the Azure Monitor custom metrics API
+const azureMonitorScope = "https://monitoring.azure.com/.default"
+
+// AzureMonitorConfig configures the Azure Monitor output
+type AzureMonitorConfig struct {
+	// ResourceID is the Azure resource the metrics are attached to, e.g. the
+	// virtual machine or container app running the monitor
+	ResourceID string `yaml:"resource_id"`
+	// Region is the Azure region of the resource, e.g. westeurope
+	Region string `yaml:"region"`
+	// Namespace groups the metrics (default: DefaultAzureNamespace)
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// AzureMonitor is a ResultSink writing custom metrics to Azure Monitor at
+// every summary, with Name and Domain dimensions:
+//
+//	Up            1 or 0, aggregated over the checks since the last summary
+//	Latency       latency in milliseconds, aggregated likewise
+//	Availability  availability percentage
+type AzureMonitor struct {
+	Config     AzureMonitorConfig
+	Credential azcore.TokenCredential
+	Client     *http.Client
+	// URL is the regional custom metrics endpoint
+	URL string
+
+	mu      sync.Mutex
+	pending map[string]map[string]*azureSeries
+}
+
+// azureSeries is the aggregate of the values of a metric of an endpoint
+type azureSeries struct {
+	DimValues []string `json:"dimValues"`
+	Min       float64  `json:"min"`
+	Max       float64  `json:"max"`
+	Sum       float64  `json:"sum"`
+	Count     int      `json:"count"`
+}
+
+// add aggregates a value into the series
+func (s *azureSeries) add(value float64) {
+	if s.Count == 0 || value < s.Min {
+		s.Min = value
+	}
+	if s.Count == 0 || value > s.Max {
+		s.Max = value
+	}
+	s.Sum += value
+	s.Count++
+}
+
+// NewAzureMonitor returns an AzureMonitor authenticated with the default
+// Azure credential chain: environment variables, workload identity, managed
+// identity or the Azure CLI login
+func NewAzureMonitor(config AzureMonitorConfig) (*AzureMonitor, error) {
+	if config.ResourceID == "" || config.Region == "" {
+		return nil, fmt.Errorf("azure output requires a resource_id and a region")
+	}
+	if config.Namespace == "" {
+		config.Namespace = DefaultAzureNamespace
+	}
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Azure credentials: %v", err)
+	}
+	return &AzureMonitor{
+		Config:     config,
+		Credential: credential,
+		Client:     &http.Client{Timeout: 30 * time.Second},
+		URL:        fmt.Sprintf("https://%s.monitoring.azure.com", config.Region),
+	}, nil
+}
+
+// Observe aggregates the metrics of a check result
+func (a *AzureMonitor) Observe(r Result, stats Availability) {
+	up := 0.0
+	if r.Up {
+		up = 1
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.series("Up", r.Endpoint).add(up)
+	a.series("Latency", r.Endpoint).add(milliseconds(r.Latency))
+}
+
+// series returns the aggregate of a metric of an endpoint, creating it if
+// needed. Must be called with mu held.
+func (a *AzureMonitor) series(metric string, req Configuration) *azureSeries {
+	if a.pending == nil {
+		a.pending = make(map[string]map[string]*azureSeries)
+	}
+	if a.pending[metric] == nil {
+		a.pending[metric] = make(map[string]*azureSeries)
+	}
+	series, ok := a.pending[metric][req.Url]
+	if !ok {
+		series = &azureSeries{DimValues: []string{req.Name, req.Domain()}}
+		a.pending[metric][req.Url] = series
+	}
+	return series
+}
+
+// Flush adds the availability of every endpoint to the aggregated metrics and
+// writes them, one request per metric. The aggregates are cleared even if
+// writing fails.
+func (a *AzureMonitor) Flush(cycle Cycle) error {
+	now := time.Now()
+
+	a.mu.Lock()
+	for _, req := range cycle.Endpoints {
+		stats := cycle.Availability[req.Url]
+		if stats.Total() == 0 {
+			continue
+		}
+		a.series("Availability", req).add(100 * float64(stats.SuccessCount) / float64(stats.Total()))
+	}
+	pending := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	token, err := a.Credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{azureMonitorScope}})
+	if err != nil {
+		return fmt.Errorf("failed to get Azure token: %v", err)
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token.Token}
+	url := strings.TrimSuffix(a.URL, "/") + "/" + strings.TrimPrefix(a.Config.ResourceID, "/") + "/metrics"
+
+	for _, metric := range []string{"Up", "Latency", "Availability"} {
+		if len(pending[metric]) == 0 {
+			continue
+		}
+		series := make([]*azureSeries, 0, len(pending[metric]))
+		for _, s := range pending[metric] {
+			series = append(series, s)
+		}
+		payload := map[string]any{
+			"time": now.UTC().Format(time.RFC3339),
+			"data": map[string]any{
+				"baseData": map[string]any{
+					"metric":    metric,
+					"namespace": a.Config.Namespace,
+					"dimNames":  []string{"Name", "Domain"},
+					"series":    series,
+				},
+			},
+		}
+		if err := postJSON(a.Client, url, headers, payload); err != nil {
+			return fmt.Errorf("failed to write Azure Monitor metrics: %v", err)
+		}
+	}
+	return nil
+}
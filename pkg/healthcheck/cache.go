@@ -0,0 +1,87 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CacheConfig asserts on the caching behavior of an HTTP endpoint, e.g. of a
+// CDN in front of it
+type CacheConfig struct {
+	// ETag requires an ETag header
+	ETag bool `yaml:"etag,omitempty"`
+	// Age requires an Age header, i.e. a response served from a cache, and
+	// MaxAge, when set, requires it to be at most that old
+	Age    bool          `yaml:"age,omitempty"`
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+	// Conditional revalidates the response with If-None-Match or
+	// If-Modified-Since and expects 304 Not Modified
+	Conditional bool `yaml:"conditional,omitempty"`
+}
+
+// checkCache validates the cache headers of a response and, when enabled,
+// that a conditional request for it returns 304 Not Modified
+func (c *HTTPChecker) checkCache(ctx context.Context, req Configuration, jar http.CookieJar, header http.Header) error {
+	cache := req.Cache
+	etag, lastModified := header.Get("ETag"), header.Get("Last-Modified")
+	if cache.ETag && etag == "" {
+		return fmt.Errorf("response has no ETag header")
+	}
+
+	if cache.Age || cache.MaxAge > 0 {
+		value := header.Get("Age")
+		if value == "" {
+			return fmt.Errorf("response has no Age header, it wasn't served from a cache")
+		}
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid Age header %q", value)
+		}
+		if age := time.Duration(seconds) * time.Second; cache.MaxAge > 0 && age > cache.MaxAge {
+			return fmt.Errorf("response Age is %v, expected at most %v", age, cache.MaxAge)
+		}
+	}
+
+	if !cache.Conditional {
+		return nil
+	}
+	conditional := req.conditional()
+	switch {
+	case etag != "":
+		conditional.Headers["If-None-Match"] = etag
+	case lastModified != "":
+		conditional.Headers["If-Modified-Since"] = lastModified
+	default:
+		return fmt.Errorf("response has no ETag or Last-Modified header to revalidate")
+	}
+	result, _ := c.exchange(ctx, conditional, jar, false)
+	switch {
+	case result.Err != nil:
+		return fmt.Errorf("conditional request failed: %v", result.Err)
+	case !result.Up:
+		return fmt.Errorf("conditional request returned %d, expected 304", result.StatusCode)
+	}
+	return nil
+}
+
+// conditional returns the settings of the revalidation request of an
+// endpoint, which only expects 304 Not Modified
+func (c Configuration) conditional() Configuration {
+	req := c
+	req.Headers = maps.Clone(c.Headers)
+	if req.Headers == nil {
+		req.Headers = make(map[string]string)
+	}
+	req.ExpectedStatus = []int{http.StatusNotModified}
+	req.ExpectBodyContains, req.ExpectBodyRegex, req.ExpectBodyNotContains = "", "", nil
+	req.ExpectHeaders, req.ExpectHeadersRegex = nil, nil
+	req.bodyRegex, req.headersRegex = nil, nil
+	req.MinSize, req.MaxSize = 0, 0
+	req.ExpectSHA256, req.DetectChanges = "", false
+	req.Cache = nil
+	return req
+}
@@ -0,0 +1,137 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// alertField is a labelled value shown in rich chat messages
+type alertField struct {
+	Name  string
+	Value string
+}
+
+// alertTitle is the headline of an alert in rich chat messages
+func alertTitle(a Alert) string {
+	return fmt.Sprintf("%s is %s", a.Endpoint.Name, a.Current)
+}
+
+// alertFields lists the details of an alert in rich chat messages
+func alertFields(a Alert) []alertField {
+	fields := []alertField{
+		{"URL", a.Endpoint.Url},
+		{"Status", fmt.Sprintf("%s (was %s)", a.Current, a.Previous)},
+	}
+	if a.Reason != "" {
+		fields = append(fields, alertField{"Reason", a.Reason})
+	}
+	fields = append(fields, alertField{"Check", resultDetail(a.Result)})
+	if a.Result.Err != nil {
+		fields = append(fields, alertField{"Error", a.Result.Err.Error()})
+	}
+	if a.Current == StatusDown {
+		fields = append(fields, alertField{"Consecutive failures", fmt.Sprint(a.ConsecutiveFailures)})
+	}
+	if a.Endpoint.Severity != "" {
+		fields = append(fields, alertField{"Severity", a.Endpoint.Severity})
+	}
+	return fields
+}
+
+// TeamsNotifier posts alerts as Adaptive Cards to a Microsoft Teams incoming
+// webhook or Workflows URL
+type TeamsNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewTeamsNotifier returns a TeamsNotifier posting to the given URL
+func NewTeamsNotifier(url string) *TeamsNotifier {
+	return &TeamsNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Notify posts the alert as a card with a colored headline and its details as facts
+func (n *TeamsNotifier) Notify(a Alert) error {
+	color := "Warning"
+	switch {
+	case a.Opens():
+		color = "Attention"
+	case a.Resolves():
+		color = "Good"
+	}
+
+	var facts []map[string]string
+	for _, field := range alertFields(a) {
+		facts = append(facts, map[string]string{"title": field.Name, "value": field.Value})
+	}
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body": []map[string]any{
+			{"type": "TextBlock", "text": alertTitle(a), "weight": "Bolder", "size": "Medium", "color": color, "wrap": true},
+			{"type": "FactSet", "facts": facts},
+		},
+	}
+	return postJSON(n.Client, n.URL, nil, map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	})
+}
+
+// Colors of Discord embeds
+const (
+	discordRed    = 0xd83c3e
+	discordGreen  = 0x2ea043
+	discordOrange = 0xf0883e
+)
+
+// DiscordNotifier posts alerts as embeds to a Discord webhook
+type DiscordNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewDiscordNotifier returns a DiscordNotifier posting to the given URL
+func NewDiscordNotifier(url string) *DiscordNotifier {
+	return &DiscordNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Notify posts the alert as an embed colored by the endpoint status
+func (n *DiscordNotifier) Notify(a Alert) error {
+	color := discordOrange
+	switch {
+	case a.Opens():
+		color = discordRed
+	case a.Resolves():
+		color = discordGreen
+	}
+
+	var fields []map[string]any
+	for _, field := range alertFields(a) {
+		// Discord rejects field values over 1024 characters
+		fields = append(fields, map[string]any{"name": field.Name, "value": truncate(field.Value, 1024), "inline": len(field.Value) < 40})
+	}
+	timestamp := a.Result.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return postJSON(n.Client, n.URL, nil, map[string]any{
+		"username": "healthcheck",
+		"embeds": []map[string]any{{
+			"title":     truncate(alertTitle(a), 256),
+			"color":     color,
+			"fields":    fields,
+			"timestamp": timestamp.Format(time.RFC3339),
+		}},
+	})
+}
@@ -0,0 +1,111 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Checker performs a health check against a single endpoint. Implementations
+// must abandon the check when ctx is cancelled.
+type Checker interface {
+	Check(ctx context.Context, req Configuration) Result
+}
+
+// Check types supported in the endpoint configuration
+const (
+	TypeHTTP = "http"
+	TypeTCP  = "tcp"
+	TypeDNS  = "dns"
+)
+
+// DefaultTimeout is the check timeout used when none is configured
+const DefaultTimeout = 1 * time.Second
+
+// DefaultRetryBackoff is the wait before the first retry when an endpoint
+// sets retries without retry_backoff
+const DefaultRetryBackoff = 1 * time.Second
+
+// CheckerFactory creates the Checker of a check type using the given latency
+// threshold and default timeout
+type CheckerFactory func(latencyThreshold, timeout time.Duration) Checker
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]CheckerFactory)
+)
+
+// Register makes a check type available to endpoints configured with
+// `type: <checkType>`. It is meant to be called from init functions and panics
+// if the type is registered twice.
+func Register(checkType string, factory CheckerFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[checkType]; exists {
+		panic(fmt.Sprintf("healthcheck: check type %q registered twice", checkType))
+	}
+	registry[checkType] = factory
+}
+
+// Types returns the registered check types, sorted
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for checkType := range registry {
+		types = append(types, checkType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// registered reports whether a check type has been registered
+func registered(checkType string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	_, exists := registry[checkType]
+	return exists
+}
+
+// TypeChecker dispatches each check to the checker registered for the
+// endpoint type
+type TypeChecker struct {
+	Checkers map[string]Checker
+}
+
+// NewChecker returns a TypeChecker with a checker of every registered type
+// using the given latency threshold and default timeout
+func NewChecker(latencyThreshold, timeout time.Duration) *TypeChecker {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	checkers := make(map[string]Checker, len(registry))
+	for checkType, factory := range registry {
+		checkers[checkType] = factory(latencyThreshold, timeout)
+	}
+	return &TypeChecker{Checkers: checkers}
+}
+
+// Get returns the checker of a check type, or nil if there is none
+func (c *TypeChecker) Get(checkType string) Checker {
+	return c.Checkers[checkType]
+}
+
+// Check runs the checker for the endpoint type. Endpoints without a type are checked over HTTP.
+func (c *TypeChecker) Check(ctx context.Context, req Configuration) Result {
+	checkType := req.Type
+	if checkType == "" {
+		checkType = TypeHTTP
+	}
+
+	checker, exists := c.Checkers[checkType]
+	if !exists {
+		return Result{Endpoint: req, Time: time.Now(), Err: fmt.Errorf("unsupported check type %q", req.Type)}
+	}
+	return checker.Check(ctx, req)
+}
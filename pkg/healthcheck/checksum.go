@@ -0,0 +1,45 @@
+package healthcheck
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// hashesBody reports whether the response body of the endpoint is hashed
+func (c Configuration) hashesBody() bool {
+	return c.ExpectSHA256 != "" || c.DetectChanges
+}
+
+// validateChecksum checks that the expected checksum is a hex SHA-256 digest
+func (c Configuration) validateChecksum() error {
+	if c.ExpectSHA256 == "" {
+		return nil
+	}
+	if digest, err := hex.DecodeString(c.ExpectSHA256); err != nil || len(digest) != 32 {
+		return fmt.Errorf("invalid expect_sha256 %q, expected 64 hexadecimal characters", c.ExpectSHA256)
+	}
+	return nil
+}
+
+// assertChecksum checks the SHA-256 of the response body against the
+// endpoint's expected checksum
+func assertChecksum(req Configuration, checksum string) error {
+	if req.ExpectSHA256 != "" && !strings.EqualFold(checksum, req.ExpectSHA256) {
+		return fmt.Errorf("response body SHA-256 is %s, expected %s", checksum, strings.ToLower(req.ExpectSHA256))
+	}
+	return nil
+}
+
+// changeReason describes a change of the response body between two checks
+func changeReason(previous, current string) string {
+	return fmt.Sprintf("content changed, SHA-256 %s -> %s", shortChecksum(previous), shortChecksum(current))
+}
+
+// shortChecksum abbreviates a checksum for logs and alerts
+func shortChecksum(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
@@ -0,0 +1,129 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// cloudWatchBatchSize is the maximum number of metrics of a PutMetricData call
+const cloudWatchBatchSize = 1000
+
+// CloudWatch is a ResultSink publishing check results as AWS CloudWatch
+// metrics with Name and Domain dimensions:
+//
+//	Up            1 or 0 at every check
+//	Latency       latency of every check in milliseconds
+//	Availability  availability percentage at every summary
+type CloudWatch struct {
+	Namespace string
+	Client    *cloudwatch.Client
+
+	mu      sync.Mutex
+	pending []types.MetricDatum
+}
+
+// NewCloudWatch returns a CloudWatch publishing to the given namespace. The
+// credentials and the region come from the standard AWS environment variables,
+// shared configuration files or the instance role, and region overrides them.
+func NewCloudWatch(ctx context.Context, namespace, region string) (*CloudWatch, error) {
+	var options []func(*config.LoadOptions) error
+	if region != "" {
+		options = append(options, config.WithRegion(region))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %v", err)
+	}
+	if awsConfig.Region == "" {
+		return nil, fmt.Errorf("no AWS region configured, set AWS_REGION")
+	}
+	return &CloudWatch{
+		Namespace: namespace,
+		Client:    cloudwatch.NewFromConfig(awsConfig),
+	}, nil
+}
+
+// Observe buffers the metrics of a check result
+func (c *CloudWatch) Observe(r Result, stats Availability) {
+	up := 0.0
+	if r.Up {
+		up = 1
+	}
+	dimensions := cloudWatchDimensions(r.Endpoint)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending,
+		cloudWatchDatum("Up", dimensions, up, types.StandardUnitNone, r.Time),
+		cloudWatchDatum("Latency", dimensions, milliseconds(r.Latency), types.StandardUnitMilliseconds, r.Time),
+	)
+}
+
+// Flush adds the availability of every endpoint to the buffered metrics and
+// publishes them. The buffer is cleared even if publishing fails so a broken
+// connection doesn't grow memory without bound.
+func (c *CloudWatch) Flush(cycle Cycle) error {
+	now := time.Now()
+
+	c.mu.Lock()
+	for _, req := range cycle.Endpoints {
+		stats := cycle.Availability[req.Url]
+		if stats.Total() == 0 {
+			continue
+		}
+		availability := 100 * float64(stats.SuccessCount) / float64(stats.Total())
+		c.pending = append(c.pending, cloudWatchDatum("Availability", cloudWatchDimensions(req), availability, types.StandardUnitPercent, now))
+	}
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for start := 0; start < len(pending); start += cloudWatchBatchSize {
+		end := min(start+cloudWatchBatchSize, len(pending))
+		_, err := c.Client.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(c.Namespace),
+			MetricData: pending[start:end],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish CloudWatch metrics: %v", err)
+		}
+	}
+	return nil
+}
+
+// cloudWatchDimensions are the dimensions of the metrics of an endpoint
+func cloudWatchDimensions(req Configuration) []types.Dimension {
+	return []types.Dimension{
+		{Name: aws.String("Name"), Value: aws.String(cloudWatchValue(req.Name))},
+		{Name: aws.String("Domain"), Value: aws.String(cloudWatchValue(req.Domain()))},
+	}
+}
+
+// cloudWatchDatum returns a metric value at a time
+func cloudWatchDatum(name string, dimensions []types.Dimension, value float64, unit types.StandardUnit, t time.Time) types.MetricDatum {
+	return types.MetricDatum{
+		MetricName: aws.String(name),
+		Dimensions: dimensions,
+		Value:      aws.Float64(value),
+		Unit:       unit,
+		Timestamp:  aws.Time(t),
+	}
+}
+
+// cloudWatchValue returns a dimension value CloudWatch accepts: 1 to 1024
+// characters
+func cloudWatchValue(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return truncate(s, 1024)
+}
@@ -0,0 +1,104 @@
+package healthcheck
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// decoders decompress response bodies by content encoding
+var decoders = map[string]func(io.Reader) (io.ReadCloser, error){
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"x-gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	// HTTP deflate is zlib-wrapped
+	"deflate": func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+	"br": func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	},
+	"zstd": func(r io.Reader) (io.ReadCloser, error) {
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	},
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// decodeBody returns a reader of the decompressed body of a response read
+// from wire, and its content encoding. Bodies the transport already
+// decompressed, with unsupported encodings or that aren't compressed are
+// returned as is.
+func decodeBody(resp *http.Response, wire io.Reader) (io.ReadCloser, string, error) {
+	if resp.Uncompressed {
+		// The transport requested and decoded gzip itself
+		return io.NopCloser(wire), "gzip", nil
+	}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	decode, ok := decoders[encoding]
+	if !ok || resp.Request.Method == http.MethodHead {
+		return io.NopCloser(wire), encoding, nil
+	}
+	decoded, err := decode(wire)
+	if err != nil {
+		return io.NopCloser(wire), encoding, fmt.Errorf("failed to decode %s response body: %v", encoding, err)
+	}
+	return decoded, encoding, nil
+}
+
+// compressed reports whether the response body was sent compressed
+func (r Result) compressed() bool {
+	return r.Encoding != "" && r.Encoding != "identity"
+}
+
+// received returns the number of body bytes received, compressed or not
+func (r Result) received() int64 {
+	if r.CompressedSize > 0 {
+		return r.CompressedSize
+	}
+	return r.Size
+}
+
+// compressionDetail formats how many responses were compressed and how much
+func compressionDetail(s TransferStats) string {
+	detail := fmt.Sprintf("%d of %d responses compressed", s.Compressed, s.Count)
+	if ratio := s.CompressionRatio(); ratio > 0 {
+		detail += fmt.Sprintf(", to %.1f%% of their size", ratio*100)
+	}
+	return detail
+}
+
+// sizeDetail formats the body size of a result, with its compressed size
+func sizeDetail(r Result) string {
+	detail := formatBytes(float64(r.Size))
+	switch {
+	case r.CompressedSize > 0:
+		detail += fmt.Sprintf(" (%s %s)", formatBytes(float64(r.CompressedSize)), r.Encoding)
+	case r.compressed():
+		detail += fmt.Sprintf(" (%s)", r.Encoding)
+	}
+	return detail
+}
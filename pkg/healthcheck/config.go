@@ -0,0 +1,501 @@
+// Package healthcheck implements the endpoint health checking engine used by
+// the healthcheck command. It can be embedded in other programs to monitor the
+// availability and latency of HTTP services.
+package healthcheck
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration struct to hold endpoint details
+type Configuration struct {
+	Name    string            `yaml:"name"`
+	Url     string            `yaml:"url"`
+	Type    string            `yaml:"type,omitempty"`
+	Method  string            `yaml:"method,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Auth    *Auth             `yaml:"auth,omitempty"`
+	Tags    []string          `yaml:"tags,omitempty"`
+	TLS     *TLSConfig        `yaml:"tls,omitempty"`
+	// Sign adds an HMAC signature header to the requests of HTTP checks
+	Sign *Signature `yaml:"sign,omitempty"`
+	// CookieJar keeps the cookies set by the endpoint across its checks
+	CookieJar bool `yaml:"cookie_jar,omitempty"`
+
+	// Severity ranks the impact of an outage: critical (the default), warning
+	// or info. It is used for alert routing and by --once.
+	Severity string `yaml:"severity,omitempty"`
+
+	// Proxy is the URL of the proxy HTTP checks go through, e.g.
+	// http://proxy.internal:3128, or "direct" to bypass the proxy set in the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	Proxy string `yaml:"proxy,omitempty"`
+	// Resolve connects to this IP, optionally with a port, instead of
+	// resolving the URL host. The Host header and TLS SNI still use the URL
+	// host, to probe a single backend behind a load balancer.
+	Resolve string `yaml:"resolve,omitempty"`
+
+	// IPVersion restricts HTTP and TCP checks to IPv4 ("4") or IPv6 ("6").
+	// Either family is used when empty or "any".
+	IPVersion string `yaml:"ip_version,omitempty"`
+
+	// HTTPVersion "1.1" disables HTTP/2, "2" marks the check DOWN unless
+	// HTTP/2 is negotiated and "3" sends the request over QUIC. The protocol
+	// is negotiated when empty or "any".
+	HTTPVersion string `yaml:"http_version,omitempty"`
+
+	// FollowRedirects disables following redirects when false, so the
+	// redirect response itself is checked. MaxRedirects bounds the number of
+	// redirects followed (default: 10).
+	FollowRedirects *bool `yaml:"follow_redirects,omitempty"`
+	MaxRedirects    int   `yaml:"max_redirects,omitempty"`
+
+	// Body is sent as the request payload, e.g. a JSON document for POST checks
+	Body        string `yaml:"body,omitempty"`
+	ContentType string `yaml:"content_type,omitempty"`
+
+	// LatencyThreshold overrides the global latency threshold for this endpoint
+	LatencyThreshold time.Duration `yaml:"latency_threshold,omitempty"`
+	// Timeout overrides the global check timeout for this endpoint
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Interval overrides the global check interval for this endpoint
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// Schedule is a cron expression in local time the endpoint is checked on
+	// instead of an interval, e.g. "*/5 9-17 * * MON-FRI"
+	Schedule string `yaml:"schedule,omitempty"`
+	// Jitter overrides the global maximum random delay before every check
+	Jitter time.Duration `yaml:"jitter,omitempty"`
+	// DependsOn names the endpoint this one depends on, e.g. a VPN gateway.
+	// Checks are skipped while it is DOWN.
+	DependsOn string `yaml:"depends_on,omitempty"`
+
+	// Retries is how many times a failed check is retried before it counts as
+	// a failure. RetryBackoff is the wait before the first retry, doubled
+	// before every following one (default: 1s).
+	Retries      int           `yaml:"retries,omitempty"`
+	RetryBackoff time.Duration `yaml:"retry_backoff,omitempty"`
+
+	// Number of consecutive failures or successes needed to change the endpoint state. Both default to 1.
+	FailureThreshold int `yaml:"failure_threshold,omitempty"`
+	SuccessThreshold int `yaml:"success_threshold,omitempty"`
+
+	// SLO is the availability objective in percent, e.g. 99.9, used for error budget tracking
+	SLO float64 `yaml:"slo,omitempty"`
+
+	// Anomaly enables latency anomaly detection against a learned baseline
+	Anomaly *AnomalyConfig `yaml:"anomaly,omitempty"`
+
+	// Response body assertions. The check only counts as UP when the body
+	// matches and contains none of ExpectBodyNotContains.
+	ExpectBodyContains    string   `yaml:"expect_body_contains,omitempty"`
+	ExpectBodyRegex       string   `yaml:"expect_body_regex,omitempty"`
+	ExpectBodyNotContains []string `yaml:"expect_body_not_contains,omitempty"`
+
+	// Response body size assertions in bytes. The check only counts as UP when
+	// the body is at least MinSize and at most MaxSize, when set.
+	MinSize int64 `yaml:"min_size,omitempty"`
+	MaxSize int64 `yaml:"max_size,omitempty"`
+
+	// ExpectSHA256 only counts the check as UP when the SHA-256 of the response
+	// body matches. DetectChanges alerts when the body changes between checks.
+	ExpectSHA256  string `yaml:"expect_sha256,omitempty"`
+	DetectChanges bool   `yaml:"detect_changes,omitempty"`
+
+	// AcceptEncoding sets the Accept-Encoding header, e.g. "br, gzip", and
+	// ExpectCompressed only counts the check as UP when the response is
+	// compressed
+	AcceptEncoding   string `yaml:"accept_encoding,omitempty"`
+	ExpectCompressed bool   `yaml:"expect_compressed,omitempty"`
+
+	// Cache asserts on the cache headers of the response and revalidates it
+	Cache *CacheConfig `yaml:"cache,omitempty"`
+
+	// Response header assertions. ExpectHeaders requires an exact value and
+	// ExpectHeadersRegex a value matching the expression. Names are case-insensitive.
+	ExpectHeaders      map[string]string `yaml:"expect_headers,omitempty"`
+	ExpectHeadersRegex map[string]string `yaml:"expect_headers_regex,omitempty"`
+
+	// ExpectedStatus lists the status codes that count as UP. Any 2xx code is accepted when empty.
+	ExpectedStatus []int `yaml:"expected_status,omitempty"`
+
+	// DNS check options. RecordType defaults to A and Resolver to the system resolver.
+	RecordType  string   `yaml:"record_type,omitempty"`
+	ExpectedIPs []string `yaml:"expected_ips,omitempty"`
+	Resolver    string   `yaml:"resolver,omitempty"`
+
+	// Ping makes WebSocket checks send a ping and wait for the pong
+	Ping bool `yaml:"ping,omitempty"`
+
+	// StartTLS makes SMTP and LDAP checks upgrade the session with STARTTLS
+	StartTLS bool `yaml:"starttls,omitempty"`
+
+	// KeyExchange makes SSH checks complete the key exchange after the banner
+	KeyExchange bool `yaml:"key_exchange,omitempty"`
+
+	// Query is run by database checks instead of a ping, e.g. SELECT 1
+	Query string `yaml:"query,omitempty"`
+
+	// Kafka check options. Topic is the topic whose metadata is fetched and
+	// MinISR the in-sync replicas every partition needs.
+	Topic  string `yaml:"topic,omitempty"`
+	MinISR int    `yaml:"min_isr,omitempty"`
+
+	// MaxOffset is the clock offset above which NTP checks are DOWN (default: 100ms)
+	MaxOffset time.Duration `yaml:"max_offset,omitempty"`
+
+	// Command is the program and arguments run by exec checks. It is run
+	// directly, not through a shell.
+	Command []string `yaml:"command,omitempty"`
+
+	// Options are passed as is to the checker plugin of the endpoint type
+	Options map[string]any `yaml:"options,omitempty"`
+
+	// Steps are the ordered requests of a transaction check
+	Steps []Step `yaml:"steps,omitempty"`
+
+	// bodyRegex and headersRegex are ExpectBodyRegex and ExpectHeadersRegex
+	// compiled when the configuration is parsed
+	bodyRegex    *regexp.Regexp
+	headersRegex map[string]*regexp.Regexp
+}
+
+// Domain returns the host portion of the endpoint URL
+func (c Configuration) Domain() string {
+	// Endpoints other than HTTP may be configured as a plain host:port or name
+	if c.Type != "" && c.Type != TypeHTTP && !strings.Contains(c.Url, "://") {
+		return c.Url
+	}
+	// Transactions are grouped under the host of their first step
+	if c.Type == TypeTransaction && len(c.Steps) > 0 {
+		return ExtractDomain(c.Steps[0].Url)
+	}
+	return ExtractDomain(c.Url)
+}
+
+// network restricts a dial network such as "tcp" to the IP version of the
+// endpoint
+func (c Configuration) network(network string) string {
+	switch c.IPVersion {
+	case "4", "6":
+		return network + c.IPVersion
+	default:
+		return network
+	}
+}
+
+// recordType returns the DNS record type to query
+func (c Configuration) recordType() string {
+	if c.RecordType == "" {
+		return "A"
+	}
+	return strings.ToUpper(c.RecordType)
+}
+
+// StatusExpected reports whether the status code counts as UP for the endpoint
+func (c Configuration) StatusExpected(code int) bool {
+	if len(c.ExpectedStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, expected := range c.ExpectedStatus {
+		if code == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// timeoutOr returns the endpoint timeout, falling back to the given default
+func (c Configuration) timeoutOr(def time.Duration) time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return def
+}
+
+// latencyThresholdOr returns the endpoint latency threshold, falling back to the given default
+func (c Configuration) latencyThresholdOr(def time.Duration) time.Duration {
+	if c.LatencyThreshold > 0 {
+		return c.LatencyThreshold
+	}
+	return def
+}
+
+// intervalOr returns the endpoint interval, falling back to the given default
+func (c Configuration) intervalOr(def time.Duration) time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return def
+}
+
+// jitterOr returns the endpoint jitter, falling back to the given default
+func (c Configuration) jitterOr(def time.Duration) time.Duration {
+	if c.Jitter > 0 {
+		return c.Jitter
+	}
+	return def
+}
+
+// retryBackoff returns the wait before the given retry, starting at 1. The
+// backoff stops doubling after the tenth retry.
+func (c Configuration) retryBackoff(retry int) time.Duration {
+	backoff := c.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	return backoff << min(retry-1, 10)
+}
+
+// failureThreshold returns the consecutive failures needed to mark the endpoint DOWN
+func (c Configuration) failureThreshold() int {
+	if c.FailureThreshold > 0 {
+		return c.FailureThreshold
+	}
+	return 1
+}
+
+// successThreshold returns the consecutive successes needed to mark the endpoint UP
+func (c Configuration) successThreshold() int {
+	if c.SuccessThreshold > 0 {
+		return c.SuccessThreshold
+	}
+	return 1
+}
+
+// validResolve reports whether a resolve override is an IP address, with an
+// optional port
+func validResolve(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = strings.Trim(address, "[]")
+	}
+	return net.ParseIP(host) != nil
+}
+
+// ExtractDomain extracts the domain from a URL
+func ExtractDomain(rawUrl string) string {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		log.Printf("Invalid URL '%s': %v", rawUrl, err)
+		return "invalid_domain"
+	}
+	return parsedUrl.Host
+}
+
+// Config is the full configuration file: the endpoints to monitor and the
+// sections configuring the monitor itself. A file that is only a list of
+// endpoints is also accepted.
+type Config struct {
+	// Include lists further configuration files, or glob patterns, whose
+	// endpoints are merged into this one. Relative paths are relative to the
+	// including file.
+	Include []string `yaml:"include,omitempty"`
+	// Defaults are endpoint settings, such as the method, headers, timeout,
+	// interval or thresholds, inherited by every endpoint of the file that
+	// doesn't set them
+	Defaults    *Configuration      `yaml:"defaults,omitempty"`
+	Alerting    AlertingConfig      `yaml:"alerting,omitempty"`
+	AlertRoutes []AlertRoute        `yaml:"alert_routes,omitempty"`
+	Maintenance []MaintenanceWindow `yaml:"maintenance,omitempty"`
+	Outputs     OutputsConfig       `yaml:"outputs,omitempty"`
+	Digest      *DigestConfig       `yaml:"digest,omitempty"`
+	Endpoints   []Configuration     `yaml:"endpoints"`
+
+	// Sources are the files and patterns the configuration was loaded from
+	Sources []string `yaml:"-"`
+}
+
+// Parse parses YAML contents into a Config. Every problem is reported in
+// ConfigErrors: schema problems, such as unknown fields, with their line and
+// column, and invalid settings of endpoints, routes and outputs.
+func Parse(data []byte) (*Config, error) {
+	config, err := parse(data, true, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDependencies(config.Endpoints); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// parse parses and validates YAML contents into a Config, except for the
+// dependencies between endpoints which may be defined in other files.
+// Positions are left out of errors when unset, and ${VAR} references are
+// resolved with env.
+func parse(data []byte, positions bool, env envLookup) (*Config, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("error parsing YAML: %v", err)
+	}
+
+	config := &Config{}
+	var problems ConfigErrors
+	if len(document.Content) > 0 {
+		root := document.Content[0]
+		problems = validateSchema(root, positions)
+		applyDefaults(root)
+		target := any(config)
+		if root.Kind == yaml.SequenceNode {
+			// A bare list of endpoints
+			target = &config.Endpoints
+		}
+		if err := root.Decode(target); err != nil {
+			var typeErr *yaml.TypeError
+			if !errors.As(err, &typeErr) {
+				return nil, fmt.Errorf("error parsing YAML: %v", err)
+			}
+			problems = append(problems, typeErrors(typeErr, positions)...)
+		}
+	}
+
+	// Interpolate ${ENV_VAR} references before the values are validated
+	for i := range config.Endpoints {
+		if err := config.Endpoints[i].expandEnv(env); err != nil {
+			problems.addf("endpoint '%s': %v", config.Endpoints[i].Name, err)
+		}
+	}
+	if err := config.Alerting.expandEnv(env); err != nil {
+		problems.addf("alerting: %v", err)
+	}
+	if err := config.Outputs.expandEnv(env); err != nil {
+		problems.addf("outputs: %v", err)
+	}
+
+	for _, req := range config.Endpoints {
+		if req.Type != "" && !registered(req.Type) {
+			problems.addf("endpoint '%s': unsupported check type %q, expected one of %s", req.Name, req.Type, strings.Join(Types(), ", "))
+		}
+	}
+
+	for i, req := range config.Endpoints {
+		// References to unset variables are already reported
+		if req.Resolve != "" && !strings.Contains(req.Resolve, "${") && !validResolve(req.Resolve) {
+			problems.addf("endpoint '%s': invalid resolve %q, expected an IP address with an optional port", req.Name, req.Resolve)
+		}
+		switch req.IPVersion {
+		case "", "any", "4", "6":
+		default:
+			problems.addf("endpoint '%s': invalid ip_version %q, expected 4, 6 or any", req.Name, req.IPVersion)
+		}
+		switch req.HTTPVersion {
+		case "", "any", "1.1", "2", "3":
+		default:
+			problems.addf("endpoint '%s': invalid http_version %q, expected 1.1, 2, 3 or any", req.Name, req.HTTPVersion)
+		}
+		if req.Schedule != "" {
+			if _, err := cron.ParseStandard(req.Schedule); err != nil {
+				problems.addf("endpoint '%s': invalid schedule: %v", req.Name, err)
+			}
+			if req.Interval > 0 {
+				problems.addf("endpoint '%s': schedule and interval are mutually exclusive", req.Name)
+			}
+		}
+		if req.Severity != "" {
+			if err := validateSeverity(req.Severity); err != nil {
+				problems.addf("endpoint '%s': %v", req.Name, err)
+			}
+		}
+		if err := req.validateSize(); err != nil {
+			problems.addf("endpoint '%s': %v", req.Name, err)
+		}
+		if err := req.validateChecksum(); err != nil {
+			problems.addf("endpoint '%s': %v", req.Name, err)
+		}
+		if req.Sign != nil {
+			if err := req.Sign.validate(); err != nil {
+				problems.addf("endpoint '%s': %v", req.Name, err)
+			}
+		}
+		if req.Auth != nil && req.Auth.Type == AuthOAuth2 {
+			if err := req.Auth.validateOAuth2(); err != nil {
+				problems.addf("endpoint '%s': %v", req.Name, err)
+			}
+		}
+		if slices.Contains(req.ExpectBodyNotContains, "") {
+			problems.addf("endpoint '%s': empty string in expect_body_not_contains", req.Name)
+		}
+		if err := config.Endpoints[i].compileAssertions(); err != nil {
+			problems.addf("endpoint '%s': %v", req.Name, err)
+		}
+		if req.Anomaly != nil {
+			if err := req.Anomaly.validate(); err != nil {
+				problems.addf("endpoint '%s': %v", req.Name, err)
+			}
+		}
+	}
+	// Transactions are keyed by their name unless they set a URL
+	for i, req := range config.Endpoints {
+		if req.Type != TypeTransaction {
+			continue
+		}
+		if err := req.validateSteps(); err != nil {
+			problems.addf("endpoint '%s': %v", req.Name, err)
+		}
+		if req.Url == "" {
+			config.Endpoints[i].Url = transactionUrl(req.Name)
+		}
+	}
+
+	// Exec checks are keyed by their name unless they set a URL
+	for i, req := range config.Endpoints {
+		if req.Type != TypeExec {
+			continue
+		}
+		if len(req.Command) == 0 {
+			problems.addf("endpoint '%s': exec checks need a command", req.Name)
+		}
+		if req.Url == "" {
+			config.Endpoints[i].Url = execUrl(req.Name)
+		}
+	}
+
+	if err := validateRoutes(config.AlertRoutes, config.Alerting); err != nil {
+		problems.addf("%v", err)
+	}
+	if config.Digest != nil {
+		if err := config.Digest.validate(config.Alerting); err != nil {
+			problems.addf("%v", err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, tidyErrors(problems)
+	}
+	return config, nil
+}
+
+// Load reads and parses the configuration file at the given path. The format
+// is detected from the file extension: .json, .toml, or YAML otherwise.
+func Load(filePath string) (*Config, error) {
+	return LoadFormat(filePath, "")
+}
+
+// ParseConfig parses YAML contents and returns the endpoints
+func ParseConfig(data []byte) ([]Configuration, error) {
+	config, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return config.Endpoints, nil
+}
+
+// LoadConfig reads the configuration file at the given path and returns the endpoints
+func LoadConfig(filePath string) ([]Configuration, error) {
+	config, err := Load(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return config.Endpoints, nil
+}
@@ -0,0 +1,139 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultConsulAddress is the address of the local Consul agent
+const DefaultConsulAddress = "http://127.0.0.1:8500"
+
+// ConsulLock elects the instance holding a Consul KV lock, acquired with a
+// session whose TTL is renewed while the instance is alive
+type ConsulLock struct {
+	// Address is the base URL of the Consul HTTP API
+	Address string
+	// Token is sent as an ACL token when set
+	Token string
+	// Key is the KV key of the lock, e.g. service/healthcheck/leader
+	Key      string
+	Identity string
+	Client   *http.Client
+
+	session string
+}
+
+// NewConsulLock returns a ConsulLock on the given key
+func NewConsulLock(address, token, key, identity string) *ConsulLock {
+	return &ConsulLock{
+		Address:  strings.TrimSuffix(address, "/"),
+		Token:    token,
+		Key:      strings.Trim(key, "/"),
+		Identity: identity,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies the elector in logs
+func (l *ConsulLock) Name() string {
+	return "Consul lock " + l.Key
+}
+
+// Campaign renews the session of this instance, creating it when missing or
+// expired, and acquires the lock with it. Consul sessions have a TTL of at
+// least 10s.
+func (l *ConsulLock) Campaign(ctx context.Context, ttl time.Duration) (bool, error) {
+	if l.session != "" {
+		status, err := l.put(ctx, "/v1/session/renew/"+l.session, nil, nil)
+		if status == http.StatusNotFound {
+			l.session = ""
+		} else if err != nil {
+			return false, err
+		}
+	}
+	if l.session == "" {
+		var created struct {
+			ID string `json:"ID"`
+		}
+		session := map[string]string{
+			"Name":     "healthcheck " + l.Identity,
+			"TTL":      fmt.Sprintf("%ds", int(max(ttl, 10*time.Second)/time.Second)),
+			"Behavior": "release",
+		}
+		if _, err := l.put(ctx, "/v1/session/create", session, &created); err != nil {
+			return false, err
+		}
+		l.session = created.ID
+	}
+
+	var acquired bool
+	path := "/v1/kv/" + l.Key + "?acquire=" + url.QueryEscape(l.session)
+	if _, err := l.put(ctx, path, l.Identity, &acquired); err != nil {
+		return false, err
+	}
+	return acquired, nil
+}
+
+// Resign releases the lock and destroys the session
+func (l *ConsulLock) Resign(ctx context.Context) error {
+	if l.session == "" {
+		return nil
+	}
+	session := l.session
+	l.session = ""
+	if _, err := l.put(ctx, "/v1/kv/"+l.Key+"?release="+url.QueryEscape(session), nil, nil); err != nil {
+		return err
+	}
+	_, err := l.put(ctx, "/v1/session/destroy/"+session, nil, nil)
+	return err
+}
+
+// put sends a PUT request to the Consul API with a JSON body, or the raw
+// string for KV values, and decodes the response into into when set. It
+// returns the response status.
+func (l *ConsulLock) put(ctx context.Context, path string, body any, into any) (int, error) {
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, l.Address+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	if l.Token != "" {
+		req.Header.Set("X-Consul-Token", l.Token)
+	}
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("consul request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("consul returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if into != nil {
+		if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode consul response: %v", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
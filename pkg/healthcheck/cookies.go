@@ -0,0 +1,25 @@
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+)
+
+// sessionJar returns the cookie jar kept across the checks of the endpoint
+// with the given URL, creating it on first use. Session cookies set by the
+// endpoint, e.g. at the end of a redirect to a login page and back, are sent
+// with its later checks.
+func (c *HTTPChecker) sessionJar(url string) (http.CookieJar, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if jar, exists := c.jars[url]; exists {
+		return jar, nil
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	c.jars[url] = jar
+	return jar, nil
+}
@@ -0,0 +1,87 @@
+package healthcheck
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// csvHeader is written when the CSV file is created
+var csvHeader = []string{"timestamp", "name", "url", "up", "latency_ms", "availability_pct"}
+
+// CSVSink appends one row per endpoint to a CSV file at every summary, with
+// the outcome and latency of its latest check and its availability. up is 1
+// or 0 so the column can be averaged in a spreadsheet.
+type CSVSink struct {
+	Path string
+
+	mu     sync.Mutex
+	latest map[string]Result
+}
+
+// NewCSVSink returns a CSVSink appending to the file at path
+func NewCSVSink(path string) *CSVSink {
+	return &CSVSink{Path: path, latest: make(map[string]Result)}
+}
+
+// Observe remembers the latest result of the endpoint
+func (c *CSVSink) Observe(r Result, stats Availability) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest[r.Endpoint.Url] = r
+}
+
+// Flush appends a row for every endpoint checked at least once, writing the
+// header first if the file is new or empty
+func (c *CSVSink) Flush(cycle Cycle) error {
+	timestamp := time.Now().Format(time.RFC3339)
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	c.mu.Lock()
+	for _, req := range cycle.Endpoints {
+		r, checked := c.latest[req.Url]
+		if !checked {
+			continue
+		}
+		up := "0"
+		if r.Up {
+			up = "1"
+		}
+		writer.Write([]string{
+			timestamp, req.Name, req.Url, up,
+			strconv.FormatFloat(milliseconds(r.Latency), 'f', 3, 64),
+			strconv.Itoa(cycle.Availability[req.Url].Percentage()),
+		})
+	}
+	c.mu.Unlock()
+	writer.Flush()
+
+	if buf.Len() == 0 {
+		return nil
+	}
+	return c.append(buf.Bytes())
+}
+
+// append adds rows to the end of the file
+func (c *CSVSink) append(rows []byte) error {
+	file, err := os.OpenFile(c.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open CSV file '%s': %v", c.Path, err)
+	}
+
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		writer := csv.NewWriter(file)
+		writer.Write(csvHeader)
+		writer.Flush()
+	}
+	if _, err := file.Write(rows); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write CSV file '%s': %v", c.Path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,213 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultDatadogSite is the Datadog site used without one configured
+const DefaultDatadogSite = "datadoghq.com"
+
+// Datadog service check statuses
+const (
+	datadogOK       = 0
+	datadogCritical = 2
+)
+
+// DatadogConfig configures the Datadog API: the outputs section publishes
+// metrics and service checks with it, and the alerting section posts events
+type DatadogConfig struct {
+	// APIKey defaults to the DD_API_KEY environment variable
+	APIKey string `yaml:"api_key,omitempty"`
+	// Site is the Datadog site, e.g. datadoghq.eu (default: DD_SITE or DefaultDatadogSite)
+	Site string `yaml:"site,omitempty"`
+	// Tags are added to every metric, service check and event
+	Tags []string `yaml:"tags,omitempty"`
+}
+
+// withDefaults fills the API key and site from the environment
+func (c DatadogConfig) withDefaults() (DatadogConfig, error) {
+	if c.APIKey == "" {
+		c.APIKey = os.Getenv("DD_API_KEY")
+	}
+	if c.APIKey == "" {
+		return c, fmt.Errorf("datadog requires an api_key or DD_API_KEY")
+	}
+	if c.Site == "" {
+		c.Site = os.Getenv("DD_SITE")
+	}
+	if c.Site == "" {
+		c.Site = DefaultDatadogSite
+	}
+	return c, nil
+}
+
+// apiURL returns the base URL of the Datadog API of the site
+func (c DatadogConfig) apiURL() string {
+	return "https://api." + c.Site
+}
+
+// tags returns the Datadog tags of an endpoint: its name, domain, severity
+// and own tags, followed by the configured tags
+func (c DatadogConfig) tags(req Configuration) []string {
+	tags := []string{"endpoint:" + req.Name, "domain:" + req.Domain()}
+	if req.Severity != "" {
+		tags = append(tags, "severity:"+req.Severity)
+	}
+	tags = append(tags, req.Tags...)
+	return append(tags, c.Tags...)
+}
+
+// Datadog is a ResultSink submitting check results to the Datadog API at
+// every summary:
+//
+//	healthcheck.up            gauge, 1 or 0 at every check
+//	healthcheck.latency       gauge, latency of every check in milliseconds
+//	healthcheck.availability  gauge, availability percentage at every summary
+//	healthcheck.can_connect   service check, OK or CRITICAL at every check
+type Datadog struct {
+	Config DatadogConfig
+	Client *http.Client
+	// URL is the Datadog API base URL of the site
+	URL string
+	// Host is the host name of the service checks (default: the local host name)
+	Host string
+
+	mu     sync.Mutex
+	series []map[string]any
+	checks []map[string]any
+}
+
+// NewDatadog returns a Datadog for the given configuration
+func NewDatadog(config DatadogConfig) (*Datadog, error) {
+	config, err := config.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &Datadog{
+		Config: config,
+		Client: &http.Client{Timeout: 30 * time.Second},
+		URL:    config.apiURL(),
+		Host:   host,
+	}, nil
+}
+
+// Observe buffers the metrics and the service check of a check result
+func (d *Datadog) Observe(r Result, stats Availability) {
+	up, status, message := 0.0, datadogCritical, ""
+	if r.Up {
+		up, status = 1, datadogOK
+	} else if r.Err != nil {
+		message = r.Err.Error()
+	}
+	tags := d.Config.tags(r.Endpoint)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.series = append(d.series,
+		datadogGauge("healthcheck.up", tags, up, r.Time),
+		datadogGauge("healthcheck.latency", tags, milliseconds(r.Latency), r.Time),
+	)
+	d.checks = append(d.checks, map[string]any{
+		"check":     "healthcheck.can_connect",
+		"host_name": d.Host,
+		"status":    status,
+		"timestamp": r.Time.Unix(),
+		"message":   truncate(message, 500),
+		"tags":      tags,
+	})
+}
+
+// Flush adds the availability of every endpoint to the buffered metrics and
+// submits the metrics and the service checks. The buffers are cleared even if
+// submitting fails.
+func (d *Datadog) Flush(cycle Cycle) error {
+	now := time.Now()
+
+	d.mu.Lock()
+	for _, req := range cycle.Endpoints {
+		stats := cycle.Availability[req.Url]
+		if stats.Total() == 0 {
+			continue
+		}
+		availability := 100 * float64(stats.SuccessCount) / float64(stats.Total())
+		d.series = append(d.series, datadogGauge("healthcheck.availability", d.Config.tags(req), availability, now))
+	}
+	series, checks := d.series, d.checks
+	d.series, d.checks = nil, nil
+	d.mu.Unlock()
+
+	headers := map[string]string{"DD-API-KEY": d.Config.APIKey}
+	if len(series) > 0 {
+		if err := postJSON(d.Client, d.URL+"/api/v2/series", headers, map[string]any{"series": series}); err != nil {
+			return fmt.Errorf("failed to submit Datadog metrics: %v", err)
+		}
+	}
+	if len(checks) > 0 {
+		if err := postJSON(d.Client, d.URL+"/api/v1/check_run", headers, checks); err != nil {
+			return fmt.Errorf("failed to submit Datadog service checks: %v", err)
+		}
+	}
+	return nil
+}
+
+// datadogGauge returns a gauge point of the series API
+func datadogGauge(metric string, tags []string, value float64, t time.Time) map[string]any {
+	return map[string]any{
+		"metric": metric,
+		"type":   3, // gauge
+		"points": []map[string]any{{"timestamp": t.Unix(), "value": value}},
+		"tags":   tags,
+	}
+}
+
+// DatadogEventNotifier posts a Datadog event for every alert, so state
+// transitions show up on dashboards and in the event stream. Events of an
+// endpoint are aggregated by its URL.
+type DatadogEventNotifier struct {
+	Config DatadogConfig
+	Client *http.Client
+	// URL is the Datadog API base URL of the site
+	URL string
+}
+
+// NewDatadogEventNotifier returns a DatadogEventNotifier for the given configuration
+func NewDatadogEventNotifier(config DatadogConfig) (*DatadogEventNotifier, error) {
+	config, err := config.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	return &DatadogEventNotifier{
+		Config: config,
+		Client: &http.Client{Timeout: 5 * time.Second},
+		URL:    config.apiURL(),
+	}, nil
+}
+
+// Notify posts an error event when a critical endpoint goes DOWN (a warning or
+// info event for lower severities), a success event when it recovers and a
+// warning event for other alerts
+func (n *DatadogEventNotifier) Notify(a Alert) error {
+	alertType := "warning"
+	switch {
+	case a.Opens() && a.Endpoint.severity() == SeverityCritical:
+		alertType = "error"
+	case a.Opens() && a.Endpoint.severity() == SeverityInfo:
+		alertType = "info"
+	case a.Resolves():
+		alertType = "success"
+	}
+
+	return postJSON(n.Client, n.URL+"/api/v1/events", map[string]string{"DD-API-KEY": n.Config.APIKey}, map[string]any{
+		"title":            truncate(fmt.Sprintf("%s is %s", a.Endpoint.Name, a.Current), 100),
+		"text":             truncate(alertMessage(a), 4000),
+		"alert_type":       alertType,
+		"aggregation_key":  "healthcheck:" + a.Endpoint.Url,
+		"source_type_name": "healthcheck",
+		"tags":             n.Config.tags(a.Endpoint),
+	})
+}
@@ -0,0 +1,84 @@
+package healthcheck
+
+import "gopkg.in/yaml.v3"
+
+// applyDefaults copies the settings of the defaults section of a configuration
+// document into every endpoint that doesn't set them, before the document is
+// decoded. Headers are merged, with the endpoint's own values taking
+// precedence. Working on the document rather than the decoded endpoints
+// keeps explicit values such as `follow_redirects: false` from being
+// mistaken for unset ones.
+func applyDefaults(root *yaml.Node) {
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+	defaults, endpoints := mappingValue(root, "defaults"), mappingValue(root, "endpoints")
+	if defaults == nil || defaults.Kind != yaml.MappingNode || endpoints == nil || endpoints.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for _, endpoint := range endpoints.Content {
+		if endpoint.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(defaults.Content); i += 2 {
+			key, value := defaults.Content[i], defaults.Content[i+1]
+			own := mappingValue(endpoint, key.Value)
+			switch {
+			case own == nil:
+				endpoint.Content = append(endpoint.Content, key, value)
+			case key.Value == "headers" && own.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+				merged := *own
+				merged.Content = append([]*yaml.Node(nil), own.Content...)
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					if mappingValue(own, value.Content[j].Value) == nil {
+						merged.Content = append(merged.Content, value.Content[j], value.Content[j+1])
+					}
+				}
+				setMappingValue(endpoint, key.Value, &merged)
+			}
+		}
+	}
+}
+
+// mappingValue returns the value of a key of a mapping node, including keys
+// inherited through YAML merge keys (<<: *anchor), or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind == yaml.AliasNode {
+		mapping = mapping.Alias
+	}
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	var merged *yaml.Node
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+		if k.Value == key && k.Tag != "!!merge" {
+			return v
+		}
+		if k.Tag == "!!merge" && merged == nil {
+			sources := []*yaml.Node{v}
+			if v.Kind == yaml.SequenceNode {
+				sources = v.Content
+			}
+			for _, source := range sources {
+				if merged = mappingValue(source, key); merged != nil {
+					break
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// setMappingValue replaces the value of a key of a mapping node, or adds the
+// key when it is only inherited through a merge key
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if k := mapping.Content[i]; k.Value == key && k.Tag != "!!merge" {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
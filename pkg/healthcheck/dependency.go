@@ -0,0 +1,96 @@
+package healthcheck
+
+import (
+	"fmt"
+	"time"
+)
+
+// validateDependencies checks that every depends_on names another endpoint
+// and that dependencies don't form a cycle
+func validateDependencies(endpoints []Configuration) error {
+	for _, req := range endpoints {
+		if req.DependsOn == "" {
+			continue
+		}
+		if req.DependsOn == req.Name {
+			return fmt.Errorf("endpoint '%s': depends on itself", req.Name)
+		}
+		parent, ok := findEndpoint(endpoints, req.DependsOn)
+		for steps := 0; ok; steps++ {
+			if steps == len(endpoints) || parent.Name == req.Name {
+				return fmt.Errorf("endpoint '%s': circular depends_on", req.Name)
+			}
+			if parent.DependsOn == "" {
+				break
+			}
+			parent, ok = findEndpoint(endpoints, parent.DependsOn)
+		}
+		if !ok {
+			return fmt.Errorf("endpoint '%s': depends on unknown endpoint '%s'", req.Name, req.DependsOn)
+		}
+	}
+	return nil
+}
+
+// findEndpoint returns the endpoint with the given name
+func findEndpoint(endpoints []Configuration, name string) (Configuration, bool) {
+	for _, req := range endpoints {
+		if req.Name == name {
+			return req, true
+		}
+	}
+	return Configuration{}, false
+}
+
+// dependencyWaves groups the indexes of the endpoints so that every endpoint
+// comes after the endpoint it depends on. Endpoints depending on an endpoint
+// that isn't monitored are in the first wave.
+func dependencyWaves(endpoints []Configuration) [][]int {
+	depth := make(map[string]int, len(endpoints))
+	var depthOf func(req Configuration, seen int) int
+	depthOf = func(req Configuration, seen int) int {
+		if d, ok := depth[req.Name]; ok {
+			return d
+		}
+		d := 0
+		if parent, ok := findEndpoint(endpoints, req.DependsOn); ok && req.DependsOn != "" && seen < len(endpoints) {
+			d = depthOf(parent, seen+1) + 1
+		}
+		depth[req.Name] = d
+		return d
+	}
+
+	var waves [][]int
+	for i, req := range endpoints {
+		d := depthOf(req, 0)
+		for len(waves) <= d {
+			waves = append(waves, nil)
+		}
+		waves[d] = append(waves[d], i)
+	}
+	return waves
+}
+
+// skipCheck reports whether the check of an endpoint is skipped because the
+// endpoint it depends on is DOWN or itself skipped, and returns that endpoint
+func (s *Scheduler) skipCheck(req Configuration) (Configuration, bool) {
+	if req.DependsOn == "" {
+		return Configuration{}, false
+	}
+	parent, ok := findEndpoint(s.Endpoints(), req.DependsOn)
+	if !ok {
+		return Configuration{}, false
+	}
+	state := s.State.Get(parent.Url)
+	return parent, state.Status == StatusDown || state.Skipped
+}
+
+// skip marks the endpoint as SKIPPED instead of checking it. The transition is
+// logged once, and the state and availability of the endpoint are otherwise
+// left untouched until it is checked again.
+func (s *Scheduler) skip(req, parent Configuration) Result {
+	if previous := s.State.Skip(req.Url); !previous.Skipped {
+		s.Logger.Printf("SKIPPED: %s (%s) depends on %s (%s), which is %s", req.Name, req.Url, parent.Name, parent.Url, s.State.Get(parent.Url).Reported())
+	}
+	return Result{Endpoint: req, Time: time.Now(), Skipped: parent.Name}
+}
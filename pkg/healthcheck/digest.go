@@ -0,0 +1,208 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DigestConfig is the digest section of the configuration file: a periodic
+// availability summary sent to notifier sets, e.g. before an SLO review
+type DigestConfig struct {
+	// Schedule is a cron expression in local time, e.g. "0 9 * * MON"
+	Schedule string `yaml:"schedule"`
+	// Period is the time covered by a digest (default: the time between two
+	// runs of the schedule, e.g. a day or a week)
+	Period time.Duration `yaml:"period,omitempty"`
+	// Notifiers names the sets of alerting.notifiers, or default, the digest
+	// is sent to. Their webhook and email notifiers receive it.
+	Notifiers []string `yaml:"notifiers"`
+}
+
+// validate checks the digest schedule and notifier sets
+func (c DigestConfig) validate(alerting AlertingConfig) error {
+	if _, err := cron.ParseStandard(c.Schedule); err != nil {
+		return fmt.Errorf("digest: invalid schedule: %v", err)
+	}
+	if c.Period < 0 {
+		return fmt.Errorf("digest: negative period")
+	}
+	if err := validateNotifierSets(c.Notifiers, alerting); err != nil {
+		return fmt.Errorf("digest: %v", err)
+	}
+	return nil
+}
+
+// Digest summarizes the availability of every endpoint over a period
+type Digest struct {
+	Since     time.Time
+	Until     time.Time
+	Endpoints []DigestEndpoint
+}
+
+// DigestEndpoint is the history of an endpoint over the digest period and,
+// when it was already checked then, over the period before for trends
+type DigestEndpoint struct {
+	HistoryReport
+	Previous *HistoryReport
+}
+
+// Title is a one line description of the digest
+func (d Digest) Title() string {
+	return fmt.Sprintf("Availability digest %s to %s", d.Since.Format("2006-01-02 15:04"), d.Until.Format("2006-01-02 15:04"))
+}
+
+// Text formats the digest with one line per endpoint: availability, incidents,
+// MTTR and p95 latency, with the change since the previous period
+func (d Digest) Text() string {
+	var b strings.Builder
+	b.WriteString(d.Title())
+	b.WriteString("\n")
+	if len(d.Endpoints) == 0 {
+		b.WriteString("\nNo checks recorded.\n")
+		return b.String()
+	}
+
+	var incidents, checks, failed int
+	for _, e := range d.Endpoints {
+		incidents += e.Outages
+		checks += e.TotalChecks
+		failed += e.FailedChecks
+	}
+	fmt.Fprintf(&b, "\n%d endpoint%s, %d incident%s, %.2f%% of %d checks successful\n\n",
+		len(d.Endpoints), plural(len(d.Endpoints)), incidents, plural(incidents), 100*float64(checks-failed)/float64(max(checks, 1)), checks)
+
+	for _, e := range d.Endpoints {
+		fmt.Fprintf(&b, "- %s (%s): %.2f%% availability", e.Name, e.Url, e.Availability)
+		if e.Previous != nil {
+			fmt.Fprintf(&b, " (%+.2f)", e.Availability-e.Previous.Availability)
+		}
+		fmt.Fprintf(&b, ", %d incident%s", e.Outages, plural(e.Outages))
+		if e.MTTRSeconds > 0 {
+			fmt.Fprintf(&b, ", MTTR %s", time.Duration(e.MTTRSeconds*float64(time.Second)).Round(time.Second))
+		}
+		if e.Ongoing {
+			b.WriteString(", DOWN at the end of the period")
+		}
+		if e.P95LatencyMs > 0 {
+			fmt.Fprintf(&b, ", p95 latency %.0fms", e.P95LatencyMs)
+			if e.Previous != nil && e.Previous.P95LatencyMs > 0 {
+				fmt.Fprintf(&b, " (%+.0f%%)", 100*(e.P95LatencyMs-e.Previous.P95LatencyMs)/e.Previous.P95LatencyMs)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// DigestNotifier is implemented by the notifiers that can deliver a digest
+type DigestNotifier interface {
+	NotifyDigest(d Digest) error
+}
+
+// Digester compiles a Digest from the stored history on a schedule and sends
+// it to its notifiers
+type Digester struct {
+	History   *History
+	Notifiers []DigestNotifier
+	// Period is the time covered by a digest
+	Period time.Duration
+	// Logger receives delivery failures. Defaults to the standard logger.
+	Logger *log.Logger
+	// Leader, when set, reports whether this instance leads its HA pair.
+	// Only the leader sends digests.
+	Leader func() bool
+
+	schedule cron.Schedule
+}
+
+// NewDigester returns a Digester for the given configuration
+func NewDigester(config DigestConfig, history *History, notifiers []DigestNotifier) (*Digester, error) {
+	schedule, err := cron.ParseStandard(config.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("digest: invalid schedule: %v", err)
+	}
+	period := config.Period
+	if period == 0 {
+		next := schedule.Next(time.Now())
+		period = schedule.Next(next).Sub(next)
+	}
+	return &Digester{
+		History:   history,
+		Notifiers: notifiers,
+		Period:    period,
+		Logger:    log.Default(),
+		schedule:  schedule,
+	}, nil
+}
+
+// Run sends a digest at every run of the schedule until ctx is cancelled
+func (d *Digester) Run(ctx context.Context) {
+	for {
+		next := d.schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+		if d.Leader != nil && !d.Leader() {
+			continue
+		}
+		if err := d.Send(ctx, next); err != nil {
+			d.Logger.Printf("Error sending digest: %v", err)
+		}
+	}
+}
+
+// Send compiles the digest of the period ending at until and sends it to
+// every notifier, returning the first delivery error
+func (d *Digester) Send(ctx context.Context, until time.Time) error {
+	digest, err := d.Compile(ctx, until)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, notifier := range d.Notifiers {
+		if err := notifier.NotifyDigest(digest); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Compile builds the digest of the period ending at until
+func (d *Digester) Compile(ctx context.Context, until time.Time) (Digest, error) {
+	since := until.Add(-d.Period)
+	current, err := d.History.ReportBetween(ctx, since, until)
+	if err != nil {
+		return Digest{}, err
+	}
+	previous, err := d.History.ReportBetween(ctx, since.Add(-d.Period), since)
+	if err != nil {
+		return Digest{}, err
+	}
+	byUrl := make(map[string]*HistoryReport, len(previous))
+	for i := range previous {
+		byUrl[previous[i].Url] = &previous[i]
+	}
+
+	digest := Digest{Since: since, Until: until}
+	for _, report := range current {
+		digest.Endpoints = append(digest.Endpoints, DigestEndpoint{HistoryReport: report, Previous: byUrl[report.Url]})
+	}
+	return digest, nil
+}
+
+// plural returns the suffix of a count of a regular noun
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
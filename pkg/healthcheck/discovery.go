@@ -0,0 +1,121 @@
+package healthcheck
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Discoverer produces endpoints to check from an external source, such as the
+// services of a Kubernetes cluster
+type Discoverer interface {
+	// Name identifies the source in logs
+	Name() string
+	Discover(ctx context.Context) ([]Configuration, error)
+}
+
+// Discovery keeps the endpoints of a Scheduler in sync with the static
+// endpoints of the configuration file and the endpoints found by every
+// Discoverer. Static endpoints win over discovered ones with the same URL.
+type Discovery struct {
+	Scheduler *Scheduler
+	Sources   []Discoverer
+	// Interval is how often the sources are refreshed
+	Interval time.Duration
+	// Logger receives discovery errors. Defaults to the standard logger.
+	Logger *log.Logger
+
+	mu         sync.Mutex
+	static     []Configuration
+	discovered [][]Configuration
+}
+
+// NewDiscovery returns a Discovery updating the scheduler from the given
+// sources at every interval. The scheduler's current endpoints are kept as the
+// static endpoints.
+func NewDiscovery(scheduler *Scheduler, interval time.Duration, sources ...Discoverer) *Discovery {
+	return &Discovery{
+		Scheduler:  scheduler,
+		Sources:    sources,
+		Interval:   interval,
+		Logger:     log.Default(),
+		static:     scheduler.Endpoints(),
+		discovered: make([][]Configuration, len(sources)),
+	}
+}
+
+// SetStatic replaces the static endpoints, e.g. after the configuration file
+// is reloaded, and updates the scheduler
+func (d *Discovery) SetStatic(endpoints []Configuration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.static = endpoints
+	d.apply()
+}
+
+// Refresh queries every source and updates the scheduler if the endpoints
+// changed. A source that fails keeps its previous endpoints.
+func (d *Discovery) Refresh(ctx context.Context) {
+	results := make([][]Configuration, len(d.Sources))
+	failed := make([]bool, len(d.Sources))
+	for i, source := range d.Sources {
+		endpoints, err := source.Discover(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				d.Logger.Printf("Error discovering endpoints from %s, keeping previous ones: %v", source.Name(), err)
+			}
+			failed[i] = true
+			continue
+		}
+		results[i] = endpoints
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, endpoints := range results {
+		if !failed[i] {
+			d.discovered[i] = endpoints
+		}
+	}
+	d.apply()
+}
+
+// Run refreshes the sources at every interval until ctx is cancelled
+func (d *Discovery) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.Refresh(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// apply reloads the scheduler with the merged endpoints if they changed.
+// Callers must hold the lock.
+func (d *Discovery) apply() {
+	seen := make(map[string]bool)
+	var merged []Configuration
+	for _, endpoints := range append([][]Configuration{d.static}, d.discovered...) {
+		for _, req := range endpoints {
+			if seen[req.Url] {
+				continue
+			}
+			seen[req.Url] = true
+			merged = append(merged, req)
+		}
+	}
+
+	if reflect.DeepEqual(merged, d.Scheduler.Endpoints()) {
+		return
+	}
+	d.Scheduler.Reload(merged)
+}
@@ -0,0 +1,143 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DNSChecker resolves a name and validates the answers
+type DNSChecker struct {
+	// LatencyThreshold is the maximum resolution latency for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the lookup unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeDNS, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewDNSChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewDNSChecker returns a DNSChecker using the given latency threshold and default timeout
+func NewDNSChecker(latencyThreshold, timeout time.Duration) *DNSChecker {
+	return &DNSChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check resolves the endpoint name with its record type and resolver and
+// measures the resolution latency
+func (c *DNSChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+
+	startTime := time.Now()
+	answers, err := lookup(ctx, dnsResolver(req.Resolver), req.recordType(), dnsName(req.Url))
+	result.Latency = time.Since(startTime)
+
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		result.Err = fmt.Errorf("NXDOMAIN: %s", dnsErr.Name)
+		return result
+	case err != nil:
+		result.Err = err
+		return result
+	case len(answers) == 0:
+		result.Err = fmt.Errorf("no %s records returned", req.recordType())
+		return result
+	}
+
+	// Every returned address must be one of the expected ones
+	if len(req.ExpectedIPs) > 0 {
+		for _, answer := range answers {
+			if !slices.Contains(req.ExpectedIPs, answer) {
+				result.Err = fmt.Errorf("unexpected answer %s", answer)
+				return result
+			}
+		}
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// lookup resolves name and returns the answers as strings
+func lookup(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		answers := make([]string, len(ips))
+		for i, ip := range ips {
+			answers[i] = ip.String()
+		}
+		return answers, err
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		return []string{cname}, err
+	case "MX":
+		records, err := resolver.LookupMX(ctx, name)
+		answers := make([]string, len(records))
+		for i, mx := range records {
+			answers[i] = mx.Host
+		}
+		return answers, err
+	case "NS":
+		records, err := resolver.LookupNS(ctx, name)
+		answers := make([]string, len(records))
+		for i, ns := range records {
+			answers[i] = ns.Host
+		}
+		return answers, err
+	case "TXT":
+		return resolver.LookupTXT(ctx, name)
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+}
+
+// dnsResolver returns a resolver querying the given server, or the system
+// resolver when none is configured
+func dnsResolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// dnsName returns the name to resolve, accepting both dns://name and a plain name
+func dnsName(rawUrl string) string {
+	if !strings.Contains(rawUrl, "://") {
+		return rawUrl
+	}
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	return parsedUrl.Host
+}
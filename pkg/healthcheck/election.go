@@ -0,0 +1,176 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Leader election backends
+const (
+	ElectionFile       = "file"
+	ElectionConsul     = "consul"
+	ElectionKubernetes = "kubernetes"
+)
+
+// DefaultLeaderTTL is how long a leader holds the leadership without renewing it
+const DefaultLeaderTTL = 15 * time.Second
+
+// Elector is a lock shared by the instances of an HA monitor pair. Its
+// methods are called from a single goroutine.
+type Elector interface {
+	// Campaign acquires the leadership, or renews it for ttl when held, and
+	// reports whether this instance holds it
+	Campaign(ctx context.Context, ttl time.Duration) (bool, error)
+	// Resign releases the leadership when held, and any resource held to
+	// campaign
+	Resign(ctx context.Context) error
+	// Name identifies the elector in logs
+	Name() string
+}
+
+// Election keeps campaigning for the leadership of an HA monitor pair, so
+// both instances check every endpoint but only the leader sends alerts
+type Election struct {
+	Elector Elector
+	// TTL is how long the leadership is held without renewal. It is renewed
+	// every third of it.
+	TTL time.Duration
+	// Logger receives leadership changes and errors. Defaults to the
+	// standard logger.
+	Logger *log.Logger
+
+	leader  atomic.Bool
+	renewed time.Time
+}
+
+// NewElection returns an Election campaigning with the given elector
+func NewElection(elector Elector, ttl time.Duration) *Election {
+	if ttl <= 0 {
+		ttl = DefaultLeaderTTL
+	}
+	return &Election{Elector: elector, TTL: ttl, Logger: log.Default()}
+}
+
+// Leader reports whether this instance holds the leadership
+func (e *Election) Leader() bool {
+	return e.leader.Load()
+}
+
+// Role returns "leader" or "standby"
+func (e *Election) Role() string {
+	if e.Leader() {
+		return "leader"
+	}
+	return "standby"
+}
+
+// Campaign tries once to acquire or renew the leadership. When the elector
+// fails, the leadership is kept until the TTL since the last renewal has
+// elapsed, as no other instance can take it over before then.
+func (e *Election) Campaign(ctx context.Context) {
+	leader, err := e.Elector.Campaign(ctx, e.TTL)
+	now := time.Now()
+	switch {
+	case err != nil:
+		e.Logger.Printf("Error campaigning for leadership with %s: %v", e.Elector.Name(), err)
+		leader = e.Leader() && now.Sub(e.renewed) < e.TTL
+	case leader:
+		e.renewed = now
+	}
+
+	if leader != e.leader.Swap(leader) {
+		if leader {
+			e.Logger.Printf("LEADER: acquired the leadership with %s, sending alerts", e.Elector.Name())
+		} else {
+			e.Logger.Printf("STANDBY: lost the leadership with %s, suppressing alerts", e.Elector.Name())
+		}
+	}
+}
+
+// Run campaigns every third of the TTL until ctx is cancelled, then resigns
+// so the other instance takes over without waiting for the TTL
+func (e *Election) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.TTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.Campaign(ctx)
+		case <-ctx.Done():
+			e.leader.Store(false)
+			resignCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := e.Elector.Resign(resignCtx); err != nil {
+				e.Logger.Printf("Error resigning the leadership with %s: %v", e.Elector.Name(), err)
+			}
+			return
+		}
+	}
+}
+
+// DefaultIdentity identifies this instance to the other: the host name and
+// process ID
+func DefaultIdentity() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// FileLock elects the instance holding an exclusive lock on a file shared
+// by the instances, e.g. on the same host or a shared volume. The lock is
+// released by the operating system when the process dies, so it has no TTL.
+type FileLock struct {
+	Path string
+	// Identity is written to the file while the lock is held
+	Identity string
+
+	file *os.File
+}
+
+// NewFileLock returns a FileLock on the given path
+func NewFileLock(path, identity string) *FileLock {
+	return &FileLock{Path: path, Identity: identity}
+}
+
+// Name identifies the elector in logs
+func (l *FileLock) Name() string {
+	return "file lock " + l.Path
+}
+
+// Campaign tries to lock the file, unless already locked by this instance
+func (l *FileLock) Campaign(ctx context.Context, ttl time.Duration) (bool, error) {
+	if l.file != nil {
+		return true, nil
+	}
+	file, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	locked, err := lockFile(file)
+	if !locked {
+		file.Close()
+		return false, err
+	}
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(l.Identity+"\n"), 0)
+	}
+	l.file = file
+	return true, nil
+}
+
+// Resign unlocks the file
+func (l *FileLock) Resign(ctx context.Context) error {
+	if l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+	return err
+}
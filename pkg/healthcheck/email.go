@@ -0,0 +1,206 @@
+package healthcheck
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SMTP connection security modes
+const (
+	EmailTLSStartTLS = "starttls"
+	EmailTLSImplicit = "tls"
+	EmailTLSNone     = "none"
+)
+
+// emailTimeout bounds an SMTP session, from the connection to QUIT, so a
+// stalled server doesn't block the checks alerts are sent from
+const emailTimeout = 30 * time.Second
+
+// Default templates of alert emails
+const (
+	defaultEmailSubject = `[{{.Alert.Current}}] {{.Alert.Endpoint.Name}}`
+	defaultEmailBody    = `{{.Message}}
+{{if .Failing}}
+Endpoints currently DOWN:
+{{range .Failing}}- {{.Name}} ({{.Url}}): {{.Availability}}% availability, {{.ConsecutiveFailures}} consecutive failures
+{{end}}{{else}}
+All endpoints are UP.
+{{end}}`
+)
+
+// EmailConfig configures alert emails sent through an SMTP server
+type EmailConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	// TLS is starttls (default), tls for implicit TLS, or none
+	TLS string `yaml:"tls,omitempty"`
+	// Subject and Body are text/template templates executed with the alert
+	// and the endpoints currently DOWN
+	Subject string `yaml:"subject,omitempty"`
+	Body    string `yaml:"body,omitempty"`
+}
+
+// EmailNotifier sends alerts as emails through an SMTP server
+type EmailNotifier struct {
+	Config EmailConfig
+	// Status, when set, provides the state of every endpoint so emails can
+	// summarize all failing endpoints, not just the one that changed
+	Status func() []EndpointStatus
+
+	subject *template.Template
+	body    *template.Template
+}
+
+// emailData is passed to the subject and body templates
+type emailData struct {
+	Alert   Alert
+	Message string
+	Failing []EndpointStatus
+}
+
+// NewEmailNotifier returns an EmailNotifier for the given configuration
+func NewEmailNotifier(config EmailConfig) (*EmailNotifier, error) {
+	if config.Host == "" || config.From == "" || len(config.To) == 0 {
+		return nil, fmt.Errorf("email alerting requires host, from and to")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+		if config.TLS == EmailTLSImplicit {
+			config.Port = 465
+		}
+	}
+	if config.TLS == "" {
+		config.TLS = EmailTLSStartTLS
+	}
+	if config.TLS != EmailTLSStartTLS && config.TLS != EmailTLSImplicit && config.TLS != EmailTLSNone {
+		return nil, fmt.Errorf("unsupported email tls mode %q", config.TLS)
+	}
+	if config.Subject == "" {
+		config.Subject = defaultEmailSubject
+	}
+	if config.Body == "" {
+		config.Body = defaultEmailBody
+	}
+
+	subject, err := template.New("subject").Parse(config.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email subject template: %v", err)
+	}
+	body, err := template.New("body").Parse(config.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email body template: %v", err)
+	}
+
+	return &EmailNotifier{Config: config, subject: subject, body: body}, nil
+}
+
+// Notify renders the alert email and sends it to every recipient
+func (n *EmailNotifier) Notify(a Alert) error {
+	data := emailData{Alert: a, Message: alertMessage(a)}
+	if n.Status != nil {
+		for _, status := range n.Status() {
+			if status.Status == StatusDown {
+				data.Failing = append(data.Failing, status)
+			}
+		}
+	}
+
+	var subject, body bytes.Buffer
+	if err := n.subject.Execute(&subject, data); err != nil {
+		return fmt.Errorf("failed to render email subject: %v", err)
+	}
+	if err := n.body.Execute(&body, data); err != nil {
+		return fmt.Errorf("failed to render email body: %v", err)
+	}
+
+	return n.send(n.message(subject.String(), body.String()))
+}
+
+// NotifyDigest sends the digest as a plain text email
+func (n *EmailNotifier) NotifyDigest(d Digest) error {
+	return n.send(n.message(d.Title(), d.Text()))
+}
+
+// message formats a plain text email to every recipient
+func (n *EmailNotifier) message(subject, body string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.Config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.Config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.TrimSpace(subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// send delivers the message over SMTP using the configured security mode
+func (n *EmailNotifier) send(msg []byte) error {
+	addr := net.JoinHostPort(n.Config.Host, strconv.Itoa(n.Config.Port))
+	tlsConfig := &tls.Config{ServerName: n.Config.Host}
+
+	var client *smtp.Client
+	if n.Config.TLS == EmailTLSImplicit {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", addr, tlsConfig)
+		if err != nil {
+			return err
+		}
+		conn.SetDeadline(time.Now().Add(emailTimeout))
+		if client, err = smtp.NewClient(conn, n.Config.Host); err != nil {
+			conn.Close()
+			return err
+		}
+	} else {
+		conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+		if err != nil {
+			return err
+		}
+		conn.SetDeadline(time.Now().Add(emailTimeout))
+		if client, err = smtp.NewClient(conn, n.Config.Host); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	defer client.Close()
+
+	if n.Config.TLS == EmailTLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %v", err)
+		}
+	}
+	if n.Config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.Config.Username, n.Config.Password, n.Config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(n.Config.From); err != nil {
+		return err
+	}
+	for _, to := range n.Config.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,104 @@
+package healthcheck
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envPattern matches ${VAR} references. The bare $VAR form is deliberately not
+// supported so payloads such as GraphQL variables are left untouched.
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// envLookup returns the value of an environment variable referenced by a
+// configuration
+type envLookup func(name string) (string, error)
+
+// lookupEnv reads variables from the environment of the process
+func lookupEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// refuseEnv refuses every reference, for configurations fetched from a URL:
+// whoever controls the URL could otherwise send the secrets of this host to
+// an endpoint of their choosing
+func refuseEnv(name string) (string, error) {
+	return "", fmt.Errorf("environment variable %s is not interpolated in a configuration fetched from a URL", name)
+}
+
+// expandEnv replaces ${VAR} references in s with the values env returns.
+// It fails on the first variable env refuses.
+func expandEnv(s string, env envLookup) (string, error) {
+	var lookupErr error
+	expanded := envPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envPattern.FindStringSubmatch(ref)[1]
+		value, err := env(name)
+		if err != nil && lookupErr == nil {
+			lookupErr = err
+		}
+		return value
+	})
+	if lookupErr != nil {
+		return "", lookupErr
+	}
+	return expanded, nil
+}
+
+// expandEnv interpolates environment variables into the URL, headers, body,
+// proxy, resolve address, credentials and command of the endpoint and of its
+// transaction steps
+func (c *Configuration) expandEnv(env envLookup) error {
+	fields := []*string{&c.Url, &c.Body, &c.Proxy, &c.Resolve}
+	for i := range c.Command {
+		fields = append(fields, &c.Command[i])
+	}
+	if c.Auth != nil {
+		fields = append(fields, &c.Auth.Username, &c.Auth.Password, &c.Auth.Token, &c.Auth.TokenURL, &c.Auth.ClientID, &c.Auth.ClientSecret)
+	}
+	if c.Sign != nil {
+		fields = append(fields, &c.Sign.Secret)
+	}
+
+	for _, field := range fields {
+		expanded, err := expandEnv(*field, env)
+		if err != nil {
+			return err
+		}
+		*field = expanded
+	}
+
+	if err := expandHeaders(c.Headers, env); err != nil {
+		return err
+	}
+
+	for i := range c.Steps {
+		step := &c.Steps[i]
+		for _, field := range []*string{&step.Url, &step.Body} {
+			expanded, err := expandEnv(*field, env)
+			if err != nil {
+				return err
+			}
+			*field = expanded
+		}
+		if err := expandHeaders(step.Headers, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandHeaders interpolates environment variables into header values
+func expandHeaders(headers map[string]string, env envLookup) error {
+	for key, value := range headers {
+		expanded, err := expandEnv(value, env)
+		if err != nil {
+			return err
+		}
+		headers[key] = expanded
+	}
+	return nil
+}
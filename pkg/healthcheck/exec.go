@@ -0,0 +1,115 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// TypeExec is a check running an external command
+const TypeExec = "exec"
+
+// execWaitDelay bounds how long a timed out command's output is waited for,
+// e.g. when a child of a killed shell still holds stdout open
+const execWaitDelay = time.Second
+
+// ExecChecker runs the command of an endpoint and counts it as UP when it
+// exits with status 0 and its standard output matches the body assertions
+type ExecChecker struct {
+	// LatencyThreshold is the maximum run time for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the command unless the endpoint sets its own. The
+	// command is killed when it runs longer.
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeExec, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewExecChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewExecChecker returns an ExecChecker using the given latency threshold and default timeout
+func NewExecChecker(latencyThreshold, timeout time.Duration) *ExecChecker {
+	return &ExecChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check runs the command and waits for it to exit, measuring its run time
+func (c *ExecChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	if len(req.Command) == 0 {
+		result.Err = fmt.Errorf("no command configured")
+		return result
+	}
+
+	timeout := req.timeoutOr(c.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, req.Command[0], req.Command[1:]...)
+	cmd.WaitDelay = execWaitDelay
+	stdout := &cappedBuffer{limit: maxBodySize}
+	stderr := &cappedBuffer{limit: maxBodySize}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	startTime := time.Now()
+	err := cmd.Run()
+	result.Latency = time.Since(startTime)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Err = fmt.Errorf("command timed out after %v", timeout)
+		return result
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if message := lastLine(stderr.Bytes()); errors.As(err, &exitErr) && message != "" {
+			err = fmt.Errorf("%v: %s", err, message)
+		}
+		result.Err = err
+		return result
+	}
+	if err := assertBody(req, stdout.Bytes()); err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// execUrl is the URL identifying an exec check without one configured
+func execUrl(name string) string {
+	return TypeExec + "://" + url.PathEscape(name)
+}
+
+// lastLine returns the last non-empty line of a command output, which
+// usually explains a failure
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a chatty command can't exhaust memory
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,159 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Failure causes of DOWN results
+const (
+	CauseDNS       = "dns"
+	CauseRefused   = "connection_refused"
+	CauseTLS       = "tls"
+	CauseTimeout   = "timeout"
+	CauseStatus    = "bad_status"
+	CauseAssertion = "assertion"
+	CauseSlow      = "slow"
+	CauseOther     = "other"
+)
+
+// Causes lists the failure causes in the order they are reported
+var Causes = []string{CauseDNS, CauseRefused, CauseTLS, CauseTimeout, CauseStatus, CauseAssertion, CauseSlow, CauseOther}
+
+// errUnexpectedStatus is wrapped by the errors of checks that received a
+// status code other than the expected one
+var errUnexpectedStatus = errors.New("unexpected status")
+
+// Cause classifies why a check failed: dns, connection_refused, tls, timeout,
+// bad_status, assertion (a response failing a header, body or protocol
+// assertion), slow or other. It is empty for successful checks and for
+// failures during maintenance windows, which aren't counted as failures.
+func (r Result) Cause() string {
+	if r.Up || r.Maintenance != "" {
+		return ""
+	}
+	if r.Slow {
+		return CauseSlow
+	}
+	if r.Err == nil || errors.Is(r.Err, errUnexpectedStatus) {
+		return CauseStatus
+	}
+	if r.StatusCode != 0 {
+		return CauseAssertion
+	}
+	if cause := errorCause(r.Err); cause != "" {
+		return cause
+	}
+	return CauseOther
+}
+
+// errorCause classifies a check error by its type, or by its message for
+// errors that were formatted rather than wrapped
+func errorCause(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var verifyErr *tls.CertificateVerificationError
+	var headerErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return CauseDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return CauseTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return CauseRefused
+	case errors.As(err, &verifyErr), errors.As(err, &headerErr), errors.As(err, &alertErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return CauseTLS
+	}
+
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "no such host"):
+		return CauseDNS
+	case strings.Contains(message, "timeout"), strings.Contains(message, "deadline exceeded"):
+		return CauseTimeout
+	case strings.Contains(message, "connection refused"):
+		return CauseRefused
+	case strings.Contains(message, "tls:"), strings.Contains(message, "x509:"), strings.Contains(message, "TLS handshake"):
+		return CauseTLS
+	}
+	return ""
+}
+
+// FailureCauses counts failed checks by cause
+type FailureCauses struct {
+	DNS       int
+	Refused   int
+	TLS       int
+	Timeout   int
+	Status    int
+	Assertion int
+	Slow      int
+	Other     int
+}
+
+// counter returns the counter of a cause
+func (c *FailureCauses) counter(cause string) *int {
+	switch cause {
+	case CauseDNS:
+		return &c.DNS
+	case CauseRefused:
+		return &c.Refused
+	case CauseTLS:
+		return &c.TLS
+	case CauseTimeout:
+		return &c.Timeout
+	case CauseStatus:
+		return &c.Status
+	case CauseAssertion:
+		return &c.Assertion
+	case CauseSlow:
+		return &c.Slow
+	default:
+		return &c.Other
+	}
+}
+
+// add counts a failure with the given cause
+func (c *FailureCauses) add(cause string) {
+	*c.counter(cause)++
+}
+
+// Count returns the number of failures with the given cause
+func (c FailureCauses) Count(cause string) int {
+	return *c.counter(cause)
+}
+
+// Map returns the number of failures by cause, omitting causes with none
+func (c FailureCauses) Map() map[string]int {
+	counts := make(map[string]int)
+	for _, cause := range Causes {
+		if n := c.Count(cause); n > 0 {
+			counts[cause] = n
+		}
+	}
+	return counts
+}
+
+// String formats the non-zero counts in reporting order, e.g.
+// "timeout 3, connection refused 1"
+func (c FailureCauses) String() string {
+	var parts []string
+	for _, cause := range Causes {
+		if n := c.Count(cause); n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", strings.ReplaceAll(cause, "_", " "), n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
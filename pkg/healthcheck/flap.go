@@ -0,0 +1,61 @@
+package healthcheck
+
+// Flap detection follows Nagios: the percent state change is computed over the
+// last FlapHistory raw check results, weighting recent changes more heavily.
+// An endpoint starts flapping when it rises above FlapHighThreshold and stops
+// once it falls below FlapLowThreshold.
+const (
+	FlapHistory       = 21
+	FlapHighThreshold = 50.0
+	FlapLowThreshold  = 25.0
+)
+
+// flapHistory is a ring of the latest raw check outcomes of an endpoint
+type flapHistory struct {
+	outcomes [FlapHistory]bool
+	next     int
+	count    int
+}
+
+// add records a check outcome, overwriting the oldest once full
+func (h *flapHistory) add(up bool) {
+	h.outcomes[h.next] = up
+	h.next = (h.next + 1) % FlapHistory
+	if h.count < FlapHistory {
+		h.count++
+	}
+}
+
+// stateChange returns the weighted percent of state changes in the history.
+// The weight of a change grows linearly from 0.8 for the oldest to 1.2 for
+// the newest.
+func (h *flapHistory) stateChange() float64 {
+	if h.count < 2 {
+		return 0
+	}
+
+	oldest := (h.next - h.count + FlapHistory) % FlapHistory
+	var weighted float64
+	for i := 1; i < h.count; i++ {
+		prev := h.outcomes[(oldest+i-1)%FlapHistory]
+		curr := h.outcomes[(oldest+i)%FlapHistory]
+		if prev != curr {
+			weighted += 0.8 + 0.4*float64(i-1)/float64(FlapHistory-2)
+		}
+	}
+	return weighted / float64(FlapHistory-1) * 100
+}
+
+// updateFlapping records a check outcome and updates whether the endpoint is
+// flapping, using the high and low thresholds as hysteresis
+func (s *EndpointState) updateFlapping(up bool) {
+	s.history.add(up)
+	s.StateChange = s.history.stateChange()
+
+	switch {
+	case !s.Flapping && s.StateChange > FlapHighThreshold:
+		s.Flapping = true
+	case s.Flapping && s.StateChange < FlapLowThreshold:
+		s.Flapping = false
+	}
+}
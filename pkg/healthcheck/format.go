@@ -0,0 +1,99 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration file formats. JSON and TOML files use the same keys as YAML.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// DetectFormat returns the configuration format matching the file extension,
+// defaulting to YAML
+func DetectFormat(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatYAML
+	}
+}
+
+// ParseFormat parses configuration contents in the given format. JSON and
+// TOML documents are converted to YAML so every format shares the same schema,
+// defaults and environment variable expansion.
+func ParseFormat(data []byte, format string) (*Config, error) {
+	return parseFormat(data, format, lookupEnv)
+}
+
+// parseFormat is ParseFormat resolving ${VAR} references with env
+func parseFormat(data []byte, format string, env envLookup) (*Config, error) {
+	converted, err := toYAML(data, format)
+	if err != nil {
+		return nil, err
+	}
+	config, err := parse(converted, isYAML(format), env)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDependencies(config.Endpoints); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// isYAML reports whether a format is YAML, whose documents are parsed as is
+func isYAML(format string) bool {
+	return format == "" || format == FormatYAML
+}
+
+// toYAML converts configuration contents in the given format to YAML
+func toYAML(data []byte, format string) ([]byte, error) {
+	var document any
+	switch format {
+	case "", FormatYAML:
+		return data, nil
+	case FormatJSON:
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("error parsing JSON: %v", err)
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("error parsing TOML: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported configuration format '%s'", format)
+	}
+
+	converted, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("error converting %s configuration: %v", strings.ToUpper(format), err)
+	}
+	return converted, nil
+}
+
+// LoadFormat reads and parses the configuration file at the given path in the
+// given format, detected from the file extension when empty. The path can be
+// a glob pattern such as configs/*.yml, and files can include others, in
+// which case every file is merged into a single configuration.
+func LoadFormat(filePath, format string) (*Config, error) {
+	loader := configLoader{config: &Config{}, origins: make(map[string]string), loaded: make(map[string]bool)}
+	if err := loader.load(filePath, format); err != nil {
+		return nil, err
+	}
+	if err := validateDependencies(loader.config.Endpoints); err != nil {
+		return nil, err
+	}
+	return loader.config, nil
+}
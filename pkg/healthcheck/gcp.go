@@ -0,0 +1,145 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// DefaultGCPMetricPrefix is the type prefix of the custom metrics written to
+// Google Cloud Monitoring
+const DefaultGCPMetricPrefix = "custom.googleapis.com/healthcheck"
+
+// gcpBatchSize is the maximum number of time series of a create request
+const gcpBatchSize = 200
+
+// GCPMonitoringConfig configures the Google Cloud Monitoring output
+type GCPMonitoringConfig struct {
+	ProjectID string `yaml:"project_id"`
+	// MetricPrefix is prepended to the metric names (default: DefaultGCPMetricPrefix)
+	MetricPrefix string `yaml:"metric_prefix,omitempty"`
+}
+
+// GCPMonitoring is a ResultSink writing custom metrics to Google Cloud
+// Monitoring at every summary, labeled with the endpoint name and domain:
+//
+//	<prefix>/up                1 or 0, from the last check
+//	<prefix>/latency_ms        latency of the last check
+//	<prefix>/availability_pct  availability percentage
+//
+// Cloud Monitoring accepts one point per series every few seconds, so only
+// the last check of each endpoint since the previous summary is written.
+type GCPMonitoring struct {
+	Config GCPMonitoringConfig
+	// Client authenticates requests, with Application Default Credentials by default
+	Client *http.Client
+	// URL is the Cloud Monitoring API base URL
+	URL string
+
+	mu   sync.Mutex
+	last map[string]Result
+}
+
+// NewGCPMonitoring returns a GCPMonitoring authenticated with Application
+// Default Credentials, e.g. GOOGLE_APPLICATION_CREDENTIALS or the service
+// account of the instance. The project defaults to the one of the credentials.
+func NewGCPMonitoring(ctx context.Context, config GCPMonitoringConfig) (*GCPMonitoring, error) {
+	credentials, err := google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/monitoring.write")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Google Cloud credentials: %v", err)
+	}
+	if config.ProjectID == "" {
+		config.ProjectID = credentials.ProjectID
+	}
+	if config.ProjectID == "" {
+		return nil, fmt.Errorf("gcp output requires a project_id")
+	}
+	if config.MetricPrefix == "" {
+		config.MetricPrefix = DefaultGCPMetricPrefix
+	}
+	client := oauth2.NewClient(ctx, credentials.TokenSource)
+	client.Timeout = 30 * time.Second
+	return &GCPMonitoring{
+		Config: config,
+		Client: client,
+		URL:    "https://monitoring.googleapis.com",
+	}, nil
+}
+
+// Observe keeps the last result of the endpoint
+func (g *GCPMonitoring) Observe(r Result, stats Availability) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.last == nil {
+		g.last = make(map[string]Result)
+	}
+	g.last[r.Endpoint.Url] = r
+}
+
+// Flush writes the last check and the availability of every endpoint
+func (g *GCPMonitoring) Flush(cycle Cycle) error {
+	now := time.Now()
+
+	g.mu.Lock()
+	last := g.last
+	g.last = nil
+	g.mu.Unlock()
+
+	var series []map[string]any
+	for _, req := range cycle.Endpoints {
+		if r, ok := last[req.Url]; ok {
+			up := 0.0
+			if r.Up {
+				up = 1
+			}
+			series = append(series,
+				g.timeSeries("up", req, up, now),
+				g.timeSeries("latency_ms", req, milliseconds(r.Latency), now))
+		}
+		if stats := cycle.Availability[req.Url]; stats.Total() > 0 {
+			series = append(series, g.timeSeries("availability_pct", req, 100*float64(stats.SuccessCount)/float64(stats.Total()), now))
+		}
+	}
+
+	for start := 0; start < len(series); start += gcpBatchSize {
+		end := min(start+gcpBatchSize, len(series))
+		if err := g.create(series[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeSeries returns a gauge point of a metric of an endpoint
+func (g *GCPMonitoring) timeSeries(metric string, req Configuration, value float64, t time.Time) map[string]any {
+	return map[string]any{
+		"metric": map[string]any{
+			"type":   g.Config.MetricPrefix + "/" + metric,
+			"labels": map[string]string{"name": req.Name, "domain": req.Domain()},
+		},
+		"resource": map[string]any{
+			"type":   "global",
+			"labels": map[string]string{"project_id": g.Config.ProjectID},
+		},
+		"points": []map[string]any{{
+			"interval": map[string]string{"endTime": t.UTC().Format(time.RFC3339Nano)},
+			"value":    map[string]float64{"doubleValue": value},
+		}},
+	}
+}
+
+// create writes time series with the timeSeries.create method
+func (g *GCPMonitoring) create(series []map[string]any) error {
+	url := fmt.Sprintf("%s/v3/projects/%s/timeSeries", strings.TrimSuffix(g.URL, "/"), g.Config.ProjectID)
+	err := postJSON(g.Client, url, nil, map[string]any{"timeSeries": series})
+	if err != nil {
+		return fmt.Errorf("failed to write Google Cloud Monitoring metrics: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package healthcheck
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// livenessIntervals is how many scheduler intervals may pass without a
+// summary before the monitor reports itself as not live
+const livenessIntervals = 3
+
+// monitorHealth tracks the progress of the scheduler for its own health endpoints
+type monitorHealth struct {
+	mu         sync.Mutex
+	started    time.Time
+	lastCycle  time.Time
+	lastCheck  time.Time
+	configTime time.Time
+	configErr  error
+}
+
+// MonitorHealth is the state of the monitor served by /healthz and /readyz
+type MonitorHealth struct {
+	Status    string    `json:"status"`
+	Live      bool      `json:"live"`
+	Ready     bool      `json:"ready"`
+	Started   time.Time `json:"started"`
+	LastCycle time.Time `json:"last_cycle,omitempty"`
+	LastCheck time.Time `json:"last_check,omitempty"`
+	Endpoints int       `json:"endpoints"`
+	// ConfigLoaded is when the configuration was last loaded, successfully
+	// or not, and ConfigError the reason it failed
+	ConfigLoaded time.Time `json:"config_loaded,omitempty"`
+	ConfigError  string    `json:"config_error,omitempty"`
+	// Role is "leader" or "standby" with leader election
+	Role string `json:"role,omitempty"`
+}
+
+// ConfigLoaded records the outcome of loading the configuration, reported
+// by the health endpoints. A failed reload makes the monitor not ready until
+// a configuration loads again.
+func (s *Scheduler) ConfigLoaded(err error) {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	s.health.configTime = time.Now()
+	s.health.configErr = err
+}
+
+// markCycle records the end of a cycle
+func (s *Scheduler) markCycle() {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	s.health.lastCycle = time.Now()
+}
+
+// markCheck records a completed check
+func (s *Scheduler) markCheck(t time.Time) {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	if t.After(s.health.lastCheck) {
+		s.health.lastCheck = t
+	}
+}
+
+// Health reports whether the monitor is live, i.e. it wrote a summary within
+// the last few intervals, and ready, i.e. it completed its first cycle and
+// its configuration loaded
+func (s *Scheduler) Health() MonitorHealth {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+
+	now := time.Now()
+	progress := s.health.lastCycle
+	if progress.IsZero() {
+		progress = s.health.started
+	}
+	health := MonitorHealth{
+		Live:         progress.IsZero() || s.Interval <= 0 || now.Sub(progress) <= livenessIntervals*s.Interval,
+		Ready:        !s.health.lastCycle.IsZero() && s.health.configErr == nil,
+		Started:      s.health.started,
+		LastCycle:    s.health.lastCycle,
+		LastCheck:    s.health.lastCheck,
+		Endpoints:    len(s.Endpoints()),
+		ConfigLoaded: s.health.configTime,
+	}
+	if s.health.configErr != nil {
+		health.ConfigError = s.health.configErr.Error()
+	}
+	if s.Election != nil {
+		health.Role = s.Election.Role()
+	}
+	switch {
+	case !health.Live:
+		health.Status = "stalled"
+	case s.health.lastCycle.IsZero():
+		health.Status = "starting"
+	case !health.Ready:
+		health.Status = "degraded"
+	default:
+		health.Status = "ok"
+	}
+	return health
+}
+
+// HealthHandler returns an http.Handler serving the health of the monitor
+// itself, for liveness and readiness probes:
+//
+//	GET /healthz  200 while the scheduler makes progress, 503 when stalled
+//	GET /readyz   200 once the first cycle completed and the configuration loaded, 503 otherwise
+func (s *Scheduler) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		health := s.Health()
+		code := http.StatusOK
+		if !health.Live {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, health)
+	})
+
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		health := s.Health()
+		code := http.StatusOK
+		if !health.Live || !health.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, health)
+	})
+
+	return mux
+}
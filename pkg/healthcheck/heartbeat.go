@@ -0,0 +1,42 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Heartbeat is a ResultSink requesting a URL at every summary, for dead man's
+// switch services such as healthchecks.io or Uptime Kuma push monitors that
+// alert when the pings stop, i.e. when the monitor itself is down
+type Heartbeat struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHeartbeat returns a Heartbeat requesting the given URL
+func NewHeartbeat(url string) *Heartbeat {
+	return &Heartbeat{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Observe ignores individual results
+func (h *Heartbeat) Observe(r Result, stats Availability) {}
+
+// Flush requests the heartbeat URL
+func (h *Heartbeat) Flush(cycle Cycle) error {
+	resp, err := h.Client.Get(h.URL)
+	if err != nil {
+		return fmt.Errorf("heartbeat failed: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("heartbeat failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package healthcheck
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sync"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// historySchema creates the results table. Times are stored as fixed-width
+// UTC text so they sort correctly and work with SQLite date functions.
+const historySchema = `
+CREATE TABLE IF NOT EXISTS results (
+	id          INTEGER PRIMARY KEY,
+	time        TEXT    NOT NULL,
+	name        TEXT    NOT NULL,
+	url         TEXT    NOT NULL,
+	type        TEXT    NOT NULL,
+	status      TEXT    NOT NULL,
+	outcome     TEXT    NOT NULL,
+	latency_ms  REAL    NOT NULL,
+	status_code INTEGER NOT NULL,
+	error       TEXT    NOT NULL DEFAULT '',
+	retries     INTEGER NOT NULL DEFAULT 0,
+	maintenance TEXT    NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS results_url_time ON results (url, time);
+CREATE INDEX IF NOT EXISTS results_time ON results (time);
+`
+
+// historyTimeFormat is the layout of the time column
+const historyTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// History stores every check result in a SQLite database. Results are
+// buffered and written in a single transaction at every summary.
+type History struct {
+	DB *sql.DB
+
+	mu      sync.Mutex
+	pending []Result
+}
+
+// OpenHistory opens the SQLite database at path, creating it and the results
+// table if needed
+func OpenHistory(path string) (*History, error) {
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database '%s': %v", path, err)
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history tables in '%s': %v", path, err)
+	}
+	return &History{DB: db}, nil
+}
+
+// Close closes the database
+func (h *History) Close() error {
+	return h.DB.Close()
+}
+
+// Observe buffers a check result
+func (h *History) Observe(r Result, stats Availability) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pending = append(h.pending, r)
+}
+
+// Flush writes the buffered results. The buffer is cleared even if the write
+// fails so a broken database doesn't grow memory without bound.
+func (h *History) Flush(cycle Cycle) error {
+	h.mu.Lock()
+	pending := h.pending
+	h.pending = nil
+	h.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	return h.insert(context.Background(), pending)
+}
+
+// insert writes results in a single transaction
+func (h *History) insert(ctx context.Context, results []Result) error {
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO results
+		(time, name, url, type, status, outcome, latency_ms, status_code, error, retries, maintenance)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range results {
+		checkType := r.Endpoint.Type
+		if checkType == "" {
+			checkType = TypeHTTP
+		}
+		var errText string
+		if r.Err != nil {
+			errText = r.Err.Error()
+		}
+		_, err := stmt.ExecContext(ctx,
+			r.Time.UTC().Format(historyTimeFormat), r.Endpoint.Name, r.Endpoint.Url, checkType,
+			string(r.Status()), r.Outcome(), milliseconds(r.Latency), r.StatusCode, errText, r.Retries, r.Maintenance)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
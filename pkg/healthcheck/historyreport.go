@@ -0,0 +1,222 @@
+package healthcheck
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// HistoryReport summarizes the stored history of an endpoint over a period
+type HistoryReport struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+	// First and Last are the times of the first and last check in the period
+	First time.Time `json:"first_check"`
+	Last  time.Time `json:"last_check"`
+
+	TotalChecks         int `json:"total_checks"`
+	FailedChecks        int `json:"failed_checks"`
+	MaintenanceFailures int `json:"maintenance_failures"`
+	// Availability is the percentage of successful checks, excluding failures
+	// during maintenance windows
+	Availability float64 `json:"availability_pct"`
+
+	// Outages counts the periods of consecutive failures. An outage lasts from
+	// its first failed check until the next successful one, or until the last
+	// check when it is still ongoing.
+	Outages int `json:"outages"`
+	// MTTRSeconds is the mean duration of the outages that recovered and
+	// MTBFSeconds the mean time UP between outages
+	MTTRSeconds          float64 `json:"mttr_seconds"`
+	MTBFSeconds          float64 `json:"mtbf_seconds"`
+	LongestOutageSeconds float64 `json:"longest_outage_seconds"`
+	// Ongoing is set when the endpoint was DOWN at its last check
+	Ongoing bool `json:"ongoing_outage"`
+
+	// Latency percentiles of the checks that got a response in time or were slow
+	P50LatencyMs float64 `json:"p50_latency_ms"`
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+	P99LatencyMs float64 `json:"p99_latency_ms"`
+}
+
+// Report summarizes the stored results of every endpoint checked since the
+// given time, ordered by endpoint name
+func (h *History) Report(ctx context.Context, since time.Time) ([]HistoryReport, error) {
+	return h.ReportBetween(ctx, since, time.Now())
+}
+
+// ReportBetween summarizes the stored results of every endpoint checked at or
+// after since and before until, ordered by endpoint name
+func (h *History) ReportBetween(ctx context.Context, since, until time.Time) ([]HistoryReport, error) {
+	builders, err := h.scan(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]HistoryReport, len(builders))
+	for i, builder := range builders {
+		reports[i] = builder.finish()
+	}
+	slices.SortStableFunc(reports, func(a, b HistoryReport) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return reports, nil
+}
+
+// Incidents returns the incidents of every endpoint in the stored results
+// since the given time, oldest first. An incident still ongoing at the last
+// check has no end.
+func (h *History) Incidents(ctx context.Context, since time.Time) ([]Incident, error) {
+	builders, err := h.scan(ctx, since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var incidents []Incident
+	for _, builder := range builders {
+		builder.finish()
+		incidents = append(incidents, builder.incidents...)
+	}
+	slices.SortStableFunc(incidents, func(a, b Incident) int {
+		return a.Start.Compare(b.Start)
+	})
+	return incidents, nil
+}
+
+// scan reads the stored results between since and until into one
+// reportBuilder per endpoint
+func (h *History) scan(ctx context.Context, since, until time.Time) ([]*reportBuilder, error) {
+	rows, err := h.DB.QueryContext(ctx, `SELECT time, name, url, outcome, latency_ms, error FROM results
+		WHERE time >= ? AND time < ? ORDER BY url, time`,
+		since.UTC().Format(historyTimeFormat), until.UTC().Format(historyTimeFormat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %v", err)
+	}
+	defer rows.Close()
+
+	var builders []*reportBuilder
+	var builder *reportBuilder
+	for rows.Next() {
+		var (
+			timestamp, name, url, outcome, errText string
+			latencyMs                              float64
+		)
+		if err := rows.Scan(&timestamp, &name, &url, &outcome, &latencyMs, &errText); err != nil {
+			return nil, fmt.Errorf("failed to read history: %v", err)
+		}
+		t, err := time.Parse(historyTimeFormat, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q in history: %v", timestamp, err)
+		}
+
+		if builder == nil || builder.report.Url != url {
+			builder = &reportBuilder{report: HistoryReport{Url: url, First: t}}
+			builders = append(builders, builder)
+		}
+		builder.report.Name = name // The latest name wins if the endpoint was renamed
+		builder.add(t, outcome, time.Duration(latencyMs*float64(time.Millisecond)), errText)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+	return builders, nil
+}
+
+// reportBuilder accumulates the results of one endpoint in time order
+type reportBuilder struct {
+	report    HistoryReport
+	latencies []time.Duration
+
+	incidents []Incident // Every outage, the last one ongoing when current is set
+	current   bool
+	recovered []time.Duration
+	longest   time.Duration
+}
+
+// add records a single result
+func (b *reportBuilder) add(t time.Time, outcome string, latency time.Duration, errText string) {
+	r := &b.report
+	r.Last = t
+
+	if outcome == OutcomeMaintenance {
+		r.MaintenanceFailures++
+		return
+	}
+	r.TotalChecks++
+	if outcome == OutcomeUp || outcome == OutcomeSlow {
+		b.latencies = append(b.latencies, latency)
+	}
+
+	if outcome == OutcomeUp {
+		if b.current {
+			incident := &b.incidents[len(b.incidents)-1]
+			end := t
+			incident.End = &end
+			incident.DurationSeconds = t.Sub(incident.Start).Seconds()
+			b.recovered = append(b.recovered, incident.Duration())
+			b.longest = max(b.longest, incident.Duration())
+			b.current = false
+		}
+		return
+	}
+
+	r.FailedChecks++
+	if !b.current {
+		b.incidents = append(b.incidents, Incident{Start: t, Error: errText})
+		b.current = true
+		r.Outages++
+	}
+	incident := &b.incidents[len(b.incidents)-1]
+	incident.FailedChecks++
+	incident.DurationSeconds = t.Sub(incident.Start).Seconds()
+}
+
+// finish computes the aggregates once every result has been added
+func (b *reportBuilder) finish() HistoryReport {
+	r := b.report
+
+	if r.TotalChecks > 0 {
+		pct := float64(r.TotalChecks-r.FailedChecks) / float64(r.TotalChecks) * 100
+		r.Availability = math.Round(pct*100) / 100
+	}
+
+	if b.current {
+		// An ongoing outage lasts until the last check
+		r.Ongoing = true
+		incident := &b.incidents[len(b.incidents)-1]
+		incident.DurationSeconds = r.Last.Sub(incident.Start).Seconds()
+		b.longest = max(b.longest, incident.Duration())
+	}
+	var downtime time.Duration
+	for i := range b.incidents {
+		b.incidents[i].Name, b.incidents[i].Url = r.Name, r.Url
+		downtime += b.incidents[i].Duration()
+	}
+	r.LongestOutageSeconds = b.longest.Seconds()
+	if len(b.recovered) > 0 {
+		var total time.Duration
+		for _, outage := range b.recovered {
+			total += outage
+		}
+		r.MTTRSeconds = (total / time.Duration(len(b.recovered))).Seconds()
+	}
+	if r.Outages > 0 {
+		r.MTBFSeconds = (max(r.Last.Sub(r.First)-downtime, 0) / time.Duration(r.Outages)).Seconds()
+	}
+
+	slices.Sort(b.latencies)
+	r.P50LatencyMs = milliseconds(nearestRank(b.latencies, 50))
+	r.P95LatencyMs = milliseconds(nearestRank(b.latencies, 95))
+	r.P99LatencyMs = milliseconds(nearestRank(b.latencies, 99))
+	return r
+}
+
+// nearestRank returns the p-th percentile of sorted latencies
+func nearestRank(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
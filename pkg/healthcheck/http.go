@@ -0,0 +1,413 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Connection pool defaults for the shared HTTP transports
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// DefaultMaxRedirects is the number of redirects followed when an endpoint doesn't set max_redirects
+const DefaultMaxRedirects = 10
+
+// HTTPChecker checks endpoints over HTTP. Checks share pooled transports so
+// keep-alive connections and TLS sessions are reused across checks.
+type HTTPChecker struct {
+	// LatencyThreshold is the maximum latency for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the whole request unless the endpoint sets its own
+	Timeout time.Duration
+
+	// Connection pool tuning, applied to transports created after they are set
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	mu         sync.Mutex
+	transports map[transportKey]http.RoundTripper
+	tokens     map[tokenKey]oauth2.TokenSource
+	jars       map[string]http.CookieJar
+}
+
+// transportKey identifies the endpoint settings that require a dedicated transport
+type transportKey struct {
+	tls   TLSConfig
+	proxy string
+	// resolve pins host to an address
+	host, resolve string
+	ipVersion     string
+	http1, http3  bool
+}
+
+// ProxyDirect is the proxy setting that bypasses the environment proxy
+const ProxyDirect = "direct"
+
+func init() {
+	Register(TypeHTTP, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewHTTPChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewHTTPChecker returns an HTTPChecker using the given latency threshold and default timeout
+func NewHTTPChecker(latencyThreshold, timeout time.Duration) *HTTPChecker {
+	return &HTTPChecker{
+		LatencyThreshold:    latencyThreshold,
+		Timeout:             timeout,
+		MaxIdleConns:        DefaultMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+		transports:          make(map[transportKey]http.RoundTripper),
+		tokens:              make(map[tokenKey]oauth2.TokenSource),
+		jars:                make(map[string]http.CookieJar),
+	}
+}
+
+// transport returns the shared transport for the endpoint settings, creating
+// it on first use. TLS files are loaded once per distinct configuration.
+// Endpoints without a proxy setting use the proxy of the environment.
+func (c *HTTPChecker) transport(req Configuration) (http.RoundTripper, error) {
+	key := transportKey{
+		proxy:     req.Proxy,
+		ipVersion: req.IPVersion,
+		http1:     req.HTTPVersion == "1.1",
+		http3:     req.HTTPVersion == "3",
+	}
+	if req.TLS != nil {
+		key.tls = *req.TLS
+	}
+	if req.Resolve != "" {
+		key.host, key.resolve = hostname(req.Url), req.Resolve
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if transport, exists := c.transports[key]; exists {
+		return transport, nil
+	}
+
+	if key.http3 {
+		transport, err := http3Transport(req, key)
+		if err != nil {
+			return nil, err
+		}
+		c.transports[key] = transport
+		return transport, nil
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, req.network(network), addr)
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		IdleConnTimeout:       c.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	switch req.Proxy {
+	case "":
+	case ProxyDirect:
+		transport.Proxy = nil
+	default:
+		proxyUrl, err := url.Parse(req.Proxy)
+		if err != nil || proxyUrl.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL '%s'", req.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	if req.TLS != nil {
+		tlsConfig, err := req.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	if key.http1 {
+		// A non-nil empty map disables HTTP/2 negotiation
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	if req.Resolve != "" {
+		// Connections to the pinned host go straight to its address, the
+		// Host header and SNI still come from the URL
+		transport.Proxy = nil
+		transport.DialContext = pinnedDial(transport.DialContext, key.host, req.Resolve)
+	}
+
+	c.transports[key] = transport
+	return transport, nil
+}
+
+// Check sends a request to the endpoint and measures its latency
+func (c *HTTPChecker) Check(ctx context.Context, req Configuration) Result {
+	var jar http.CookieJar
+	if req.CookieJar {
+		var err error
+		if jar, err = c.sessionJar(req.Url); err != nil {
+			return Result{Endpoint: req, Time: time.Now(), Err: err}
+		}
+	}
+	result, resp := c.exchange(ctx, req, jar, req.Cache != nil)
+	if result.Up && req.Cache != nil {
+		if err := c.checkCache(ctx, req, jar, resp.Header); err != nil {
+			result.Up = false
+			result.Err = err
+		}
+	}
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// response is what later steps of a transaction can extract variables from
+type response struct {
+	Header http.Header
+	Body   []byte
+}
+
+// exchange sends the request of an endpoint and validates its status and
+// body, without applying the latency threshold. The response headers and
+// body are returned when keepBody is set and the request succeeded. Cookies
+// are stored in jar when it's not nil.
+func (c *HTTPChecker) exchange(ctx context.Context, req Configuration, jar http.CookieJar, keepBody bool) (Result, *response) {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	// Set default method to GET if not specified
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	// Attach the request body if any
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+
+	// Create HTTP request
+	httpReq, err := http.NewRequestWithContext(ctx, method, req.Url, body)
+	if err != nil {
+		result.Err = err
+		return result, nil
+	}
+	if req.ContentType != "" {
+		httpReq.Header.Set("Content-Type", req.ContentType)
+	}
+
+	// Add headers if any
+	if req.AcceptEncoding != "" {
+		httpReq.Header.Set("Accept-Encoding", req.AcceptEncoding)
+	}
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	// Initialize HTTP client with timeout on the shared transport
+	transport, err := c.transport(req)
+	if err != nil {
+		result.Err = err
+		return result, nil
+	}
+
+	// Add credentials if any
+	if req.Auth != nil {
+		if err := c.authorize(httpReq, req, transport); err != nil {
+			result.Err = err
+			return result, nil
+		}
+	}
+
+	// Sign the request if the endpoint requires signed requests
+	if req.Sign != nil {
+		if err := req.Sign.apply(httpReq, req.Body, time.Now()); err != nil {
+			result.Err = err
+			return result, nil
+		}
+	}
+	client := &http.Client{
+		Timeout:       req.timeoutOr(c.Timeout),
+		Transport:     transport,
+		CheckRedirect: req.checkRedirect,
+		Jar:           jar,
+	}
+
+	// Trace the phases of the request and the address it was sent to
+	trace := &phaseTrace{}
+	httpReq = httpReq.WithContext(trace.context(ctx))
+
+	// Measure latency, up to the end of the body so it includes every phase
+	startTime := time.Now()
+	resp, err := client.Do(httpReq)
+
+	if err != nil {
+		result.Latency = time.Since(startTime)
+		result.Phases, result.RemoteAddr = trace.result()
+		result.Err = err
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	// Read the body, which also lets the connection be reused, and time the
+	// transfer. Compressed bodies are decoded so assertions see the content.
+	// Only the start is kept for assertions but all of it is counted and
+	// hashed.
+	transferStart := time.Now()
+	wire := &countingReader{reader: resp.Body}
+	decoded, encoding, readErr := decodeBody(resp, wire)
+	defer decoded.Close()
+	var reader io.Reader = decoded
+	hash := sha256.New()
+	if req.hashesBody() {
+		reader = io.TeeReader(decoded, hash)
+	}
+	respBody, err := io.ReadAll(io.LimitReader(reader, maxBodySize))
+	rest, drainErr := io.Copy(io.Discard, reader)
+	if readErr == nil {
+		readErr = errors.Join(err, drainErr)
+	}
+	result.Size = int64(len(respBody)) + rest
+	result.Encoding = encoding
+	if result.compressed() && !resp.Uncompressed {
+		result.CompressedSize = wire.n
+	}
+	if req.hashesBody() && readErr == nil {
+		result.Checksum = hex.EncodeToString(hash.Sum(nil))
+	}
+	result.Latency = time.Since(startTime)
+	result.Phases, result.RemoteAddr = trace.result()
+	result.Phases.Transfer = time.Since(transferStart)
+
+	// Determine UP or DOWN
+	result.StatusCode = resp.StatusCode
+	result.Protocol = resp.Proto
+	result.Up = req.StatusExpected(resp.StatusCode)
+
+	// A downgrade from a required HTTP/2 is a failure
+	if result.Up && req.HTTPVersion == "2" && resp.ProtoMajor != 2 {
+		result.Up = false
+		result.Err = fmt.Errorf("negotiated %s, expected HTTP/2", resp.Proto)
+	}
+
+	// A response sent uncompressed is a failure when compression is expected
+	if result.Up && req.ExpectCompressed && !result.compressed() {
+		result.Up = false
+		result.Err = fmt.Errorf("response is not compressed")
+	}
+
+	// Validate the response headers if the endpoint asserts on them
+	if result.Up {
+		if err := assertHeaders(req, resp.Header); err != nil {
+			result.Up = false
+			result.Err = err
+		}
+	}
+
+	// Validate the response size if the endpoint limits it
+	if result.Up && req.checksSize() {
+		err := readErr
+		if err == nil {
+			err = assertSize(req, result.Size)
+		}
+		if err != nil {
+			result.Up = false
+			result.Err = err
+		}
+	}
+
+	// Validate the response checksum if the endpoint expects one
+	if result.Up && req.ExpectSHA256 != "" {
+		err := readErr
+		if err == nil {
+			err = assertChecksum(req, result.Checksum)
+		}
+		if err != nil {
+			result.Up = false
+			result.Err = err
+		}
+	}
+
+	// Validate the response body if the endpoint asserts on its content
+	var kept *response
+	if result.Up && (keepBody || req.wantsBody()) {
+		err := readErr
+		if err == nil {
+			err = assertBody(req, respBody)
+		}
+		if err != nil {
+			result.Up = false
+			result.Err = err
+		} else if keepBody {
+			kept = &response{Header: resp.Header, Body: respBody}
+		}
+	}
+	return result, kept
+}
+
+// checkRedirect returns the redirect policy of the endpoint
+func (c Configuration) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.FollowRedirects != nil && !*c.FollowRedirects {
+		return http.ErrUseLastResponse
+	}
+
+	limit := c.MaxRedirects
+	if limit <= 0 {
+		limit = DefaultMaxRedirects
+	}
+	if len(via) > limit {
+		return fmt.Errorf("stopped after %d redirects", limit)
+	}
+	return nil
+}
+
+// pinnedDial returns a dial function connecting to address instead of host.
+// address is an IP, optionally with a port replacing the port of the URL.
+// Other hosts, e.g. after a redirect, are resolved normally.
+func pinnedDial(dial func(ctx context.Context, network, addr string) (net.Conn, error), host, address string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	pinnedHost, pinnedPort, err := net.SplitHostPort(address)
+	if err != nil {
+		pinnedHost = strings.Trim(address, "[]")
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if h, port, err := net.SplitHostPort(addr); err == nil && strings.EqualFold(h, host) {
+			if pinnedPort != "" {
+				port = pinnedPort
+			}
+			addr = net.JoinHostPort(pinnedHost, port)
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+// hostname returns the host of a URL without its port
+func hostname(rawUrl string) string {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	return parsedUrl.Hostname()
+}
@@ -0,0 +1,94 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3Transport returns a transport sending requests over HTTP/3. The QUIC
+// connections share a single UDP socket per transport. Proxies don't apply,
+// the resolve and IP version settings do.
+func http3Transport(req Configuration, key transportKey) (http.RoundTripper, error) {
+	transport := &http3.Transport{}
+	if req.TLS != nil {
+		tlsConfig, err := req.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	udpConn, err := net.ListenUDP(req.network("udp"), nil)
+	if err != nil {
+		return nil, err
+	}
+	quicTransport := &quic.Transport{Conn: udpConn}
+
+	transport.Dial = func(ctx context.Context, addr string, tlsConfig *tls.Config, config *quic.Config) (*quic.Conn, error) {
+		udpAddr, err := resolveUDPAddr(ctx, req.network("ip"), addr, key)
+		if err != nil {
+			return nil, err
+		}
+
+		// The QUIC handshake establishes the connection and TLS at once
+		trace := httptrace.ContextClientTrace(ctx)
+		if trace != nil && trace.ConnectStart != nil {
+			trace.ConnectStart("udp", udpAddr.String())
+		}
+		conn, err := quicTransport.DialEarly(ctx, udpAddr, tlsConfig, config)
+		if trace != nil && trace.ConnectDone != nil {
+			trace.ConnectDone("udp", udpAddr.String(), err)
+		}
+		return conn, err
+	}
+	return transport, nil
+}
+
+// resolveUDPAddr resolves the host:port address of a request, honoring the
+// resolve override of the transport and reporting the lookup to the trace
+// of ctx
+func resolveUDPAddr(ctx context.Context, network, addr string, key transportKey) (*net.UDPAddr, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if key.resolve != "" && strings.EqualFold(host, key.host) {
+		pinnedHost, pinnedPort, err := net.SplitHostPort(key.resolve)
+		if err != nil {
+			pinnedHost = strings.Trim(key.resolve, "[]")
+		} else {
+			port = pinnedPort
+		}
+		host = pinnedHost
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, err
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return net.UDPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(portNumber))), nil
+	}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, network, host)
+	if trace != nil && trace.DNSDone != nil {
+		trace.DNSDone(httptrace.DNSDoneInfo{Err: err})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return net.UDPAddrFromAddrPort(netip.AddrPortFrom(ips[0].Unmap(), uint16(portNumber))), nil
+}
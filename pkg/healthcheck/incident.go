@@ -0,0 +1,127 @@
+package healthcheck
+
+import (
+	"slices"
+	"time"
+)
+
+// maxRecentIncidents bounds the recovered incidents kept per endpoint
+const maxRecentIncidents = 50
+
+// Incident is a period of consecutive failed checks of an endpoint, from the
+// first failure until the next successful check. Failures during maintenance
+// windows neither open nor extend an incident.
+type Incident struct {
+	Name  string    `json:"name"`
+	Url   string    `json:"url"`
+	Start time.Time `json:"start"`
+	// End is the time of the successful check that closed the incident, nil
+	// while it is ongoing
+	End *time.Time `json:"end,omitempty"`
+	// DurationSeconds lasts until End, or until the latest failed check while
+	// the incident is ongoing
+	DurationSeconds float64 `json:"duration_seconds"`
+	FailedChecks    int     `json:"failed_checks"`
+	// Error is the error of the first failed check
+	Error string `json:"error,omitempty"`
+}
+
+// Ongoing reports whether the endpoint is still failing
+func (i Incident) Ongoing() bool {
+	return i.End == nil
+}
+
+// Duration returns the length of the incident
+func (i Incident) Duration() time.Duration {
+	return time.Duration(i.DurationSeconds * float64(time.Second))
+}
+
+// IncidentStats tracks the incidents of an endpoint
+type IncidentStats struct {
+	// Count is the number of incidents, including an ongoing one
+	Count int
+	// Recovered is the number of incidents that ended and Downtime their
+	// total duration
+	Recovered int
+	Downtime  time.Duration
+	// FirstCheck and LastCheck delimit the observed period
+	FirstCheck time.Time
+	LastCheck  time.Time
+	// Current is the ongoing incident, nil when the endpoint is UP
+	Current *Incident
+	// Recent holds the latest recovered incidents, oldest first
+	Recent []Incident
+}
+
+// record updates the incidents with a check result. Current and Recent are
+// replaced rather than modified so copies of the stats stay consistent.
+func (s *IncidentStats) record(r Result) {
+	if s.FirstCheck.IsZero() {
+		s.FirstCheck = r.Time
+	}
+	s.LastCheck = r.Time
+
+	if !r.Up {
+		var incident Incident
+		if s.Current != nil {
+			incident = *s.Current
+		} else {
+			s.Count++
+			incident = Incident{Name: r.Endpoint.Name, Url: r.Endpoint.Url, Start: r.Time}
+			if r.Err != nil {
+				incident.Error = r.Err.Error()
+			}
+		}
+		incident.FailedChecks++
+		incident.DurationSeconds = r.Time.Sub(incident.Start).Seconds()
+		s.Current = &incident
+		return
+	}
+
+	if s.Current == nil {
+		return
+	}
+	incident := *s.Current
+	end := r.Time
+	incident.End = &end
+	incident.DurationSeconds = end.Sub(incident.Start).Seconds()
+	s.Current = nil
+	s.Recovered++
+	s.Downtime += incident.Duration()
+
+	recent := slices.Clip(s.Recent)
+	if len(recent) >= maxRecentIncidents {
+		recent = recent[len(recent)-maxRecentIncidents+1:]
+	}
+	s.Recent = append(recent, incident)
+}
+
+// Timeline returns the recent incidents followed by the ongoing one
+func (s IncidentStats) Timeline() []Incident {
+	timeline := slices.Clone(s.Recent)
+	if s.Current != nil {
+		timeline = append(timeline, *s.Current)
+	}
+	return timeline
+}
+
+// MTTR returns the mean time to recovery of the recovered incidents
+func (s IncidentStats) MTTR() time.Duration {
+	if s.Recovered == 0 {
+		return 0
+	}
+	return s.Downtime / time.Duration(s.Recovered)
+}
+
+// MTBF returns the mean time between failures: the time the endpoint was UP
+// over the observed period divided by the number of incidents
+func (s IncidentStats) MTBF() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	downtime := s.Downtime
+	if s.Current != nil {
+		downtime += s.Current.Duration()
+	}
+	return max(s.LastCheck.Sub(s.FirstCheck)-downtime, 0) / time.Duration(s.Count)
+}
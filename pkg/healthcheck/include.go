@@ -0,0 +1,131 @@
+package healthcheck
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// configLoader merges configuration files matched by a glob pattern or
+// included by other files. Endpoints and maintenance windows are combined,
+// while alerting, alert_routes, outputs and digest may only be set in one file.
+type configLoader struct {
+	config *Config
+	// origins maps endpoint names and sections to the file defining them
+	origins map[string]string
+	// loaded holds the absolute paths of the files already merged
+	loaded map[string]bool
+}
+
+// load merges the files matching pattern, parsed in the given format or the
+// one of their extension, followed by the files they include
+func (l *configLoader) load(pattern, format string) error {
+	files := []string{pattern}
+	if hasGlobMeta(pattern) {
+		var err error
+		if files, err = filepath.Glob(pattern); err != nil {
+			return fmt.Errorf("invalid configuration pattern '%s': %v", pattern, err)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no configuration file matches '%s'", pattern)
+		}
+	}
+	l.config.Sources = append(l.config.Sources, filepath.Clean(pattern))
+
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		if l.loaded[abs] {
+			continue
+		}
+		l.loaded[abs] = true
+
+		config, err := l.parseFile(file, format)
+		if err != nil {
+			return err
+		}
+		if err := l.merge(file, config); err != nil {
+			return err
+		}
+		for _, include := range config.Include {
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(file), include)
+			}
+			if err := l.load(include, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseFile reads and parses a single configuration file. Errors mention the
+// file unless it is the only one.
+func (l *configLoader) parseFile(file, format string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file '%s': %v", file, err)
+	}
+	if format == "" {
+		format = DetectFormat(file)
+	}
+	converted, err := toYAML(data, format)
+	var config *Config
+	if err == nil {
+		config, err = parse(converted, isYAML(format), lookupEnv)
+	}
+	if err != nil && (len(l.loaded) > 1 || hasGlobMeta(l.config.Sources[0])) {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return config, err
+}
+
+// merge adds the endpoints and sections of a file to the configuration,
+// rejecting endpoint names and sections already defined by another file
+func (l *configLoader) merge(file string, config *Config) error {
+	for _, req := range config.Endpoints {
+		if origin, exists := l.origins["endpoint "+req.Name]; exists && origin != file {
+			return fmt.Errorf("endpoint '%s' is defined in both '%s' and '%s'", req.Name, origin, file)
+		}
+		l.origins["endpoint "+req.Name] = file
+	}
+	l.config.Endpoints = append(l.config.Endpoints, config.Endpoints...)
+	l.config.Maintenance = append(l.config.Maintenance, config.Maintenance...)
+
+	sections := []struct {
+		name   string
+		value  any
+		target any
+	}{
+		{"alerting", config.Alerting, &l.config.Alerting},
+		{"alert_routes", config.AlertRoutes, &l.config.AlertRoutes},
+		{"outputs", config.Outputs, &l.config.Outputs},
+		{"digest", config.Digest, &l.config.Digest},
+	}
+	for _, section := range sections {
+		value := reflect.ValueOf(section.value)
+		if value.IsZero() {
+			continue
+		}
+		if origin, exists := l.origins[section.name]; exists {
+			return fmt.Errorf("%s is defined in both '%s' and '%s'", section.name, origin, file)
+		}
+		l.origins[section.name] = file
+		reflect.ValueOf(section.target).Elem().Set(value)
+	}
+	return nil
+}
+
+// hasGlobMeta reports whether a path contains glob metacharacters
+func hasGlobMeta(path string) bool {
+	for _, c := range path {
+		switch c {
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package healthcheck
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InfluxWriter buffers check results as InfluxDB line protocol and writes them,
+// together with the availability of every endpoint, once per cycle to a file
+// or an InfluxDB HTTP write endpoint. Points are written to two measurements:
+//
+//	healthcheck               one point per check: up, latency_ms, status_code
+//	healthcheck_availability  one point per endpoint and cycle: availability_pct and counters
+type InfluxWriter struct {
+	// Target is a file path, or an http(s) URL of a write endpoint such as
+	// http://localhost:8086/api/v2/write?org=my-org&bucket=healthcheck
+	Target string
+	// Token, when set, is sent as "Authorization: Token <token>" to HTTP targets
+	Token  string
+	Client *http.Client
+
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+// NewInfluxWriter returns an InfluxWriter writing to the given file or URL
+func NewInfluxWriter(target, token string) *InfluxWriter {
+	return &InfluxWriter{
+		Target: target,
+		Token:  token,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Observe buffers a check result
+func (w *InfluxWriter) Observe(r Result, stats Availability) {
+	up := 0
+	if r.Up {
+		up = 1
+	}
+	fields := fmt.Sprintf("up=%di,latency_ms=%g,status_code=%di", up, milliseconds(r.Latency), r.StatusCode)
+	if r.Maintenance != "" {
+		fields += ",maintenance=" + influxString(r.Maintenance)
+	}
+	if r.Err != nil {
+		fields += ",error=" + influxString(r.Err.Error())
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(&w.buffer, "healthcheck,%s %s %d\n", influxTags(r.Endpoint), fields, r.Time.UnixNano())
+}
+
+// Flush appends the availability of every endpoint to the buffered results
+// and writes them to the target. The buffer is cleared even if the write
+// fails so a broken target doesn't grow memory without bound.
+func (w *InfluxWriter) Flush(cycle Cycle) error {
+	now := time.Now().UnixNano()
+
+	w.mu.Lock()
+	for _, req := range cycle.Endpoints {
+		stats := cycle.Availability[req.Url]
+		if stats.Total() == 0 {
+			continue
+		}
+		fmt.Fprintf(&w.buffer, "healthcheck_availability,%s availability_pct=%di,total_checks=%di,successful_checks=%di,failed_checks=%di,avg_latency_ms=%g %d\n",
+			influxTags(req), stats.Percentage(), stats.Total(), stats.SuccessCount, stats.FailureCount, milliseconds(stats.AverageLatency()), now)
+	}
+	data := bytes.Clone(w.buffer.Bytes())
+	w.buffer.Reset()
+	w.mu.Unlock()
+
+	if len(data) == 0 {
+		return nil
+	}
+	if strings.HasPrefix(w.Target, "http://") || strings.HasPrefix(w.Target, "https://") {
+		return w.post(data)
+	}
+	return w.append(data)
+}
+
+// post sends the points to an InfluxDB write endpoint
+func (w *InfluxWriter) post(data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.Target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.Token)
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("InfluxDB write returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// append adds the points to the end of the target file
+func (w *InfluxWriter) append(data []byte) error {
+	file, err := os.OpenFile(w.Target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open line protocol file '%s': %v", w.Target, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write line protocol file '%s': %v", w.Target, err)
+	}
+	return file.Close()
+}
+
+// influxTags formats the tag set of an endpoint. Endpoint tags are joined
+// into a single comma separated tag.
+func influxTags(req Configuration) string {
+	tags := fmt.Sprintf("endpoint=%s,domain=%s", influxTag(req.Name), influxTag(req.Domain()))
+	if len(req.Tags) > 0 {
+		tags += ",tags=" + influxTag(strings.Join(req.Tags, ","))
+	}
+	return tags
+}
+
+// influxTag escapes a tag value. Newlines can't be escaped and become
+// escaped spaces.
+var influxTag = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\ `).Replace
+
+// influxString quotes a string field value
+func influxString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
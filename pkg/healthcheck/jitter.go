@@ -0,0 +1,54 @@
+package healthcheck
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+)
+
+// staggerOffset returns the stable position of an endpoint within its
+// interval, derived from its URL so that endpoints sharing an interval are
+// spread evenly across it and keep their slot across reloads and restarts
+func staggerOffset(url string, interval time.Duration) time.Duration {
+	h := fnv.New64a()
+	h.Write([]byte(url))
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
+// untilSlot returns the wait until the next time the endpoint's interval
+// crosses its stagger offset
+func untilSlot(now time.Time, url string, interval time.Duration) time.Duration {
+	elapsed := time.Duration(now.UnixNano() % int64(interval))
+	wait := staggerOffset(url, interval) - elapsed
+	if wait < 0 {
+		wait += interval
+	}
+	return wait
+}
+
+// jitter returns a random delay of up to max, capped at half the interval so
+// consecutive checks of an endpoint never overlap
+func jitter(max, interval time.Duration) time.Duration {
+	max = min(max, interval/2)
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
+
+// sleepContext waits for d and reports whether it elapsed before ctx was
+// cancelled
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,224 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// TypeKafka is a Kafka cluster check
+const TypeKafka = "kafka"
+
+// KafkaChecker checks that a Kafka cluster answers a metadata request through
+// one of its bootstrap brokers. When the endpoint names a topic, every
+// partition must have a leader and at least min_isr in-sync replicas.
+type KafkaChecker struct {
+	// LatencyThreshold is the maximum latency of the metadata request for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the request to each broker unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeKafka, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewKafkaChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewKafkaChecker returns a KafkaChecker using the given latency threshold and default timeout
+func NewKafkaChecker(latencyThreshold, timeout time.Duration) *KafkaChecker {
+	return &KafkaChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check fetches the metadata of the topic from the first broker that answers
+// and measures the latency of that request
+func (c *KafkaChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	transport, err := kafkaTransport(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer transport.CloseIdleConnections()
+	client := &kafka.Client{Transport: transport, Timeout: req.timeoutOr(c.Timeout)}
+
+	var topics []string
+	if req.Topic != "" {
+		topics = []string{req.Topic}
+	}
+
+	// The brokers are tried in order, like a client bootstrapping
+	var metadata *kafka.MetadataResponse
+	var failures []string
+	for _, broker := range kafkaBrokers(req.Url) {
+		startTime := time.Now()
+		metadata, err = client.Metadata(ctx, &kafka.MetadataRequest{
+			Addr:   kafka.TCP(broker),
+			Topics: topics,
+		})
+		result.Latency = time.Since(startTime)
+		if err == nil {
+			result.RemoteAddr = broker
+			break
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", broker, err))
+	}
+	if metadata == nil {
+		result.Err = fmt.Errorf("no broker answered: %s", strings.Join(failures, "; "))
+		return result
+	}
+
+	if req.Topic != "" {
+		if err := checkTopic(metadata, req.Topic, req.MinISR); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// kafkaTransport returns a transport honoring the TLS, credentials and IP
+// version settings of the endpoint. Basic auth is sent with SASL/PLAIN.
+func kafkaTransport(req Configuration) (*kafka.Transport, error) {
+	dialer := &net.Dialer{}
+	transport := &kafka.Transport{
+		ClientID: "healthcheck",
+		Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, req.network(network), addr)
+		},
+	}
+	if req.TLS != nil {
+		tlsConfig, err := req.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLS = tlsConfig
+	}
+	if req.Auth != nil {
+		username, password, err := req.Auth.basicCredentials()
+		if err != nil {
+			return nil, err
+		}
+		transport.SASL = plain.Mechanism{Username: username, Password: password}
+	}
+	return transport, nil
+}
+
+// kafkaBrokers returns the host:port of every broker of a
+// kafka://host1:port,host2:port URL or plain broker list
+func kafkaBrokers(rawUrl string) []string {
+	list := strings.TrimPrefix(rawUrl, TypeKafka+"://")
+	list, _, _ = strings.Cut(list, "/")
+
+	var brokers []string
+	for _, broker := range strings.Split(list, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, serviceAddress(broker, "9092"))
+		}
+	}
+	return brokers
+}
+
+// checkTopic verifies that the topic exists and that each of its partitions
+// has a leader and enough in-sync replicas
+func checkTopic(metadata *kafka.MetadataResponse, name string, minISR int) error {
+	for _, topic := range metadata.Topics {
+		if topic.Name != name {
+			continue
+		}
+		if topic.Error != nil {
+			return fmt.Errorf("topic %s: %v", name, topic.Error)
+		}
+		if len(topic.Partitions) == 0 {
+			return fmt.Errorf("topic %s has no partitions", name)
+		}
+		for _, partition := range topic.Partitions {
+			if partition.Error != nil && !errors.Is(partition.Error, kafka.ReplicaNotAvailable) {
+				return fmt.Errorf("topic %s partition %d: %v", name, partition.ID, partition.Error)
+			}
+			if partition.Leader.Host == "" {
+				return fmt.Errorf("topic %s partition %d has no leader", name, partition.ID)
+			}
+			if len(partition.Isr) < minISR {
+				return fmt.Errorf("topic %s partition %d has %d in-sync replicas, expected at least %d", name, partition.ID, len(partition.Isr), minISR)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("topic %s not found", name)
+}
+
+// KafkaOutputConfig publishes every check result to a Kafka topic
+type KafkaOutputConfig struct {
+	// URL lists the bootstrap brokers as kafka://host1:9092,host2:9092
+	URL   string `yaml:"url"`
+	Topic string `yaml:"topic"`
+	// Auth authenticates with SASL/PLAIN, from basic auth
+	Auth *Auth      `yaml:"auth,omitempty"`
+	TLS  *TLSConfig `yaml:"tls,omitempty"`
+}
+
+// kafkaPublisher produces messages keyed by endpoint name, so the results
+// of an endpoint stay ordered within a partition
+type kafkaPublisher struct {
+	writer *kafka.Writer
+	topic  string
+}
+
+// NewKafkaStream returns a Stream publishing results to a Kafka topic
+func NewKafkaStream(config KafkaOutputConfig) (*Stream, error) {
+	switch {
+	case config.URL == "":
+		return nil, fmt.Errorf("kafka: url is required")
+	case config.Topic == "":
+		return nil, fmt.Errorf("kafka: topic is required")
+	}
+	transport, err := kafkaTransport(Configuration{Url: config.URL, Auth: config.Auth, TLS: config.TLS})
+	if err != nil {
+		return nil, fmt.Errorf("kafka: %v", err)
+	}
+	publisher := &kafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(kafkaBrokers(config.URL)...),
+			Topic:        config.Topic,
+			Balancer:     &kafka.Hash{},
+			Transport:    transport,
+			RequiredAcks: kafka.RequireOne,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		topic: config.Topic,
+	}
+	return newStream(publisher), nil
+}
+
+// name identifies the topic in errors
+func (p *kafkaPublisher) name() string {
+	return "Kafka topic " + p.topic
+}
+
+// publish produces a message for every result
+func (p *kafkaPublisher) publish(ctx context.Context, messages []streamMessage) error {
+	records := make([]kafka.Message, len(messages))
+	for i, message := range messages {
+		records[i] = kafka.Message{Key: []byte(message.Endpoint), Value: message.Payload}
+	}
+	return p.writer.WriteMessages(ctx, records...)
+}
+
+// close flushes pending messages and closes the connections to the brokers
+func (p *kafkaPublisher) close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,286 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Service account files mounted into every pod
+const (
+	serviceAccountDir   = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountToken = serviceAccountDir + "/token"
+	serviceAccountCA    = serviceAccountDir + "/ca.crt"
+)
+
+// Annotations read from Services and Ingresses
+const (
+	// AnnotationPath sets the HTTP path checked on a Service or Ingress
+	AnnotationPath = "healthcheck.io/path"
+	// AnnotationEnabled set to "false" excludes an object from discovery
+	AnnotationEnabled = "healthcheck.io/enabled"
+)
+
+// KubernetesDiscoverer synthesizes endpoints from the Services and Ingresses
+// of a Kubernetes cluster. Services are checked over TCP, or over HTTP when
+// the port is named or declared as http/https. Ingresses are checked over
+// HTTP(S) for every host and path.
+type KubernetesDiscoverer struct {
+	// APIServer is the base URL of the Kubernetes API
+	APIServer string
+	// Token is sent as a bearer token when set
+	Token  string
+	Client *http.Client
+
+	// Namespace limits discovery to a single namespace. All namespaces when empty.
+	Namespace string
+	// LabelSelector filters objects by label, e.g. "team=payments"
+	LabelSelector string
+	// Annotation, when set, only discovers objects carrying this annotation
+	Annotation string
+}
+
+// NewKubernetesDiscoverer returns a KubernetesDiscoverer using the given API
+// server, e.g. http://127.0.0.1:8001 for `kubectl proxy`
+func NewKubernetesDiscoverer(apiServer, token string) *KubernetesDiscoverer {
+	return &KubernetesDiscoverer{
+		APIServer: strings.TrimSuffix(apiServer, "/"),
+		Token:     token,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewInClusterDiscoverer returns a KubernetesDiscoverer authenticating with
+// the service account of the pod it runs in
+func NewInClusterDiscoverer() (*KubernetesDiscoverer, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+
+	token, err := os.ReadFile(serviceAccountToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %v", err)
+	}
+	ca, err := os.ReadFile(serviceAccountCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in '%s'", serviceAccountCA)
+	}
+
+	d := NewKubernetesDiscoverer("https://"+net.JoinHostPort(host, port), strings.TrimSpace(string(token)))
+	d.Client.Transport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}
+	return d, nil
+}
+
+// Name identifies the discoverer in logs
+func (d *KubernetesDiscoverer) Name() string {
+	return "Kubernetes"
+}
+
+// kubeMetadata is the subset of object metadata used for discovery
+type kubeMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type kubeServiceList struct {
+	Items []struct {
+		Metadata kubeMetadata `json:"metadata"`
+		Spec     struct {
+			Type      string `json:"type"`
+			ClusterIP string `json:"clusterIP"`
+			Ports     []struct {
+				Name        string `json:"name"`
+				Port        int    `json:"port"`
+				Protocol    string `json:"protocol"`
+				AppProtocol string `json:"appProtocol"`
+			} `json:"ports"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+type kubeIngressList struct {
+	Items []struct {
+		Metadata kubeMetadata `json:"metadata"`
+		Spec     struct {
+			TLS []struct {
+				Hosts []string `json:"hosts"`
+			} `json:"tls"`
+			Rules []struct {
+				Host string `json:"host"`
+				HTTP *struct {
+					Paths []struct {
+						Path string `json:"path"`
+					} `json:"paths"`
+				} `json:"http"`
+			} `json:"rules"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// Discover lists the Services and Ingresses and returns an endpoint for every
+// Service port and every Ingress host and path
+func (d *KubernetesDiscoverer) Discover(ctx context.Context) ([]Configuration, error) {
+	var services kubeServiceList
+	if err := d.list(ctx, "/api/v1", "services", &services); err != nil {
+		return nil, err
+	}
+	var ingresses kubeIngressList
+	if err := d.list(ctx, "/apis/networking.k8s.io/v1", "ingresses", &ingresses); err != nil {
+		return nil, err
+	}
+
+	var endpoints []Configuration
+	for _, svc := range services.Items {
+		meta := svc.Metadata
+		if !d.selected(meta) || svc.Spec.Type == "ExternalName" || svc.Spec.ClusterIP == "None" {
+			continue
+		}
+		host := fmt.Sprintf("%s.%s.svc", meta.Name, meta.Namespace)
+		for _, port := range svc.Spec.Ports {
+			if port.Protocol != "" && port.Protocol != "TCP" {
+				continue
+			}
+			address := net.JoinHostPort(host, strconv.Itoa(port.Port))
+			req := Configuration{
+				Name: fmt.Sprintf("%s/%s:%d", meta.Namespace, meta.Name, port.Port),
+				Tags: []string{"kubernetes", "service", "namespace:" + meta.Namespace},
+			}
+			if scheme := httpScheme(port.Name, port.AppProtocol); scheme != "" {
+				req.Url = scheme + "://" + address + kubePath(meta, "/")
+			} else {
+				req.Type, req.Url = TypeTCP, "tcp://"+address
+			}
+			endpoints = append(endpoints, req)
+		}
+	}
+
+	for _, ing := range ingresses.Items {
+		meta := ing.Metadata
+		if !d.selected(meta) {
+			continue
+		}
+		secure := make(map[string]bool)
+		for _, t := range ing.Spec.TLS {
+			for _, host := range t.Hosts {
+				secure[host] = true
+			}
+		}
+		for _, rule := range ing.Spec.Rules {
+			if rule.Host == "" || strings.Contains(rule.Host, "*") {
+				continue
+			}
+			scheme := "http"
+			if secure[rule.Host] {
+				scheme = "https"
+			}
+
+			paths := []string{kubePath(meta, "/")}
+			if _, annotated := meta.Annotations[AnnotationPath]; !annotated && rule.HTTP != nil && len(rule.HTTP.Paths) > 0 {
+				paths = paths[:0]
+				for _, p := range rule.HTTP.Paths {
+					if p.Path == "" {
+						p.Path = "/"
+					}
+					paths = append(paths, p.Path)
+				}
+			}
+			for _, path := range paths {
+				endpoints = append(endpoints, Configuration{
+					Name: fmt.Sprintf("%s/%s %s%s", meta.Namespace, meta.Name, rule.Host, path),
+					Url:  scheme + "://" + rule.Host + path,
+					Tags: []string{"kubernetes", "ingress", "namespace:" + meta.Namespace},
+				})
+			}
+		}
+	}
+	return endpoints, nil
+}
+
+// list fetches a collection of objects, filtered by namespace and label selector
+func (d *KubernetesDiscoverer) list(ctx context.Context, group, resource string, into any) error {
+	path := group + "/" + resource
+	if d.Namespace != "" {
+		path = group + "/namespaces/" + url.PathEscape(d.Namespace) + "/" + resource
+	}
+	if d.LabelSelector != "" {
+		path += "?labelSelector=" + url.QueryEscape(d.LabelSelector)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.APIServer+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if d.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.Token)
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %v", resource, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to list %s: status %d", resource, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", resource, err)
+	}
+	return nil
+}
+
+// selected reports whether an object passes the annotation filter and hasn't opted out
+func (d *KubernetesDiscoverer) selected(meta kubeMetadata) bool {
+	if meta.Annotations[AnnotationEnabled] == "false" {
+		return false
+	}
+	if d.Annotation != "" {
+		value, exists := meta.Annotations[d.Annotation]
+		return exists && value != "false"
+	}
+	return true
+}
+
+// httpScheme returns the scheme of a Service port named or declared as HTTP(S)
+func httpScheme(name, appProtocol string) string {
+	for _, value := range []string{appProtocol, name} {
+		value = strings.ToLower(value)
+		switch {
+		case value == "https" || strings.HasPrefix(value, "https-"):
+			return "https"
+		case value == "http" || strings.HasPrefix(value, "http-") || value == "kubernetes.io/h2c":
+			return "http"
+		}
+	}
+	return ""
+}
+
+// kubePath returns the path annotation of an object, or def when unset
+func kubePath(meta kubeMetadata, def string) string {
+	if path := meta.Annotations[AnnotationPath]; path != "" {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		return path
+	}
+	return def
+}
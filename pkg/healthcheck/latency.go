@@ -0,0 +1,108 @@
+package healthcheck
+
+import (
+	"math"
+	"time"
+)
+
+// Histogram layout: log-scale buckets starting at 100µs, four per doubling,
+// covering latencies up to roughly 28 minutes with about 9% precision
+const (
+	histogramBuckets       = 96
+	histogramBase          = 100 * time.Microsecond
+	histogramStepsPerOctet = 4
+)
+
+// recentSamples is the number of latencies kept for sparklines
+const recentSamples = 30
+
+// LatencyHistogram is a fixed-size log-scale histogram of latencies used to
+// estimate percentiles. It is a plain value so it can be copied and persisted.
+type LatencyHistogram struct {
+	Counts [histogramBuckets]int64
+	Count  int64
+	// Max is the largest sample, which percentiles never exceed
+	Max time.Duration
+}
+
+// Add records a latency sample
+func (h *LatencyHistogram) Add(d time.Duration) {
+	h.Counts[histogramBucket(d)]++
+	h.Count++
+	h.Max = max(h.Max, d)
+}
+
+// Percentile returns the latency below which p percent of the samples fall,
+// rounded up to the bucket boundary but no higher than the largest sample.
+// It returns 0 when there are no samples.
+func (h LatencyHistogram) Percentile(p float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(p / 100 * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var cumulative int64
+	for i, count := range h.Counts {
+		cumulative += count
+		if cumulative >= rank {
+			return h.clamp(histogramBound(i))
+		}
+	}
+	return h.clamp(histogramBound(histogramBuckets - 1))
+}
+
+// clamp limits a bucket boundary to the largest sample, when known
+func (h LatencyHistogram) clamp(d time.Duration) time.Duration {
+	if h.Max > 0 && d > h.Max {
+		return h.Max
+	}
+	return d
+}
+
+// histogramBucket returns the index of the bucket holding d
+func histogramBucket(d time.Duration) int {
+	if d <= histogramBase {
+		return 0
+	}
+	i := int(math.Ceil(math.Log2(float64(d)/float64(histogramBase)) * histogramStepsPerOctet))
+	if i >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return i
+}
+
+// histogramBound returns the upper bound of bucket i
+func histogramBound(i int) time.Duration {
+	return time.Duration(float64(histogramBase) * math.Exp2(float64(i)/histogramStepsPerOctet))
+}
+
+// RecentLatencies is a fixed-size ring of the latest check latencies. Failed
+// checks are kept as zero so they show up as gaps.
+type RecentLatencies struct {
+	Samples [recentSamples]time.Duration
+	Next    int
+	Count   int
+}
+
+// Add records the latency of a check
+func (r *RecentLatencies) Add(d time.Duration) {
+	r.Samples[r.Next] = d
+	r.Next = (r.Next + 1) % recentSamples
+	if r.Count < recentSamples {
+		r.Count++
+	}
+}
+
+// Values returns the recorded latencies from oldest to newest
+func (r RecentLatencies) Values() []time.Duration {
+	values := make([]time.Duration, 0, r.Count)
+	start := (r.Next - r.Count + recentSamples) % recentSamples
+	for i := 0; i < r.Count; i++ {
+		values = append(values, r.Samples[(start+i)%recentSamples])
+	}
+	return values
+}
@@ -0,0 +1,106 @@
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// TypeLDAP is a directory server check
+const TypeLDAP = "ldap"
+
+// LDAPChecker checks that a directory server accepts a bind: a simple bind
+// with the basic auth of the endpoint, where the username is the bind DN, or
+// an anonymous bind without it. ldaps:// URLs use implicit TLS.
+type LDAPChecker struct {
+	// LatencyThreshold is the maximum latency until the bind succeeded for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the check unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeLDAP, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewLDAPChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewLDAPChecker returns an LDAPChecker using the given latency threshold and default timeout
+func NewLDAPChecker(latencyThreshold, timeout time.Duration) *LDAPChecker {
+	return &LDAPChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check connects, upgrades the connection with StartTLS when configured and
+// binds, measuring the latency of the whole exchange
+func (c *LDAPChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	bindDN, password, err := req.credentials()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	implicitTLS := strings.HasPrefix(req.Url, "ldaps://")
+	defaultPort := "389"
+	if implicitTLS {
+		defaultPort = "636"
+	}
+	address := serviceAddress(req.Url, defaultPort)
+
+	timeout := req.timeoutOr(c.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	conn, err := dialService(ctx, req, address, implicitTLS)
+	if err == nil {
+		result.RemoteAddr = conn.RemoteAddr().String()
+		err = ldapBind(conn, req, address, implicitTLS, timeout, bindDN, password)
+	}
+	result.Latency = time.Since(startTime)
+
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// ldapBind binds over an established connection and unbinds, closing the connection
+func ldapBind(netConn net.Conn, req Configuration, address string, isTLS bool, timeout time.Duration, bindDN, password string) error {
+	conn := ldap.NewConn(netConn, isTLS)
+	conn.Start()
+	conn.SetTimeout(timeout)
+	defer conn.Close()
+
+	if req.StartTLS && !isTLS {
+		host, _, _ := net.SplitHostPort(address)
+		tlsConfig, err := req.tlsConfig(host)
+		if err != nil {
+			return err
+		}
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if password == "" {
+		err = conn.UnauthenticatedBind(bindDN)
+	} else {
+		err = conn.Bind(bindDN, password)
+	}
+	if err != nil {
+		return err
+	}
+	return conn.Unbind()
+}
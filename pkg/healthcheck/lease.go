@@ -0,0 +1,181 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kubeMicroTime is the format of the times of a Lease
+const kubeMicroTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// KubernetesLease elects the instance holding a coordination.k8s.io Lease,
+// the mechanism used by Kubernetes controllers
+type KubernetesLease struct {
+	// APIServer is the base URL of the Kubernetes API
+	APIServer string
+	// Token is sent as a bearer token when set
+	Token  string
+	Client *http.Client
+
+	Namespace string
+	// Lease is the name of the Lease object
+	Lease    string
+	Identity string
+}
+
+// kubeLease is a coordination.k8s.io/v1 Lease
+type kubeLease struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		ResourceVersion string `json:"resourceVersion,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		HolderIdentity       string `json:"holderIdentity,omitempty"`
+		LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+		AcquireTime          string `json:"acquireTime,omitempty"`
+		RenewTime            string `json:"renewTime,omitempty"`
+		LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+	} `json:"spec"`
+}
+
+// NewKubernetesLease returns a KubernetesLease with the given name, using
+// the API server and credentials of a discoverer
+func NewKubernetesLease(api *KubernetesDiscoverer, namespace, name, identity string) *KubernetesLease {
+	return &KubernetesLease{
+		APIServer: api.APIServer,
+		Token:     api.Token,
+		Client:    api.Client,
+		Namespace: namespace,
+		Lease:     name,
+		Identity:  identity,
+	}
+}
+
+// Name identifies the elector in logs
+func (l *KubernetesLease) Name() string {
+	return "Kubernetes lease " + l.Namespace + "/" + l.Lease
+}
+
+// Campaign creates the lease, renews it when held by this instance, or
+// takes it over when its holder didn't renew it within its duration.
+// Conflicting updates by the other instance lose the campaign.
+func (l *KubernetesLease) Campaign(ctx context.Context, ttl time.Duration) (bool, error) {
+	var lease kubeLease
+	status, err := l.do(ctx, http.MethodGet, l.Lease, nil, &lease)
+	if status == http.StatusNotFound {
+		lease.APIVersion, lease.Kind = "coordination.k8s.io/v1", "Lease"
+		lease.Metadata.Name, lease.Metadata.Namespace = l.Lease, l.Namespace
+		l.hold(&lease, ttl)
+		status, err = l.do(ctx, http.MethodPost, "", lease, nil)
+		if status == http.StatusConflict {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	holder := lease.Spec.HolderIdentity
+	if holder != "" && holder != l.Identity {
+		renewed, err := time.Parse(time.RFC3339Nano, lease.Spec.RenewTime)
+		duration := time.Duration(lease.Spec.LeaseDurationSeconds) * time.Second
+		if err == nil && time.Since(renewed) < duration {
+			return false, nil
+		}
+	}
+	l.hold(&lease, ttl)
+	status, err = l.do(ctx, http.MethodPut, l.Lease, lease, nil)
+	if status == http.StatusConflict {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Resign clears the holder of the lease when held by this instance
+func (l *KubernetesLease) Resign(ctx context.Context) error {
+	var lease kubeLease
+	if _, err := l.do(ctx, http.MethodGet, l.Lease, nil, &lease); err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity != l.Identity {
+		return nil
+	}
+	lease.Spec.HolderIdentity, lease.Spec.RenewTime = "", ""
+	status, err := l.do(ctx, http.MethodPut, l.Lease, lease, nil)
+	if status == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+// hold makes this instance the holder of a lease for ttl from now
+func (l *KubernetesLease) hold(lease *kubeLease, ttl time.Duration) {
+	now := time.Now().UTC().Format(kubeMicroTime)
+	if lease.Spec.HolderIdentity != l.Identity {
+		if lease.Spec.HolderIdentity != "" {
+			lease.Spec.LeaseTransitions++
+		}
+		lease.Spec.HolderIdentity = l.Identity
+		lease.Spec.AcquireTime = now
+	}
+	lease.Spec.RenewTime = now
+	lease.Spec.LeaseDurationSeconds = max(int(ttl/time.Second), 1)
+}
+
+// do sends a request for the lease, or the collection of leases when name
+// is empty, and decodes the response into into when set. It returns the
+// response status.
+func (l *KubernetesLease) do(ctx context.Context, method, name string, body any, into any) (int, error) {
+	path := "/apis/coordination.k8s.io/v1/namespaces/" + url.PathEscape(l.Namespace) + "/leases"
+	if name != "" {
+		path += "/" + url.PathEscape(name)
+	}
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, l.APIServer+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if l.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+l.Token)
+	}
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("lease request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("lease request returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if into != nil {
+		if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode lease: %v", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,19 @@
+//go:build windows || plan9
+
+package healthcheck
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// lockFile is not supported on this platform
+func lockFile(file *os.File) (bool, error) {
+	return false, fmt.Errorf("file locks are not supported on %s", runtime.GOOS)
+}
+
+// unlockFile is not supported on this platform
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,180 @@
+package healthcheck
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// MaintenanceWindow is a period during which failures of the matching
+// endpoints are recorded separately and no alerts fire. A window is either a
+// fixed Start/End range or a recurring cron Schedule lasting Duration. It
+// applies to the endpoints named in Endpoints and those carrying any of Tags,
+// or to every endpoint when both are empty.
+type MaintenanceWindow struct {
+	ID        string        `yaml:"-" json:"id,omitempty"`
+	Name      string        `yaml:"name" json:"name"`
+	Endpoints []string      `yaml:"endpoints,omitempty" json:"endpoints,omitempty"`
+	Tags      []string      `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Start     time.Time     `yaml:"start,omitempty" json:"start,omitempty"`
+	End       time.Time     `yaml:"end,omitempty" json:"end,omitempty"`
+	Schedule  string        `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	Duration  time.Duration `yaml:"duration,omitempty" json:"duration,omitempty"`
+
+	schedule cron.Schedule
+}
+
+// compile validates the window and parses its cron schedule
+func (w *MaintenanceWindow) compile() error {
+	if w.Schedule != "" {
+		schedule, err := cron.ParseStandard(w.Schedule)
+		if err != nil {
+			return fmt.Errorf("maintenance window '%s': invalid schedule: %v", w.Name, err)
+		}
+		if w.Duration <= 0 {
+			return fmt.Errorf("maintenance window '%s': a schedule requires a positive duration", w.Name)
+		}
+		w.schedule = schedule
+		return nil
+	}
+
+	if w.End.IsZero() && !w.Start.IsZero() && w.Duration > 0 {
+		w.End = w.Start.Add(w.Duration)
+	}
+	if w.Start.IsZero() || w.End.IsZero() || !w.End.After(w.Start) {
+		return fmt.Errorf("maintenance window '%s': requires start and end, or a schedule and duration", w.Name)
+	}
+	return nil
+}
+
+// Active reports whether the window is in effect at t
+func (w MaintenanceWindow) Active(t time.Time) bool {
+	if w.schedule != nil {
+		// The latest activation at or before t started within the last Duration
+		return !w.schedule.Next(t.Add(-w.Duration)).After(t)
+	}
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Matches reports whether the window applies to the endpoint
+func (w MaintenanceWindow) Matches(req Configuration) bool {
+	if len(w.Endpoints) == 0 && len(w.Tags) == 0 {
+		return true
+	}
+	if slices.Contains(w.Endpoints, req.Name) {
+		return true
+	}
+	for _, tag := range w.Tags {
+		if slices.Contains(req.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Maintenance holds the configured maintenance windows and the silences
+// added at runtime. It is safe for concurrent use.
+type Maintenance struct {
+	mu       sync.RWMutex
+	windows  []MaintenanceWindow
+	silences map[string]MaintenanceWindow
+	nextID   int
+}
+
+// NewMaintenance returns a Maintenance with the given configured windows
+func NewMaintenance(windows []MaintenanceWindow) (*Maintenance, error) {
+	m := &Maintenance{silences: make(map[string]MaintenanceWindow)}
+	if err := m.SetWindows(windows); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetWindows replaces the configured windows, keeping runtime silences
+func (m *Maintenance) SetWindows(windows []MaintenanceWindow) error {
+	compiled := make([]MaintenanceWindow, len(windows))
+	for i, window := range windows {
+		if err := window.compile(); err != nil {
+			return err
+		}
+		compiled[i] = window
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows = compiled
+	return nil
+}
+
+// Active returns the window covering the endpoint at t, if any
+func (m *Maintenance) Active(req Configuration, t time.Time) (MaintenanceWindow, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, window := range m.windows {
+		if window.Matches(req) && window.Active(t) {
+			return window, true
+		}
+	}
+	for _, silence := range m.silences {
+		if silence.Matches(req) && silence.Active(t) {
+			return silence, true
+		}
+	}
+	return MaintenanceWindow{}, false
+}
+
+// AddSilence adds a runtime maintenance window and returns it with its ID
+func (m *Maintenance) AddSilence(silence MaintenanceWindow) (MaintenanceWindow, error) {
+	if err := silence.compile(); err != nil {
+		return MaintenanceWindow{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	silence.ID = strconv.Itoa(m.nextID)
+	m.silences[silence.ID] = silence
+	return silence, nil
+}
+
+// RemoveSilence deletes a runtime silence and reports whether it existed
+func (m *Maintenance) RemoveSilence(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, exists := m.silences[id]
+	delete(m.silences, id)
+	return exists
+}
+
+// Windows returns the configured windows followed by the runtime silences.
+// Expired fixed-range silences are dropped.
+func (m *Maintenance) Windows() []MaintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	windows := slices.Clone(m.windows)
+
+	silences := make([]MaintenanceWindow, 0, len(m.silences))
+	for id, silence := range m.silences {
+		if silence.schedule == nil && !now.Before(silence.End) {
+			delete(m.silences, id)
+			continue
+		}
+		silences = append(silences, silence)
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		a, _ := strconv.Atoi(silences[i].ID)
+		b, _ := strconv.Atoi(silences[j].ID)
+		return a < b
+	})
+	return append(windows, silences...)
+}
@@ -0,0 +1,78 @@
+package healthcheck
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// TypeMemcached is a memcached server check
+const TypeMemcached = "memcached"
+
+// MemcachedChecker checks that a memcached server answers the version command
+type MemcachedChecker struct {
+	// LatencyThreshold is the maximum latency for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the check unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeMemcached, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewMemcachedChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewMemcachedChecker returns a MemcachedChecker using the given latency threshold and default timeout
+func NewMemcachedChecker(latencyThreshold, timeout time.Duration) *MemcachedChecker {
+	return &MemcachedChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check connects and sends the version command, measuring the latency of the
+// whole exchange
+func (c *MemcachedChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+
+	startTime := time.Now()
+	conn, err := dialService(ctx, req, serviceAddress(req.Url, "11211"), req.TLS != nil)
+	if err == nil {
+		defer conn.Close()
+		result.RemoteAddr = conn.RemoteAddr().String()
+		err = memcachedVersion(conn)
+	}
+	result.Latency = time.Since(startTime)
+
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// memcachedVersion expects a VERSION reply to the version command
+func memcachedVersion(conn net.Conn) error {
+	if _, err := conn.Write([]byte("version\r\n")); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "VERSION ") {
+		return fmt.Errorf("unexpected reply to version: %q", line)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package healthcheck
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics exposes check results as Prometheus metrics labeled by endpoint
+// name and domain
+type Metrics struct {
+	registry *prometheus.Registry
+	up       *prometheus.GaugeVec
+	latency  *prometheus.GaugeVec
+	checks   *prometheus.CounterVec
+	failures *prometheus.CounterVec
+	quantile *prometheus.GaugeVec
+	offset   *prometheus.GaugeVec
+	size     *prometheus.GaugeVec
+
+	// series are the endpoints with metrics, whose series are deleted once
+	// they are no longer configured
+	mu     sync.Mutex
+	series map[metricEndpoint]bool
+}
+
+// metricEndpoint are the labels identifying the series of an endpoint
+type metricEndpoint struct {
+	name, domain string
+}
+
+// NewMetrics creates the healthcheck metrics on a dedicated registry
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		series:   make(map[metricEndpoint]bool),
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "healthcheck_up",
+			Help: "Whether the last check of the endpoint succeeded (1) or failed (0).",
+		}, []string{"name", "domain"}),
+		latency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "healthcheck_latency_seconds",
+			Help: "Latency of the last check of the endpoint in seconds.",
+		}, []string{"name", "domain"}),
+		checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "healthcheck_checks_total",
+			Help: "Total number of checks performed, partitioned by result.",
+		}, []string{"name", "domain", "result"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "healthcheck_failures_total",
+			Help: "Total number of failed checks, partitioned by cause.",
+		}, []string{"name", "domain", "cause"}),
+		quantile: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "healthcheck_latency_quantile_seconds",
+			Help: "Estimated latency percentiles of the endpoint in seconds.",
+		}, []string{"name", "domain", "quantile"}),
+		offset: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "healthcheck_clock_offset_seconds",
+			Help: "Clock offset of the NTP server from the local clock in seconds.",
+		}, []string{"name", "domain"}),
+		size: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "healthcheck_response_size_bytes",
+			Help: "Response body size of the last HTTP check of the endpoint in bytes.",
+		}, []string{"name", "domain"}),
+	}
+	m.registry.MustRegister(m.up, m.latency, m.checks, m.failures, m.quantile, m.offset, m.size)
+	return m
+}
+
+// Observe updates the metrics with a check result and the resulting
+// availability of its endpoint
+func (m *Metrics) Observe(r Result, stats Availability) {
+	name, domain := r.Endpoint.Name, r.Endpoint.Domain()
+	m.mu.Lock()
+	m.series[metricEndpoint{name, domain}] = true
+	m.mu.Unlock()
+
+	up, outcome := 0.0, r.Outcome()
+	if r.Up {
+		up = 1.0
+	}
+
+	m.up.WithLabelValues(name, domain).Set(up)
+	m.latency.WithLabelValues(name, domain).Set(r.Latency.Seconds())
+	m.checks.WithLabelValues(name, domain, outcome).Inc()
+	if cause := r.Cause(); cause != "" {
+		m.failures.WithLabelValues(name, domain, cause).Inc()
+	}
+	if r.Offset != 0 {
+		m.offset.WithLabelValues(name, domain).Set(r.Offset.Seconds())
+	}
+	if r.Phases.TTFB > 0 {
+		m.size.WithLabelValues(name, domain).Set(float64(r.Size))
+	}
+
+	if stats.Latencies.Count > 0 {
+		m.quantile.WithLabelValues(name, domain, "0.5").Set(stats.Latencies.Percentile(50).Seconds())
+		m.quantile.WithLabelValues(name, domain, "0.95").Set(stats.Latencies.Percentile(95).Seconds())
+		m.quantile.WithLabelValues(name, domain, "0.99").Set(stats.Latencies.Percentile(99).Seconds())
+	}
+}
+
+// Handler returns an http.Handler serving the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Flush deletes the series of the endpoints that are no longer configured,
+// e.g. removed on reload, so they don't keep reporting their last state.
+// Metrics are otherwise updated by Observe and scraped on demand.
+func (m *Metrics) Flush(c Cycle) error {
+	configured := make(map[metricEndpoint]bool, len(c.Endpoints))
+	for _, req := range c.Endpoints {
+		configured[metricEndpoint{req.Name, req.Domain()}] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for endpoint := range m.series {
+		if configured[endpoint] {
+			continue
+		}
+		labels := prometheus.Labels{"name": endpoint.name, "domain": endpoint.domain}
+		for _, vec := range []*prometheus.MetricVec{m.up.MetricVec, m.latency.MetricVec, m.checks.MetricVec, m.failures.MetricVec, m.quantile.MetricVec, m.offset.MetricVec, m.size.MetricVec} {
+			vec.DeletePartialMatch(labels)
+		}
+		delete(m.series, endpoint)
+	}
+	return nil
+}
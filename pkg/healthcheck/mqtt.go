@@ -0,0 +1,153 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+)
+
+// TypeMQTT is an MQTT broker check
+const TypeMQTT = "mqtt"
+
+// mqttConnackErrors describes the CONNACK return codes refusing a connection
+var mqttConnackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// MQTTChecker checks that an MQTT broker accepts an MQTT 3.1.1 connection.
+// mqtts:// URLs and endpoints with a tls section connect over TLS.
+type MQTTChecker struct {
+	// LatencyThreshold is the maximum latency until the CONNACK for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the check unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeMQTT, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewMQTTChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewMQTTChecker returns an MQTTChecker using the given latency threshold and default timeout
+func NewMQTTChecker(latencyThreshold, timeout time.Duration) *MQTTChecker {
+	return &MQTTChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check sends CONNECT, waits for the CONNACK and disconnects, measuring the
+// latency until the connection was accepted
+func (c *MQTTChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	username, password, err := req.credentials()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	useTLS := strings.HasPrefix(req.Url, "mqtts://") || req.TLS != nil
+	defaultPort := "1883"
+	if useTLS {
+		defaultPort = "8883"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+
+	startTime := time.Now()
+	conn, err := dialService(ctx, req, serviceAddress(req.Url, defaultPort), useTLS)
+	if err == nil {
+		defer conn.Close()
+		result.RemoteAddr = conn.RemoteAddr().String()
+		err = mqttConnect(conn, username, password)
+	}
+	result.Latency = time.Since(startTime)
+
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// Disconnect cleanly so the broker doesn't publish a will or log an error
+	conn.Write([]byte{0xe0, 0x00})
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// mqttConnect sends a CONNECT packet with a clean session and expects an
+// accepting CONNACK
+func mqttConnect(conn net.Conn, username, password string) error {
+	// Variable header: protocol name, level 4 (3.1.1), flags and keep alive
+	flags := byte(0x02)
+	if username != "" {
+		flags |= 0x80
+	}
+	if password != "" {
+		flags |= 0x40
+	}
+	packet := mqttString("MQTT")
+	packet = append(packet, 0x04, flags, 0x00, 0x3c)
+
+	// Payload: client identifier, then the credentials
+	packet = append(packet, mqttString(fmt.Sprintf("healthcheck-%d", rand.Uint32()))...)
+	if username != "" {
+		packet = append(packet, mqttString(username)...)
+	}
+	if password != "" {
+		packet = append(packet, mqttString(password)...)
+	}
+
+	header := append([]byte{0x10}, mqttRemainingLength(len(packet))...)
+	if _, err := conn.Write(append(header, packet...)); err != nil {
+		return err
+	}
+
+	connack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, connack); err != nil {
+		return fmt.Errorf("no CONNACK received: %v", err)
+	}
+	if connack[0] != 0x20 || connack[1] != 0x02 {
+		return fmt.Errorf("unexpected reply to CONNECT: % x", connack)
+	}
+	if code := connack[3]; code != 0 {
+		if reason, ok := mqttConnackErrors[code]; ok {
+			return fmt.Errorf("connection refused: %s", reason)
+		}
+		return fmt.Errorf("connection refused with return code %d", code)
+	}
+	return nil
+}
+
+// mqttString encodes a length-prefixed UTF-8 string
+func mqttString(s string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(s))), s...)
+}
+
+// mqttRemainingLength encodes the length of a packet as a variable byte integer
+func mqttRemainingLength(length int) []byte {
+	var encoded []byte
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		encoded = append(encoded, digit)
+		if length == 0 {
+			return encoded
+		}
+	}
+}
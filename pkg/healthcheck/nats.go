@@ -0,0 +1,145 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultNATSSubject is the subject prefix results are published under
+const DefaultNATSSubject = "healthcheck.results"
+
+// NATSOutputConfig publishes every check result to a NATS server
+type NATSOutputConfig struct {
+	// URL is the server as nats://[user:password@]host[:port], or tls:// for TLS
+	URL string `yaml:"url"`
+	// Subject is the prefix of the subjects: the results of an endpoint are
+	// published on <subject>.<name> (default: DefaultNATSSubject)
+	Subject string `yaml:"subject,omitempty"`
+	// Auth authenticates with a username and password (basic) or a token (bearer)
+	Auth *Auth      `yaml:"auth,omitempty"`
+	TLS  *TLSConfig `yaml:"tls,omitempty"`
+}
+
+// natsPublisher publishes messages with the NATS client, connecting on the
+// first publish and again once the client gives up reconnecting
+type natsPublisher struct {
+	config  NATSOutputConfig
+	options []nats.Option
+	conn    *nats.Conn
+
+	// serverErr is the last error the server reported asynchronously, e.g. a
+	// permissions violation
+	mu        sync.Mutex
+	serverErr error
+}
+
+// NewNATSStream returns a Stream publishing results to a NATS server
+func NewNATSStream(config NATSOutputConfig) (*Stream, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("nats: url is required")
+	}
+	if config.Subject == "" {
+		config.Subject = DefaultNATSSubject
+	}
+
+	publisher := &natsPublisher{config: config}
+	publisher.options = []nats.Option{
+		nats.Name("healthcheck"),
+		nats.MaxReconnects(-1),
+		// Fail publishes while reconnecting rather than buffering them, so
+		// they are reported at the next summary
+		nats.ReconnectBufSize(-1),
+		nats.ErrorHandler(publisher.asyncError),
+	}
+	if config.Auth != nil {
+		switch config.Auth.Type {
+		case AuthBasic:
+			username, password, err := config.Auth.basicCredentials()
+			if err != nil {
+				return nil, fmt.Errorf("nats: %v", err)
+			}
+			publisher.options = append(publisher.options, nats.UserInfo(username, password))
+		case AuthBearer:
+			token, err := credential(config.Auth.Token, config.Auth.TokenEnv)
+			if err != nil {
+				return nil, fmt.Errorf("nats: %v", err)
+			}
+			publisher.options = append(publisher.options, nats.Token(token))
+		default:
+			return nil, fmt.Errorf("nats: unsupported auth type %q, expected basic or bearer", config.Auth.Type)
+		}
+	}
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.load()
+		if err != nil {
+			return nil, fmt.Errorf("nats: %v", err)
+		}
+		publisher.options = append(publisher.options, nats.Secure(tlsConfig))
+	}
+	return newStream(publisher), nil
+}
+
+// name identifies the server in errors
+func (p *natsPublisher) name() string {
+	return "NATS " + tcpAddress(p.config.URL)
+}
+
+// publish sends a message for every result, connecting first if needed, and
+// waits for the server to receive them. Errors reported by the server since
+// the previous publish are returned.
+func (p *natsPublisher) publish(ctx context.Context, messages []streamMessage) error {
+	if p.conn == nil || p.conn.IsClosed() {
+		conn, err := nats.Connect(p.config.URL, p.options...)
+		if err != nil {
+			return err
+		}
+		p.conn = conn
+	}
+
+	for _, message := range messages {
+		if err := p.conn.Publish(p.config.Subject+"."+natsToken(message.Endpoint), message.Payload); err != nil {
+			return err
+		}
+	}
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.serverErr
+	p.serverErr = nil
+	return err
+}
+
+// asyncError records an error the server reported outside of a publish
+func (p *natsPublisher) asyncError(conn *nats.Conn, sub *nats.Subscription, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.serverErr = fmt.Errorf("server error: %v", err)
+}
+
+// close disconnects from the server
+func (p *natsPublisher) close() error {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+	return nil
+}
+
+// natsToken turns an endpoint name into a single subject token, replacing
+// the separators and wildcards NATS reserves
+func natsToken(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,138 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// TypeNTP is a time server check
+const TypeNTP = "ntp"
+
+// DefaultMaxOffset is the clock offset above which an NTP check is DOWN when
+// the endpoint doesn't set max_offset
+const DefaultMaxOffset = 100 * time.Millisecond
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and the Unix epoch
+const ntpEpochOffset = 2208988800
+
+// NTPChecker queries an NTP server with SNTP and checks that it is
+// synchronized and that its clock is within max_offset of the local clock
+type NTPChecker struct {
+	// LatencyThreshold is the maximum round-trip delay for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the query unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeNTP, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewNTPChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewNTPChecker returns an NTPChecker using the given latency threshold and default timeout
+func NewNTPChecker(latencyThreshold, timeout time.Duration) *NTPChecker {
+	return &NTPChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check sends a client request and measures the round-trip delay and the
+// clock offset of the server
+func (c *NTPChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, req.network("udp"), serviceAddress(req.Url, "123"))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	result.RemoteAddr = conn.RemoteAddr().String()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	delay, offset, err := ntpQuery(conn)
+	result.Latency = delay
+	result.Offset = offset
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	maxOffset := req.MaxOffset
+	if maxOffset <= 0 {
+		maxOffset = DefaultMaxOffset
+	}
+	if offset.Abs() > maxOffset {
+		result.Err = fmt.Errorf("clock offset %v exceeds %v", offset, maxOffset)
+		return result
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// ntpQuery sends a version 4 client request and returns the round-trip delay
+// and the offset of the server clock
+func ntpQuery(conn net.Conn) (time.Duration, time.Duration, error) {
+	// LI 0, version 4, mode 3 (client). The transmit timestamp is random, the
+	// server echoes it as the origin timestamp of its reply.
+	request := make([]byte, 48)
+	request[0] = 0x23
+	if _, err := rand.Read(request[40:]); err != nil {
+		return 0, 0, err
+	}
+
+	sent := time.Now()
+	if _, err := conn.Write(request); err != nil {
+		return 0, 0, err
+	}
+	reply := make([]byte, 48)
+	for {
+		n, err := conn.Read(reply)
+		if err != nil {
+			return time.Since(sent), 0, fmt.Errorf("no reply received: %v", err)
+		}
+		// Ignore stray datagrams that don't answer this request
+		if n >= 48 && string(reply[24:32]) == string(request[40:48]) {
+			break
+		}
+	}
+	received := time.Now()
+
+	leap, mode, stratum := reply[0]>>6, reply[0]&0x07, reply[1]
+	switch {
+	case mode != 4:
+		return time.Since(sent), 0, fmt.Errorf("unexpected NTP mode %d", mode)
+	case stratum == 0:
+		return time.Since(sent), 0, fmt.Errorf("kiss-o'-death %q", strings.TrimRight(string(reply[12:16]), "\x00"))
+	case leap == 3 || stratum > 15:
+		return time.Since(sent), 0, fmt.Errorf("server is not synchronized")
+	}
+
+	// Offset and delay from the four timestamps of the exchange, RFC 5905
+	serverReceived, serverSent := ntpTime(reply[32:40]), ntpTime(reply[40:48])
+	offset := (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2
+	delay := received.Sub(sent) - serverSent.Sub(serverReceived)
+	return delay, offset, nil
+}
+
+// ntpTime decodes a 64-bit NTP timestamp
+func ntpTime(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b[:4])) - ntpEpochOffset
+	fraction := int64(binary.BigEndian.Uint32(b[4:])) * int64(time.Second) >> 32
+	return time.Unix(seconds, fraction)
+}
@@ -0,0 +1,81 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// tokenKey identifies the OAuth2 client an access token is fetched for
+type tokenKey struct {
+	tokenURL, clientID, clientSecret, scopes string
+}
+
+// authorize sets the Authorization header of the request from the endpoint
+// credentials, fetching an OAuth2 access token when needed
+func (c *HTTPChecker) authorize(httpReq *http.Request, req Configuration, transport http.RoundTripper) error {
+	if req.Auth.Type != AuthOAuth2 {
+		return req.Auth.apply(httpReq)
+	}
+	source, err := c.tokenSource(req, transport)
+	if err != nil {
+		return err
+	}
+	token, err := source.Token()
+	if err != nil {
+		return fmt.Errorf("failed to fetch OAuth2 token: %v", err)
+	}
+	token.SetAuthHeader(httpReq)
+	return nil
+}
+
+// tokenSource returns the cached token source of the endpoint's OAuth2
+// client, creating it on first use. Tokens are reused until they expire and
+// shared by the endpoints using the same client. Token requests go through
+// the endpoint's transport, so its TLS and proxy settings apply.
+func (c *HTTPChecker) tokenSource(req Configuration, transport http.RoundTripper) (oauth2.TokenSource, error) {
+	a := req.Auth
+	secret, err := credential(a.ClientSecret, a.ClientSecretEnv)
+	if err != nil {
+		return nil, err
+	}
+	key := tokenKey{
+		tokenURL:     a.TokenURL,
+		clientID:     a.ClientID,
+		clientSecret: secret,
+		scopes:       strings.Join(a.Scopes, " "),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if source, exists := c.tokens[key]; exists {
+		return source, nil
+	}
+
+	config := clientcredentials.Config{
+		ClientID:     a.ClientID,
+		ClientSecret: secret,
+		TokenURL:     a.TokenURL,
+		Scopes:       a.Scopes,
+	}
+	client := &http.Client{Transport: transport, Timeout: req.timeoutOr(c.Timeout)}
+	source := config.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, client))
+	c.tokens[key] = source
+	return source, nil
+}
+
+// validateOAuth2 checks that the client credentials settings are complete
+func (a *Auth) validateOAuth2() error {
+	if a.TokenURL == "" || a.ClientID == "" {
+		return fmt.Errorf("oauth2 auth requires token_url and client_id")
+	}
+	if a.ClientSecret == "" && a.ClientSecretEnv == "" {
+		return fmt.Errorf("oauth2 auth requires client_secret or client_secret_env")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultOpsgenieURL is the Opsgenie API of the US region
+const DefaultOpsgenieURL = "https://api.opsgenie.com"
+
+// OpsgenieConfig configures alerts created with the Opsgenie Alert API
+type OpsgenieConfig struct {
+	APIKey string `yaml:"api_key"`
+	// URL is the API base URL, e.g. https://api.eu.opsgenie.com for the EU
+	// region (default: DefaultOpsgenieURL)
+	URL string `yaml:"url,omitempty"`
+	// Priority of the created alerts, P1 to P5 (default: from the endpoint
+	// severity, P1 for critical, P3 for warning, P5 for info and P3 when unset)
+	Priority string   `yaml:"priority,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+}
+
+// OpsgenieNotifier creates an Opsgenie alert when an endpoint goes DOWN and
+// closes it when the endpoint recovers. Alerts are keyed by the endpoint URL
+// so repeated DOWN alerts are deduplicated.
+type OpsgenieNotifier struct {
+	Config OpsgenieConfig
+	Client *http.Client
+}
+
+// NewOpsgenieNotifier returns an OpsgenieNotifier for the given configuration
+func NewOpsgenieNotifier(config OpsgenieConfig) (*OpsgenieNotifier, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("opsgenie alerting requires an api_key")
+	}
+	if config.URL == "" {
+		config.URL = DefaultOpsgenieURL
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+	switch config.Priority {
+	case "", "P1", "P2", "P3", "P4", "P5":
+	default:
+		return nil, fmt.Errorf("invalid opsgenie priority %q, expected P1 to P5", config.Priority)
+	}
+	return &OpsgenieNotifier{
+		Config: config,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// Notify creates or closes the alert of the endpoint. Other alerts, such as
+// an exhausted error budget while UP, are not sent.
+func (n *OpsgenieNotifier) Notify(a Alert) error {
+	headers := map[string]string{"Authorization": "GenieKey " + n.Config.APIKey}
+	alias := opsgenieAlias(a.Endpoint)
+
+	switch {
+	case a.Opens():
+		return postJSON(n.Client, n.Config.URL+"/v2/alerts", headers, map[string]any{
+			"message":     truncate(fmt.Sprintf("%s is DOWN", a.Endpoint.Name), 130),
+			"alias":       alias,
+			"description": alertMessage(a),
+			"priority":    n.priority(a.Endpoint),
+			"tags":        append(append([]string(nil), n.Config.Tags...), a.Endpoint.Tags...),
+			"entity":      a.Endpoint.Url,
+			"source":      "healthcheck",
+			"details":     alertDetails(a),
+		})
+	case a.Resolves():
+		closeURL := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", n.Config.URL, url.PathEscape(alias))
+		return postJSON(n.Client, closeURL, headers, map[string]any{
+			"source": "healthcheck",
+			"note":   alertMessage(a),
+		})
+	}
+	return nil
+}
+
+// priority returns the configured priority of alerts or the one matching the
+// severity of the endpoint
+func (n *OpsgenieNotifier) priority(req Configuration) string {
+	if n.Config.Priority != "" {
+		return n.Config.Priority
+	}
+	switch req.severity() {
+	case SeverityCritical:
+		return "P1"
+	case SeverityInfo:
+		return "P5"
+	default:
+		return "P3"
+	}
+}
+
+// opsgenieAlias identifies the alert of an endpoint. Opsgenie limits aliases to 512 characters.
+func opsgenieAlias(req Configuration) string {
+	return truncate("healthcheck:"+req.Url, 512)
+}
+
+// alertDetails are the key-value details attached to incidents
+func alertDetails(a Alert) map[string]string {
+	details := map[string]string{
+		"endpoint":             a.Endpoint.Name,
+		"url":                  a.Endpoint.Url,
+		"status":               string(a.Current),
+		"consecutive_failures": fmt.Sprint(a.ConsecutiveFailures),
+	}
+	if a.Endpoint.Severity != "" {
+		details["severity"] = a.Endpoint.Severity
+	}
+	if a.Result.Err != nil {
+		details["error"] = a.Result.Err.Error()
+	}
+	return details
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
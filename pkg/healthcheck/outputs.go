@@ -0,0 +1,91 @@
+package healthcheck
+
+import "context"
+
+// OutputsConfig is the outputs section of the configuration file, selecting
+// the cloud monitoring services results are published to. Changes take
+// effect on restart.
+type OutputsConfig struct {
+	GCP   *GCPMonitoringConfig `yaml:"gcp,omitempty"`
+	Azure *AzureMonitorConfig  `yaml:"azure,omitempty"`
+	// Datadog submits metrics and service checks. Events on state
+	// transitions are sent by a datadog notifier of the alerting section.
+	Datadog *DatadogConfig `yaml:"datadog,omitempty"`
+	// Kafka and NATS stream every check result as a JSON message
+	Kafka *KafkaOutputConfig `yaml:"kafka,omitempty"`
+	NATS  *NATSOutputConfig  `yaml:"nats,omitempty"`
+}
+
+// expandEnv interpolates environment variables into the output URLs and secrets
+func (c *OutputsConfig) expandEnv(env envLookup) error {
+	var fields []*string
+	if c.Datadog != nil {
+		fields = append(fields, &c.Datadog.APIKey)
+	}
+	if c.Kafka != nil {
+		fields = append(fields, &c.Kafka.URL)
+		fields = append(fields, authFields(c.Kafka.Auth)...)
+	}
+	if c.NATS != nil {
+		fields = append(fields, &c.NATS.URL)
+		fields = append(fields, authFields(c.NATS.Auth)...)
+	}
+	for _, field := range fields {
+		expanded, err := expandEnv(*field, env)
+		if err != nil {
+			return err
+		}
+		*field = expanded
+	}
+	return nil
+}
+
+// authFields returns the credentials of an output that may reference
+// environment variables
+func authFields(auth *Auth) []*string {
+	if auth == nil {
+		return nil
+	}
+	return []*string{&auth.Username, &auth.Password, &auth.Token}
+}
+
+// Sinks returns a ResultSink for every configured output
+func (c OutputsConfig) Sinks(ctx context.Context) ([]ResultSink, error) {
+	var sinks []ResultSink
+	if c.GCP != nil {
+		gcp, err := NewGCPMonitoring(ctx, *c.GCP)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, gcp)
+	}
+	if c.Azure != nil {
+		azure, err := NewAzureMonitor(*c.Azure)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, azure)
+	}
+	if c.Datadog != nil {
+		datadog, err := NewDatadog(*c.Datadog)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, datadog)
+	}
+	if c.Kafka != nil {
+		kafka, err := NewKafkaStream(*c.Kafka)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, kafka)
+	}
+	if c.NATS != nil {
+		nats, err := NewNATSStream(*c.NATS)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, nats)
+	}
+	return sinks, nil
+}
@@ -0,0 +1,69 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// persistedState is the on-disk format of the availability counters
+type persistedState struct {
+	SavedAt      time.Time               `json:"saved_at"`
+	Availability map[string]Availability `json:"availability"`
+}
+
+// SaveFile writes the availability of every URL to a JSON file. The file is
+// replaced atomically so a crash never leaves a truncated state file.
+func (s *ResultStore) SaveFile(path string) error {
+	data, err := json.Marshal(persistedState{
+		SavedAt:      time.Now(),
+		Availability: s.Snapshot(),
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file '%s': %v", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write state file '%s': %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write state file '%s': %v", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFile restores availability from a file written by SaveFile. Only URLs
+// the store already tracks are restored. A missing file is not an error.
+func (s *ResultStore) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file '%s': %v", path, err)
+	}
+
+	var state persistedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse state file '%s': %v", path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for url, stats := range state.Availability {
+		if _, exists := s.availability[url]; exists {
+			restored := stats
+			s.availability[url] = &restored
+		}
+	}
+	return nil
+}
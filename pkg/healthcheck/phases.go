@@ -0,0 +1,150 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases is the latency breakdown of an HTTP check. DNS, Connect and TLS are
+// zero when a pooled connection is reused. TTFB is the time from the request
+// being written to the first response byte, i.e. server processing, and
+// Transfer the time spent reading the response body. Redirect hops add up.
+type Phases struct {
+	DNS      time.Duration `json:"dns"`
+	Connect  time.Duration `json:"connect"`
+	TLS      time.Duration `json:"tls"`
+	TTFB     time.Duration `json:"ttfb"`
+	Transfer time.Duration `json:"transfer"`
+}
+
+// add sums the phases of two requests
+func (p Phases) add(o Phases) Phases {
+	return Phases{
+		DNS:      p.DNS + o.DNS,
+		Connect:  p.Connect + o.Connect,
+		TLS:      p.TLS + o.TLS,
+		TTFB:     p.TTFB + o.TTFB,
+		Transfer: p.Transfer + o.Transfer,
+	}
+}
+
+// String formats the phases for logs and summaries
+func (p Phases) String() string {
+	return fmt.Sprintf("DNS %v, Connect %v, TLS %v, TTFB %v, Transfer %v", p.DNS, p.Connect, p.TLS, p.TTFB, p.Transfer)
+}
+
+// PhaseStats accumulates the latency breakdown of an endpoint
+type PhaseStats struct {
+	Total Phases
+	Count int
+}
+
+// Add records the phases of a check
+func (s *PhaseStats) Add(p Phases) {
+	s.Total = s.Total.add(p)
+	s.Count++
+}
+
+// Average returns the mean time spent in every phase. Phases skipped on
+// reused connections count as zero, so the averages add up to the average
+// latency.
+func (s PhaseStats) Average() Phases {
+	if s.Count == 0 {
+		return Phases{}
+	}
+	n := time.Duration(s.Count)
+	return Phases{
+		DNS:      s.Total.DNS / n,
+		Connect:  s.Total.Connect / n,
+		TLS:      s.Total.TLS / n,
+		TTFB:     s.Total.TTFB / n,
+		Transfer: s.Total.Transfer / n,
+	}
+}
+
+// PhaseSummary is the average latency breakdown in milliseconds, for reports
+type PhaseSummary struct {
+	DNSMs      float64 `json:"dns_ms"`
+	ConnectMs  float64 `json:"connect_ms"`
+	TLSMs      float64 `json:"tls_ms"`
+	TTFBMs     float64 `json:"ttfb_ms"`
+	TransferMs float64 `json:"transfer_ms"`
+}
+
+// summary converts phases to milliseconds
+func (p Phases) summary() PhaseSummary {
+	return PhaseSummary{
+		DNSMs:      milliseconds(p.DNS),
+		ConnectMs:  milliseconds(p.Connect),
+		TLSMs:      milliseconds(p.TLS),
+		TTFBMs:     milliseconds(p.TTFB),
+		TransferMs: milliseconds(p.Transfer),
+	}
+}
+
+// phaseTrace measures the phases of a request with httptrace. Hooks may be
+// called from several goroutines, e.g. when dialing IPv4 and IPv6 in parallel.
+type phaseTrace struct {
+	mu                                             sync.Mutex
+	phases                                         Phases
+	dnsStart, connectStart, tlsStart, wroteRequest time.Time
+	remoteAddr                                     string
+}
+
+// context returns ctx with the trace hooks attached
+func (t *phaseTrace) context(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.start(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.done(&t.dnsStart, &t.phases.DNS) },
+		ConnectStart: func(string, string) {
+			t.start(&t.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.done(&t.connectStart, &t.phases.Connect)
+		},
+		TLSHandshakeStart: func() { t.start(&t.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.done(&t.tlsStart, &t.phases.TLS)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.remoteAddr = info.Conn.RemoteAddr().String()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) { t.start(&t.wroteRequest) },
+		GotFirstResponseByte: func() {
+			t.done(&t.wroteRequest, &t.phases.TTFB)
+		},
+	})
+}
+
+// start marks the beginning of a phase. Only the first start counts while
+// the phase is in progress.
+func (t *phaseTrace) start(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if at.IsZero() {
+		*at = time.Now()
+	}
+}
+
+// done adds the time since the phase started to its duration
+func (t *phaseTrace) done(at *time.Time, phase *time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !at.IsZero() {
+		*phase += time.Since(*at)
+		*at = time.Time{}
+	}
+}
+
+// result returns the measured phases and the address of the last connection
+func (t *phaseTrace) result() (Phases, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.phases, t.remoteAddr
+}
@@ -0,0 +1,182 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PluginPrefix is the file name prefix of checker plugins found by
+// LoadPlugins. healthcheck-foo provides the check type foo.
+const PluginPrefix = "healthcheck-"
+
+// PluginProtocolVersion is the version of the plugin request and response
+const PluginProtocolVersion = 1
+
+// PluginRequest is written as JSON to the standard input of a plugin for
+// every check
+type PluginRequest struct {
+	Version int `json:"version"`
+	// Endpoint is the endpoint configuration with the same keys as the
+	// configuration file, including its plugin-specific options
+	Endpoint map[string]any `json:"endpoint"`
+	// TimeoutMs is the time the check may take before the plugin is killed
+	TimeoutMs int64 `json:"timeout_ms"`
+}
+
+// PluginResponse is the JSON a plugin writes to its standard output
+type PluginResponse struct {
+	Up    bool   `json:"up"`
+	Error string `json:"error,omitempty"`
+	// LatencyMs is the latency measured by the plugin. The run time of the
+	// plugin is used when it's not set.
+	LatencyMs  *float64 `json:"latency_ms,omitempty"`
+	RemoteAddr string   `json:"remote_addr,omitempty"`
+}
+
+// PluginChecker runs an external checker binary for every check. The binary
+// reads a PluginRequest from its standard input and writes a PluginResponse
+// to its standard output.
+type PluginChecker struct {
+	Path string
+	// LatencyThreshold is the maximum latency for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the plugin run unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+// RegisterPlugin makes the checker binary at path available to endpoints
+// configured with `type: <checkType>`
+func RegisterPlugin(checkType, path string) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[checkType]; exists {
+		return fmt.Errorf("plugin '%s': check type %q is already registered", path, checkType)
+	}
+	registry[checkType] = func(latencyThreshold, timeout time.Duration) Checker {
+		return &PluginChecker{Path: path, LatencyThreshold: latencyThreshold, Timeout: timeout}
+	}
+	return nil
+}
+
+// LoadPlugins registers every executable file of dir named with PluginPrefix
+// and returns the check types they provide
+func LoadPlugins(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory '%s': %v", dir, err)
+	}
+
+	var types []string
+	for _, entry := range entries {
+		checkType, ok := strings.CutPrefix(entry.Name(), PluginPrefix)
+		if !ok || checkType == "" || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode()&0o111 == 0 {
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return types, err
+		}
+		if err := RegisterPlugin(checkType, path); err != nil {
+			return types, err
+		}
+		types = append(types, checkType)
+	}
+	return types, nil
+}
+
+// Check runs the plugin with the endpoint on its standard input and decodes
+// its response
+func (c *PluginChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	timeout := req.timeoutOr(c.Timeout)
+	input, err := pluginRequest(req, timeout)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.Path)
+	cmd.WaitDelay = execWaitDelay
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := &cappedBuffer{limit: maxBodySize}
+	stderr := &cappedBuffer{limit: maxBodySize}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	startTime := time.Now()
+	err = cmd.Run()
+	result.Latency = time.Since(startTime)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Err = fmt.Errorf("plugin timed out after %v", timeout)
+		return result
+	}
+	if err != nil {
+		if message := lastLine(stderr.Bytes()); message != "" {
+			err = fmt.Errorf("plugin failed: %v: %s", err, message)
+		} else {
+			err = fmt.Errorf("plugin failed: %v", err)
+		}
+		result.Err = err
+		return result
+	}
+
+	var response PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		result.Err = fmt.Errorf("invalid plugin response: %v", err)
+		return result
+	}
+	if response.LatencyMs != nil {
+		result.Latency = time.Duration(*response.LatencyMs * float64(time.Millisecond))
+	}
+	result.RemoteAddr = response.RemoteAddr
+	if !response.Up {
+		message := response.Error
+		if message == "" {
+			message = "plugin reported DOWN"
+		}
+		result.Err = errors.New(message)
+		return result
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// pluginRequest encodes the request sent to a plugin. The endpoint goes
+// through YAML so plugins see the keys of the configuration file.
+func pluginRequest(req Configuration, timeout time.Duration) ([]byte, error) {
+	encoded, err := yaml.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var endpoint map[string]any
+	if err := yaml.Unmarshal(encoded, &endpoint); err != nil {
+		return nil, err
+	}
+	return json.Marshal(PluginRequest{
+		Version:   PluginProtocolVersion,
+		Endpoint:  endpoint,
+		TimeoutMs: timeout.Milliseconds(),
+	})
+}
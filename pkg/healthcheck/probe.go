@@ -0,0 +1,127 @@
+package healthcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxProbeResults bounds the results a probe agent buffers between summaries,
+// so an unreachable coordinator doesn't grow memory without bound
+const maxProbeResults = 10000
+
+// maxProbeReportSize bounds the body of POST /api/probes, fitting a report
+// of maxProbeResults with long error messages
+const maxProbeReportSize = 8 << 20
+
+// ProbeResult is a check result reported by a probe agent to its coordinator
+type ProbeResult struct {
+	Name       string    `json:"name"`
+	Url        string    `json:"url"`
+	Up         bool      `json:"up"`
+	StatusCode int       `json:"status_code,omitempty"`
+	LatencyMs  float64   `json:"latency_ms"`
+	Cause      string    `json:"cause,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// ProbeReport is the body of POST /api/probes: the results of a probe agent
+// since its previous report
+type ProbeReport struct {
+	Region  string        `json:"region"`
+	Results []ProbeResult `json:"results"`
+}
+
+// probeResult converts a check result for a probe report
+func probeResult(r Result) ProbeResult {
+	result := ProbeResult{
+		Name:       r.Endpoint.Name,
+		Url:        r.Endpoint.Url,
+		Up:         r.Up,
+		StatusCode: r.StatusCode,
+		LatencyMs:  milliseconds(r.Latency),
+		Cause:      r.Cause(),
+		Time:       r.Time,
+	}
+	if r.Err != nil {
+		result.Error = r.Err.Error()
+	}
+	return result
+}
+
+// ProbeSink is a ResultSink turning a monitor into a probe agent: the results
+// of its checks are sent to a coordinator after every summary, labeled with
+// the region the agent runs in
+type ProbeSink struct {
+	// URL is the base URL of the coordinator's status API
+	URL    string
+	Region string
+	// Token, when set, is sent as a bearer token
+	Token  string
+	Client *http.Client
+
+	mu      sync.Mutex
+	results []ProbeResult
+}
+
+// NewProbeSink returns a ProbeSink reporting to the coordinator at the given URL
+func NewProbeSink(url, region, token string) *ProbeSink {
+	return &ProbeSink{
+		URL:    url,
+		Region: region,
+		Token:  token,
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Observe buffers a check result
+func (p *ProbeSink) Observe(r Result, stats Availability) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.results) < maxProbeResults {
+		p.results = append(p.results, probeResult(r))
+	}
+}
+
+// Flush sends the buffered results to the coordinator. The buffer is
+// cleared even if the request fails.
+func (p *ProbeSink) Flush(cycle Cycle) error {
+	p.mu.Lock()
+	results := p.results
+	p.results = nil
+	p.mu.Unlock()
+
+	if len(results) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(ProbeReport{Region: p.Region, Results: results})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(p.URL, "/")+"/api/probes", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to report to coordinator: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("coordinator returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package healthcheck
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// TypeRedis is a Redis server check
+const TypeRedis = "redis"
+
+// RedisChecker checks that a Redis server answers PING with PONG,
+// authenticating first when credentials are set. rediss:// URLs and
+// endpoints with a tls section connect over TLS.
+type RedisChecker struct {
+	// LatencyThreshold is the maximum latency for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the check unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeRedis, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewRedisChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewRedisChecker returns a RedisChecker using the given latency threshold and default timeout
+func NewRedisChecker(latencyThreshold, timeout time.Duration) *RedisChecker {
+	return &RedisChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check connects, authenticates if needed and sends PING, measuring the
+// latency of the whole exchange
+func (c *RedisChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	username, password, err := req.credentials()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	useTLS := strings.HasPrefix(req.Url, "rediss://") || req.TLS != nil
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+
+	startTime := time.Now()
+	conn, err := dialService(ctx, req, serviceAddress(req.Url, "6379"), useTLS)
+	if err == nil {
+		defer conn.Close()
+		result.RemoteAddr = conn.RemoteAddr().String()
+		err = redisPing(conn, username, password)
+	}
+	result.Latency = time.Since(startTime)
+
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// redisPing authenticates when a password is set and expects PONG in reply to PING
+func redisPing(conn net.Conn, username, password string) error {
+	reader := bufio.NewReader(conn)
+
+	if password != "" {
+		args := []string{"AUTH", password}
+		if username != "" {
+			args = []string{"AUTH", username, password}
+		}
+		reply, err := redisCommand(conn, reader, args...)
+		if err != nil {
+			return fmt.Errorf("AUTH failed: %v", err)
+		}
+		if reply != "OK" {
+			return fmt.Errorf("AUTH failed: unexpected reply %q", reply)
+		}
+	}
+
+	reply, err := redisCommand(conn, reader, "PING")
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected reply to PING: %q", reply)
+	}
+	return nil
+}
+
+// redisCommand sends a command and returns its simple string reply. Error
+// replies, e.g. -NOAUTH or -LOADING, are returned as errors.
+func redisCommand(conn net.Conn, reader *bufio.Reader, args ...string) (string, error) {
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(command.String())); err != nil {
+		return "", err
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return line[1:], nil
+	case strings.HasPrefix(line, "-"):
+		return "", fmt.Errorf("server error: %s", line[1:])
+	default:
+		return "", fmt.Errorf("unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,173 @@
+package healthcheck
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegionStatus is the state of an endpoint as seen from one region
+type RegionStatus struct {
+	Region       string    `json:"region"`
+	Up           bool      `json:"up"`
+	LastCheck    time.Time `json:"last_check"`
+	Error        string    `json:"error,omitempty"`
+	Availability int       `json:"availability_pct"`
+	TotalChecks  int       `json:"total_checks"`
+
+	successes int
+}
+
+// RegionalEndpoint is the state of an endpoint in every region checking it
+type RegionalEndpoint struct {
+	Name    string         `json:"name"`
+	Url     string         `json:"url"`
+	Regions []RegionStatus `json:"regions"`
+	// RegionalOutage is set while the endpoint is DOWN from some regions and
+	// UP from others
+	RegionalOutage bool `json:"regional_outage"`
+}
+
+// RegionTracker aggregates the results of an endpoint from every region that
+// checks it, to tell regional outages from global ones. It is safe for
+// concurrent use.
+type RegionTracker struct {
+	mu        sync.Mutex
+	endpoints map[string]*RegionalEndpoint
+}
+
+// NewRegionTracker returns an empty RegionTracker
+func NewRegionTracker() *RegionTracker {
+	return &RegionTracker{endpoints: make(map[string]*RegionalEndpoint)}
+}
+
+// Record applies the result of a check from a region. Regions that haven't
+// checked the endpoint within staleAfter, e.g. a probe agent that stopped
+// reporting, are left out of the comparison. It reports whether a regional
+// outage of the endpoint started, or recovered with every region UP, with the
+// status of the endpoint in every region, e.g. "DOWN from eu-west, UP from
+// us-east". A regional outage becoming global neither starts nor recovers.
+func (t *RegionTracker) Record(region string, r ProbeResult, staleAfter time.Duration) (started, recovered bool, detail string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	endpoint, exists := t.endpoints[r.Url]
+	if !exists {
+		endpoint = &RegionalEndpoint{Url: r.Url}
+		t.endpoints[r.Url] = endpoint
+	}
+	endpoint.Name = r.Name
+
+	i := slices.IndexFunc(endpoint.Regions, func(s RegionStatus) bool { return s.Region == region })
+	if i < 0 {
+		endpoint.Regions = append(endpoint.Regions, RegionStatus{Region: region})
+		slices.SortFunc(endpoint.Regions, func(a, b RegionStatus) int { return strings.Compare(a.Region, b.Region) })
+		i = slices.IndexFunc(endpoint.Regions, func(s RegionStatus) bool { return s.Region == region })
+	}
+	status := &endpoint.Regions[i]
+	status.Up, status.LastCheck, status.Error = r.Up, r.Time, r.Error
+	status.TotalChecks++
+	if r.Up {
+		status.successes++
+	}
+	status.Availability = int(float64(status.successes)/float64(status.TotalChecks)*100 + 0.5)
+
+	var up, down []string
+	for _, s := range endpoint.Regions {
+		if staleAfter > 0 && r.Time.Sub(s.LastCheck) > staleAfter {
+			continue
+		}
+		if s.Up {
+			up = append(up, s.Region)
+		} else {
+			down = append(down, s.Region)
+		}
+	}
+	regional := len(up) > 0 && len(down) > 0
+	started = regional && !endpoint.RegionalOutage
+	recovered = endpoint.RegionalOutage && len(down) == 0
+	endpoint.RegionalOutage = regional
+	return started, recovered, regionDetail(up, down)
+}
+
+// Snapshot returns the state of every endpoint in every region, by name
+func (t *RegionTracker) Snapshot() []RegionalEndpoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	endpoints := make([]RegionalEndpoint, 0, len(t.endpoints))
+	for _, endpoint := range t.endpoints {
+		snapshot := *endpoint
+		snapshot.Regions = slices.Clone(endpoint.Regions)
+		endpoints = append(endpoints, snapshot)
+	}
+	slices.SortFunc(endpoints, func(a, b RegionalEndpoint) int { return strings.Compare(a.Name, b.Name) })
+	return endpoints
+}
+
+// regionDetail formats the regions an endpoint is DOWN and UP from
+func regionDetail(up, down []string) string {
+	var parts []string
+	if len(down) > 0 {
+		parts = append(parts, "DOWN from "+strings.Join(down, ", "))
+	}
+	if len(up) > 0 {
+		parts = append(parts, "UP from "+strings.Join(up, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// recordRegion applies a result from a region and alerts when a regional
+// outage of its endpoint starts or recovers. An outage spreading to every
+// region is alerted by the checks themselves. Probe agents report once per
+// summary, so regions are stale after missing three checks or summaries.
+func (s *Scheduler) recordRegion(region string, r ProbeResult) {
+	req, known := endpointByURL(s.Endpoints(), r.Url)
+	if !known {
+		req = Configuration{Name: r.Name, Url: r.Url}
+	}
+	staleAfter := 3 * max(req.intervalOr(s.Interval), s.Interval)
+	started, recovered, detail := s.Regions.Record(region, r, staleAfter)
+	if !started && !recovered {
+		return
+	}
+
+	alert := Alert{
+		Endpoint: req,
+		Result:   Result{Endpoint: req, Up: r.Up, StatusCode: r.StatusCode, Time: r.Time},
+	}
+	if s.Maintenance != nil {
+		if window, active := s.Maintenance.Active(req, r.Time); active {
+			alert.Result.Maintenance = window.Name
+		}
+	}
+	if started {
+		alert.Previous, alert.Current = StatusUp, StatusDown
+		alert.Reason = "regional outage, " + detail
+		s.Logger.Printf("REGIONAL OUTAGE: %s (%s) %s", req.Name, req.Url, detail)
+	} else {
+		alert.Previous, alert.Current = StatusDown, StatusUp
+		alert.Reason = "regional outage over, " + detail
+		s.Logger.Printf("RECOVERED: %s (%s) regional outage over, %s", req.Name, req.Url, detail)
+	}
+	s.sendAlert(alert)
+}
+
+// endpointByURL returns the endpoint checking the given URL, the key of the
+// results of every region
+func endpointByURL(endpoints []Configuration, url string) (Configuration, bool) {
+	for _, req := range endpoints {
+		if req.Url == url {
+			return req, true
+		}
+	}
+	return Configuration{}, false
+}
+
+// ReceiveProbes records the results reported by a probe agent
+func (s *Scheduler) ReceiveProbes(report ProbeReport) {
+	for _, r := range report.Results {
+		s.recordRegion(report.Region, r)
+	}
+}
@@ -0,0 +1,152 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxConfigSize bounds the size of a remote configuration document
+const maxConfigSize = 10 << 20
+
+// IsRemote reports whether a configuration path is an HTTP(S) URL
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// RemoteConfig fetches the configuration file from an HTTP(S) URL. The ETag
+// of the last document is sent with every request so unchanged documents are
+// not downloaded and parsed again.
+type RemoteConfig struct {
+	URL string
+	// Headers are added to every request, e.g. an Authorization header
+	Headers map[string]string
+	// Format of the document. When empty it is detected from the
+	// Content-Type, then from the extension of the URL path.
+	Format string
+	Client *http.Client
+	// AllowExec accepts exec checks in the document. They are refused by
+	// default, since whoever controls the URL could run commands on this host.
+	AllowExec bool
+	// AllowEnv interpolates ${VAR} references in the document. They are
+	// refused by default, since whoever controls the URL could have the
+	// secrets in the environment of this host sent to their endpoints.
+	AllowEnv bool
+
+	mu   sync.Mutex
+	etag string
+}
+
+// NewRemoteConfig returns a RemoteConfig fetching the given URL
+func NewRemoteConfig(rawURL string, headers map[string]string, format string) *RemoteConfig {
+	return &RemoteConfig{
+		URL:     rawURL,
+		Headers: headers,
+		Format:  format,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Fetch downloads and parses the configuration. It returns a nil Config
+// without error when the document hasn't changed since the last fetch.
+func (r *RemoteConfig) Fetch(ctx context.Context) (*Config, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range r.Headers {
+		req.Header.Set(key, value)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configuration from '%s': %v", r.URL, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch configuration from '%s': status %d", r.URL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxConfigSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configuration from '%s': %v", r.URL, err)
+	}
+	env := envLookup(refuseEnv)
+	if r.AllowEnv {
+		env = lookupEnv
+	}
+	config, err := parseFormat(data, r.format(resp.Header.Get("Content-Type")), env)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Include) > 0 {
+		return nil, fmt.Errorf("include is not supported in a configuration fetched from a URL")
+	}
+	if !r.AllowExec {
+		for _, req := range config.Endpoints {
+			if req.Type == TypeExec {
+				return nil, fmt.Errorf("endpoint '%s': exec checks are not allowed in a configuration fetched from a URL", req.Name)
+			}
+		}
+	}
+
+	// Only remember the ETag of a valid document so a broken one is retried
+	r.etag = resp.Header.Get("ETag")
+	return config, nil
+}
+
+// Watch fetches the configuration at every interval until ctx is cancelled,
+// calling onChange with every changed document and onError with failures
+func (r *RemoteConfig) Watch(ctx context.Context, interval time.Duration, onChange func(*Config), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			config, err := r.Fetch(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				onError(err)
+			case config != nil:
+				onChange(config)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// format returns the document format from the configured format, the
+// response Content-Type or the URL path, in that order
+func (r *RemoteConfig) format(contentType string) string {
+	if r.Format != "" {
+		return r.Format
+	}
+	switch {
+	case strings.Contains(contentType, "json"):
+		return FormatJSON
+	case strings.Contains(contentType, "toml"):
+		return FormatTOML
+	}
+	if parsed, err := url.Parse(r.URL); err == nil {
+		return DetectFormat(parsed.Path)
+	}
+	return FormatYAML
+}
@@ -0,0 +1,202 @@
+package healthcheck
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// RemoteWriter is a ResultSink pushing check results as time series to a
+// Prometheus remote_write endpoint, such as Mimir, Thanos Receive or
+// VictoriaMetrics, for monitors that can't be scraped. The series match the
+// ones served by Metrics, plus healthcheck_availability_ratio:
+//
+//	healthcheck_up                     1 or 0 at every check
+//	healthcheck_latency_seconds        latency of every check
+//	healthcheck_checks_total           checks per result, cumulative
+//	healthcheck_failures_total         failed checks per cause, cumulative
+//	healthcheck_clock_offset_seconds   NTP clock offset
+//	healthcheck_availability_ratio     availability at every summary
+type RemoteWriter struct {
+	URL string
+	// Headers are added to every request, e.g. X-Scope-OrgID for Mimir
+	Headers map[string]string
+	// ExternalLabels are added to every series, e.g. to tell monitors apart
+	ExternalLabels map[string]string
+	Client         *http.Client
+
+	mu     sync.Mutex
+	series map[string]*remoteSeries
+	checks map[string]float64
+}
+
+// remoteSeries is a time series buffered until the next write
+type remoteSeries struct {
+	labels  [][2]string
+	samples []remoteSample
+}
+
+// remoteSample is a value at a timestamp in milliseconds
+type remoteSample struct {
+	value     float64
+	timestamp int64
+}
+
+// NewRemoteWriter returns a RemoteWriter pushing to the given URL
+func NewRemoteWriter(url string, headers, externalLabels map[string]string) *RemoteWriter {
+	return &RemoteWriter{
+		URL:            url,
+		Headers:        headers,
+		ExternalLabels: externalLabels,
+		Client:         &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Observe buffers the samples of a check result
+func (w *RemoteWriter) Observe(r Result, stats Availability) {
+	up := 0.0
+	if r.Up {
+		up = 1
+	}
+	timestamp := r.Time.UnixMilli()
+	outcome := r.Outcome()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.checks == nil {
+		w.checks = make(map[string]float64)
+	}
+
+	w.add("healthcheck_up", r.Endpoint, nil, up, timestamp)
+	w.add("healthcheck_latency_seconds", r.Endpoint, nil, r.Latency.Seconds(), timestamp)
+	counter := r.Endpoint.Url + "\x00" + outcome
+	w.checks[counter]++
+	w.add("healthcheck_checks_total", r.Endpoint, [][2]string{{"result", outcome}}, w.checks[counter], timestamp)
+	if cause := r.Cause(); cause != "" {
+		counter := r.Endpoint.Url + "\x00cause\x00" + cause
+		w.checks[counter]++
+		w.add("healthcheck_failures_total", r.Endpoint, [][2]string{{"cause", cause}}, w.checks[counter], timestamp)
+	}
+	if r.Offset != 0 {
+		w.add("healthcheck_clock_offset_seconds", r.Endpoint, nil, r.Offset.Seconds(), timestamp)
+	}
+}
+
+// Flush adds the availability of every endpoint to the buffered samples and
+// writes them. The buffer is cleared even if the write fails so a broken
+// endpoint doesn't grow memory without bound.
+func (w *RemoteWriter) Flush(cycle Cycle) error {
+	timestamp := time.Now().UnixMilli()
+
+	w.mu.Lock()
+	for _, req := range cycle.Endpoints {
+		stats := cycle.Availability[req.Url]
+		if stats.Total() == 0 {
+			continue
+		}
+		w.add("healthcheck_availability_ratio", req, nil, float64(stats.SuccessCount)/float64(stats.Total()), timestamp)
+	}
+	series := w.series
+	w.series = nil
+	w.mu.Unlock()
+
+	if len(series) == 0 {
+		return nil
+	}
+	return w.write(encodeWriteRequest(series))
+}
+
+// add appends a sample to its series. The caller holds w.mu.
+func (w *RemoteWriter) add(name string, req Configuration, extra [][2]string, value float64, timestamp int64) {
+	labels := [][2]string{{"__name__", name}, {"name", req.Name}, {"domain", req.Domain()}}
+	labels = append(labels, extra...)
+	for key, value := range w.ExternalLabels {
+		labels = append(labels, [2]string{key, value})
+	}
+	// Remote write requires the labels of a series sorted by name
+	slices.SortFunc(labels, func(a, b [2]string) int { return strings.Compare(a[0], b[0]) })
+
+	var key strings.Builder
+	for _, label := range labels {
+		key.WriteString(label[0] + "\x00" + label[1] + "\x00")
+	}
+	if w.series == nil {
+		w.series = make(map[string]*remoteSeries)
+	}
+	s, ok := w.series[key.String()]
+	if !ok {
+		s = &remoteSeries{labels: labels}
+		w.series[key.String()] = s
+	}
+	s.samples = append(s.samples, remoteSample{value, timestamp})
+}
+
+// write posts a snappy compressed WriteRequest
+func (w *RemoteWriter) write(request []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(s2.EncodeSnappy(nil, request)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for key, value := range w.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to remote write: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// encodeWriteRequest encodes the series as a prometheus.WriteRequest protobuf
+// message, with the samples of each series in time order
+func encodeWriteRequest(series map[string]*remoteSeries) []byte {
+	var request []byte
+	for _, key := range slices.Sorted(maps.Keys(series)) {
+		s := series[key]
+		slices.SortStableFunc(s.samples, func(a, b remoteSample) int { return cmp.Compare(a.timestamp, b.timestamp) })
+
+		var timeseries []byte
+		for _, label := range s.labels {
+			var encoded []byte
+			encoded = protowire.AppendTag(encoded, 1, protowire.BytesType)
+			encoded = protowire.AppendString(encoded, label[0])
+			encoded = protowire.AppendTag(encoded, 2, protowire.BytesType)
+			encoded = protowire.AppendString(encoded, label[1])
+			timeseries = protowire.AppendTag(timeseries, 1, protowire.BytesType)
+			timeseries = protowire.AppendBytes(timeseries, encoded)
+		}
+		for _, sample := range s.samples {
+			var encoded []byte
+			encoded = protowire.AppendTag(encoded, 1, protowire.Fixed64Type)
+			encoded = protowire.AppendFixed64(encoded, math.Float64bits(sample.value))
+			encoded = protowire.AppendTag(encoded, 2, protowire.VarintType)
+			encoded = protowire.AppendVarint(encoded, uint64(sample.timestamp))
+			timeseries = protowire.AppendTag(timeseries, 2, protowire.BytesType)
+			timeseries = protowire.AppendBytes(timeseries, encoded)
+		}
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, timeseries)
+	}
+	return request
+}
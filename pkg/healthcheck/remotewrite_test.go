@@ -0,0 +1,207 @@
+package healthcheck
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// writeRequestDescriptor describes prometheus.WriteRequest as defined by the
+// remote.proto and types.proto files of Prometheus, so requests are decoded
+// by the protobuf runtime rather than by the encoder under test
+func writeRequestDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, message string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     kind.Enum(),
+		}
+		if message != "" {
+			f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			f.TypeName = proto.String(".prometheus." + message)
+		}
+		return f
+	}
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("remote.proto"),
+		Package: proto.String("prometheus"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("WriteRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("timeseries", 1, message, "TimeSeries"),
+			}},
+			{Name: proto.String("TimeSeries"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("labels", 1, message, "Label"),
+				field("samples", 2, message, "Sample"),
+			}},
+			{Name: proto.String("Label"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			}},
+			{Name: proto.String("Sample"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("value", 1, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, ""),
+				field("timestamp", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+			}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("invalid descriptor: %v", err)
+	}
+	return file.Messages().ByName("WriteRequest")
+}
+
+// decodedSample is a sample of a decoded WriteRequest
+type decodedSample struct {
+	value     float64
+	timestamp int64
+}
+
+// decodeWriteRequest decodes a snappy compressed WriteRequest into its
+// samples keyed by the labels of their series, failing on unknown fields
+// and unsorted labels
+func decodeWriteRequest(t *testing.T, body []byte) map[string][]decodedSample {
+	t.Helper()
+	data, err := s2.Decode(nil, body)
+	if err != nil {
+		t.Fatalf("invalid snappy block: %v", err)
+	}
+	request := dynamicpb.NewMessage(writeRequestDescriptor(t))
+	if err := proto.Unmarshal(data, request); err != nil {
+		t.Fatalf("invalid WriteRequest: %v", err)
+	}
+	if unknown := request.GetUnknown(); len(unknown) > 0 {
+		t.Fatalf("WriteRequest has unknown fields: %x", unknown)
+	}
+
+	series := make(map[string][]decodedSample)
+	fields := request.Descriptor().Fields()
+	timeseries := request.Get(fields.ByName("timeseries")).List()
+	for i := range timeseries.Len() {
+		ts := timeseries.Get(i).Message()
+		if len(ts.GetUnknown()) > 0 {
+			t.Fatalf("TimeSeries has unknown fields")
+		}
+		tsFields := ts.Descriptor().Fields()
+
+		var names, labels []string
+		list := ts.Get(tsFields.ByName("labels")).List()
+		for j := range list.Len() {
+			label := list.Get(j).Message()
+			name := label.Get(label.Descriptor().Fields().ByName("name")).String()
+			value := label.Get(label.Descriptor().Fields().ByName("value")).String()
+			names = append(names, name)
+			labels = append(labels, name+"="+value)
+		}
+		if !slices.IsSorted(names) {
+			t.Errorf("labels are not sorted: %v", names)
+		}
+		key := strings.Join(labels, ",")
+
+		list = ts.Get(tsFields.ByName("samples")).List()
+		for j := range list.Len() {
+			sample := list.Get(j).Message()
+			series[key] = append(series[key], decodedSample{
+				value:     sample.Get(sample.Descriptor().Fields().ByName("value")).Float(),
+				timestamp: sample.Get(sample.Descriptor().Fields().ByName("timestamp")).Int(),
+			})
+		}
+	}
+	return series
+}
+
+func TestRemoteWriterRoundTrip(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	endpoint := Configuration{Name: "api", Url: "https://api.example.com/health"}
+	writer := NewRemoteWriter(server.URL, map[string]string{"X-Scope-OrgID": "tenant"}, map[string]string{"monitor": "eu"})
+	store := NewResultStore([]Configuration{endpoint})
+
+	start := time.UnixMilli(1700000000000)
+	failed := Result{Endpoint: endpoint, Err: errors.New("connection refused"), Latency: time.Second, Time: start.Add(time.Minute)}
+	up := Result{Endpoint: endpoint, Up: true, StatusCode: 200, Latency: 250 * time.Millisecond, Time: start}
+	// Observed out of order, the samples of a series are still written in
+	// time order
+	for _, r := range []Result{failed, up} {
+		store.Record(r)
+		writer.Observe(r, store.Get(endpoint.Url))
+	}
+	cycle := Cycle{Endpoints: []Configuration{endpoint}, Availability: store.Snapshot()}
+	if err := writer.Flush(cycle); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+		"X-Scope-OrgID":                     "tenant",
+	} {
+		if got := header.Get(key); got != want {
+			t.Errorf("header %s: got %q, want %q", key, got, want)
+		}
+	}
+
+	series := decodeWriteRequest(t, body)
+	labels := "domain=api.example.com,monitor=eu,name=api"
+	want := map[string][]decodedSample{
+		"__name__=healthcheck_up," + labels: {
+			{1, start.UnixMilli()},
+			{0, start.Add(time.Minute).UnixMilli()},
+		},
+		"__name__=healthcheck_latency_seconds," + labels: {
+			{0.25, start.UnixMilli()},
+			{1, start.Add(time.Minute).UnixMilli()},
+		},
+		"__name__=healthcheck_checks_total," + labels + ",result=" + OutcomeUp: {
+			{1, start.UnixMilli()},
+		},
+		"__name__=healthcheck_checks_total," + labels + ",result=" + OutcomeDown: {
+			{1, start.Add(time.Minute).UnixMilli()},
+		},
+		"__name__=healthcheck_failures_total,cause=" + failed.Cause() + "," + labels: {
+			{1, start.Add(time.Minute).UnixMilli()},
+		},
+	}
+	for key, samples := range want {
+		if got := series[key]; !slices.Equal(got, samples) {
+			t.Errorf("%s: got %v, want %v", key, got, samples)
+		}
+	}
+
+	availability := series["__name__=healthcheck_availability_ratio,"+labels]
+	if len(availability) != 1 || availability[0].value != 0.5 {
+		t.Errorf("availability: got %v, want a single 0.5 sample", availability)
+	}
+	if len(series) != len(want)+1 {
+		t.Errorf("got %d series, want %d", len(series), len(want)+1)
+	}
+
+	// The buffer is cleared by the write
+	body = nil
+	if err := writer.Flush(Cycle{}); err != nil || body != nil {
+		t.Errorf("second flush: got error %v and a write of %d bytes, want nothing written", err, len(body))
+	}
+}
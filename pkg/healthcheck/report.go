@@ -0,0 +1,192 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteSummary writes availability percentages and detailed metrics per URL
+func WriteSummary(w io.Writer, requests []Configuration, availability map[string]Availability) {
+	now := time.Now()
+
+	// Iterate over each request (each endpoint)
+	for _, req := range requests {
+		stats := availability[req.Url] // Keyed by full URL
+
+		total := stats.Total()
+		if total == 0 && stats.MaintenanceFailures == 0 {
+			fmt.Fprintf(w, "%s (%s) has no availability data yet.\n", req.Name, req.Url)
+			continue
+		}
+
+		// Print the availability percentage and detailed metrics per URL
+		fmt.Fprintf(w, "%s (%s) has %d%% availability percentage\n", req.Name, req.Url, stats.Percentage())
+		fmt.Fprintf(w, "   Rolling Availability: %s\n", rollingAvailability(stats, now))
+		if budgets := ErrorBudgets(req, stats, now); len(budgets) > 0 {
+			fmt.Fprintf(w, "   Error Budget (SLO %g%%): %s\n", req.SLO, formatErrorBudgets(budgets))
+		}
+		fmt.Fprintf(w, "   Total Checks: %d\n", total)
+		fmt.Fprintf(w, "   Successful Checks: %d\n", stats.SuccessCount)
+		fmt.Fprintf(w, "   Failed Checks: %d\n", stats.FailureCount)
+		if stats.SlowCount > 0 {
+			fmt.Fprintf(w, "   Latency Threshold Breaches: %d\n", stats.SlowCount)
+		}
+		if stats.FailureCount > 0 {
+			fmt.Fprintf(w, "   Failure Causes: %s\n", stats.Causes)
+		}
+		if stats.MaintenanceFailures > 0 {
+			fmt.Fprintf(w, "   Failed Checks During Maintenance: %d\n", stats.MaintenanceFailures)
+		}
+		if incidents := stats.Incidents; incidents.Count > 0 {
+			fmt.Fprintf(w, "   Incidents: %d (MTTR %s, MTBF %s)\n", incidents.Count, formatMean(incidents.MTTR()), formatMean(incidents.MTBF()))
+		}
+		if stats.SuccessCount > 0 {
+			fmt.Fprintf(w, "   Average Latency: %v\n", stats.AverageLatency())
+		} else {
+			fmt.Fprintf(w, "   Average Latency: N/A\n")
+		}
+		if stats.MinLatency > 0 {
+			fmt.Fprintf(w, "   Minimum Latency: %v\n", stats.MinLatency)
+		}
+		if stats.MaxLatency > 0 {
+			fmt.Fprintf(w, "   Maximum Latency: %v\n", stats.MaxLatency)
+		}
+		if stats.Latencies.Count > 0 {
+			fmt.Fprintf(w, "   Latency Percentiles: p50 %v, p95 %v, p99 %v\n",
+				stats.Latencies.Percentile(50), stats.Latencies.Percentile(95), stats.Latencies.Percentile(99))
+		}
+		if stats.Phases.Count > 0 {
+			fmt.Fprintf(w, "   Latency Breakdown: %v\n", stats.Phases.Average())
+		}
+		if transfers := stats.Transfers; transfers.Count > 0 {
+			fmt.Fprintf(w, "   Response Size: %s average, %s/s throughput\n", formatBytes(transfers.AverageSize()), formatBytes(transfers.Throughput()))
+			if transfers.Compressed > 0 || req.AcceptEncoding != "" || req.ExpectCompressed {
+				fmt.Fprintf(w, "   Compression: %s\n", compressionDetail(transfers))
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// EndpointSummary is the availability report of a single endpoint
+type EndpointSummary struct {
+	Name                string   `json:"name"`
+	Url                 string   `json:"url"`
+	Tags                []string `json:"tags,omitempty"`
+	Availability        int      `json:"availability_pct"`
+	TotalChecks         int      `json:"total_checks"`
+	SuccessfulChecks    int      `json:"successful_checks"`
+	FailedChecks        int      `json:"failed_checks"`
+	SlowChecks          int      `json:"slow_checks"`
+	MaintenanceFailures int      `json:"maintenance_failures"`
+	AverageLatencyMs    float64  `json:"avg_latency_ms"`
+	MinLatencyMs        float64  `json:"min_latency_ms"`
+	MaxLatencyMs        float64  `json:"max_latency_ms"`
+	P50LatencyMs        float64  `json:"p50_latency_ms"`
+	P95LatencyMs        float64  `json:"p95_latency_ms"`
+	P99LatencyMs        float64  `json:"p99_latency_ms"`
+	// FailureCauses counts the failed checks by cause
+	FailureCauses map[string]int `json:"failure_causes,omitempty"`
+	// Incidents counts the periods of consecutive failures, MTTRSeconds is the
+	// mean duration of those that recovered and MTBFSeconds the mean time UP
+	// between them
+	Incidents   int     `json:"incidents"`
+	MTTRSeconds float64 `json:"mttr_seconds"`
+	MTBFSeconds float64 `json:"mtbf_seconds"`
+	// WindowAvailability maps window labels (1h, 24h, 7d) to the availability
+	// over that window. Windows without checks are omitted.
+	WindowAvailability map[string]int `json:"window_availability_pct"`
+	// LatencyBreakdown is the average time spent in each phase of HTTP checks
+	LatencyBreakdown *PhaseSummary `json:"latency_breakdown,omitempty"`
+	// AverageSizeBytes and ThroughputBps are the mean response body size and
+	// the download rate of HTTP checks
+	AverageSizeBytes float64 `json:"avg_size_bytes,omitempty"`
+	ThroughputBps    float64 `json:"throughput_bps,omitempty"`
+	// CompressedResponses counts the compressed responses, and
+	// CompressionRatio is their compressed size over their original size
+	CompressedResponses int           `json:"compressed_responses,omitempty"`
+	CompressionRatio    float64       `json:"compression_ratio,omitempty"`
+	SLO                 float64       `json:"slo,omitempty"`
+	ErrorBudgets        []ErrorBudget `json:"error_budgets,omitempty"`
+}
+
+// Summarize returns the availability report of every endpoint
+func Summarize(requests []Configuration, availability map[string]Availability) []EndpointSummary {
+	now := time.Now()
+	summaries := make([]EndpointSummary, 0, len(requests))
+	for _, req := range requests {
+		stats := availability[req.Url]
+
+		windows := make(map[string]int)
+		for _, window := range Windows {
+			if pct, ok := stats.Windows.Availability(window.Duration, now); ok {
+				windows[window.Label] = pct
+			}
+		}
+
+		var breakdown *PhaseSummary
+		if stats.Phases.Count > 0 {
+			phases := stats.Phases.Average().summary()
+			breakdown = &phases
+		}
+
+		summaries = append(summaries, EndpointSummary{
+			Name:                req.Name,
+			Url:                 req.Url,
+			Tags:                req.Tags,
+			Availability:        stats.Percentage(),
+			TotalChecks:         stats.Total(),
+			SuccessfulChecks:    stats.SuccessCount,
+			FailedChecks:        stats.FailureCount,
+			SlowChecks:          stats.SlowCount,
+			FailureCauses:       stats.Causes.Map(),
+			MaintenanceFailures: stats.MaintenanceFailures,
+			AverageLatencyMs:    milliseconds(stats.AverageLatency()),
+			MinLatencyMs:        milliseconds(stats.MinLatency),
+			MaxLatencyMs:        milliseconds(stats.MaxLatency),
+			P50LatencyMs:        milliseconds(stats.Latencies.Percentile(50)),
+			P95LatencyMs:        milliseconds(stats.Latencies.Percentile(95)),
+			P99LatencyMs:        milliseconds(stats.Latencies.Percentile(99)),
+			Incidents:           stats.Incidents.Count,
+			MTTRSeconds:         stats.Incidents.MTTR().Seconds(),
+			MTBFSeconds:         stats.Incidents.MTBF().Seconds(),
+			WindowAvailability:  windows,
+			LatencyBreakdown:    breakdown,
+			AverageSizeBytes:    stats.Transfers.AverageSize(),
+			ThroughputBps:       stats.Transfers.Throughput(),
+			CompressedResponses: stats.Transfers.Compressed,
+			CompressionRatio:    stats.Transfers.CompressionRatio(),
+			SLO:                 req.SLO,
+			ErrorBudgets:        ErrorBudgets(req, stats, now),
+		})
+	}
+	return summaries
+}
+
+// rollingAvailability formats the availability over every reporting window
+func rollingAvailability(stats Availability, now time.Time) string {
+	parts := make([]string, len(Windows))
+	for i, window := range Windows {
+		if pct, ok := stats.Windows.Availability(window.Duration, now); ok {
+			parts[i] = fmt.Sprintf("%s %d%%", window.Label, pct)
+		} else {
+			parts[i] = fmt.Sprintf("%s N/A", window.Label)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatMean formats a mean time for the summary, or N/A when there is none
+func formatMean(d time.Duration) string {
+	if d == 0 {
+		return "N/A"
+	}
+	return d.Round(time.Second).String()
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,121 @@
+package healthcheck
+
+import (
+	"net"
+	"time"
+)
+
+// Result holds the outcome of a single endpoint check
+type Result struct {
+	Endpoint   Configuration
+	Up         bool
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+	Time       time.Time
+	// Slow is set when the check succeeded but took longer than the latency
+	// threshold. Slow checks count as DOWN.
+	Slow bool
+	// Maintenance is the name of the maintenance window the check ran in, if any
+	Maintenance string
+	// Retries is the number of times the check was retried before this result
+	Retries int
+	// RemoteAddr is the address the check connected to, when known
+	RemoteAddr string
+	// Phases is the latency breakdown of HTTP checks
+	Phases Phases
+	// Size is the response body size in bytes of HTTP checks, after
+	// decompression. Encoding is its content encoding, e.g. gzip, and
+	// CompressedSize its size as received when it was compressed and decoded
+	// by the check.
+	Size           int64
+	Encoding       string
+	CompressedSize int64
+	// Checksum is the hex SHA-256 of the response body of HTTP checks that
+	// expect a checksum or detect changes
+	Checksum string
+	// Protocol is the negotiated protocol of HTTP checks, e.g. HTTP/2.0, or
+	// the version banner of SSH servers
+	Protocol string
+	// Offset is the clock offset of NTP servers from the local clock, zero
+	// when not measured
+	Offset time.Duration
+	// Skipped is the name of the DOWN endpoint this one depends on when the
+	// check was skipped
+	Skipped string
+}
+
+// Status is the reported state of an endpoint
+type Status string
+
+// Endpoint states
+const (
+	StatusUnknown Status = "UNKNOWN"
+	StatusUp      Status = "UP"
+	StatusDown    Status = "DOWN"
+	// StatusFlapping is reported for endpoints oscillating between UP and DOWN
+	StatusFlapping Status = "FLAPPING"
+	// StatusDegraded is reported for UP endpoints with anomalous latency
+	StatusDegraded Status = "DEGRADED"
+	// StatusSkipped is reported for endpoints that aren't checked because an
+	// endpoint they depend on is DOWN
+	StatusSkipped Status = "SKIPPED"
+)
+
+// Check outcomes as reported in metrics
+const (
+	OutcomeUp          = "up"
+	OutcomeDown        = "down"
+	OutcomeSlow        = "slow"
+	OutcomeMaintenance = "maintenance"
+)
+
+// Outcome classifies the result: up, slow (a latency threshold breach), down
+// (a hard failure) or maintenance (any failure during a maintenance window)
+func (r Result) Outcome() string {
+	switch {
+	case r.Up:
+		return OutcomeUp
+	case r.Maintenance != "":
+		return OutcomeMaintenance
+	case r.Slow:
+		return OutcomeSlow
+	default:
+		return OutcomeDown
+	}
+}
+
+// applyLatencyThreshold marks a successful result as slow, and so DOWN, when
+// its latency reaches the threshold
+func (r *Result) applyLatencyThreshold(threshold time.Duration) {
+	if r.Up && r.Latency >= threshold {
+		r.Up = false
+		r.Slow = true
+	}
+}
+
+// Status returns the state the result puts the endpoint in
+func (r Result) Status() Status {
+	if r.Up {
+		return StatusUp
+	}
+	return StatusDown
+}
+
+// IPVersion returns 4 or 6 for the address family the check connected
+// over, or 0 when unknown
+func (r Result) IPVersion() int {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return 0
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return 0
+	case ip.To4() != nil:
+		return 4
+	default:
+		return 6
+	}
+}
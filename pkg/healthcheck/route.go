@@ -0,0 +1,99 @@
+package healthcheck
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultNotifiers is the name routes use to refer to the notifiers set
+// directly in the alerting section
+const DefaultNotifiers = "default"
+
+// AlertRoute sends the alerts of the endpoints matching its tags and
+// severities to named notifier sets. Routes are evaluated in order and the
+// first match wins unless it sets continue. Alerts no route matches go to
+// the default notifiers.
+type AlertRoute struct {
+	Name string `yaml:"name,omitempty"`
+	// Tags and Severities restrict the route to endpoints with any of the
+	// tags and any of the severities. Empty lists match every endpoint.
+	Tags       []string `yaml:"tags,omitempty"`
+	Severities []string `yaml:"severities,omitempty"`
+	// Notifiers names the sets of the alerting notifiers section, or default
+	Notifiers []string `yaml:"notifiers"`
+	// RepeatInterval repeats the DOWN alert while the endpoint stays DOWN.
+	// The alert is sent once when zero.
+	RepeatInterval time.Duration `yaml:"repeat_interval,omitempty"`
+	// Continue evaluates the following routes after this one matched
+	Continue bool `yaml:"continue,omitempty"`
+	// Escalations notify more notifier sets when an endpoint stays DOWN
+	Escalations []AlertEscalation `yaml:"escalations,omitempty"`
+}
+
+// AlertEscalation sends the DOWN alert of an endpoint to more notifier sets
+// once it has been DOWN for After, and their recovery alert when it recovers
+type AlertEscalation struct {
+	After     time.Duration `yaml:"after"`
+	Notifiers []string      `yaml:"notifiers"`
+}
+
+// Matches reports whether the route applies to the endpoint
+func (r AlertRoute) Matches(req Configuration) bool {
+	if len(r.Severities) > 0 && !slices.Contains(r.Severities, req.severity()) {
+		return false
+	}
+	if len(r.Tags) == 0 {
+		return true
+	}
+	for _, tag := range r.Tags {
+		if slices.Contains(req.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRoutes checks that every route sends to defined notifier sets
+func validateRoutes(routes []AlertRoute, alerting AlertingConfig) error {
+	for i, route := range routes {
+		name := route.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if err := validateNotifierSets(route.Notifiers, alerting); err != nil {
+			return fmt.Errorf("alert route '%s': %v", name, err)
+		}
+		for _, severity := range route.Severities {
+			if err := validateSeverity(severity); err != nil {
+				return fmt.Errorf("alert route '%s': %v", name, err)
+			}
+		}
+		if route.RepeatInterval < 0 {
+			return fmt.Errorf("alert route '%s': negative repeat_interval", name)
+		}
+		for j, escalation := range route.Escalations {
+			if escalation.After <= 0 {
+				return fmt.Errorf("alert route '%s': escalation %d requires a positive after", name, j+1)
+			}
+			if err := validateNotifierSets(escalation.Notifiers, alerting); err != nil {
+				return fmt.Errorf("alert route '%s': escalation %d: %v", name, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNotifierSets checks that a list of notifier set names is not empty
+// and only names defined sets
+func validateNotifierSets(names []string, alerting AlertingConfig) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no notifiers")
+	}
+	for _, name := range names {
+		if _, ok := alerting.Notifiers[name]; !ok && name != DefaultNotifiers {
+			return fmt.Errorf("undefined notifier set '%s'", name)
+		}
+	}
+	return nil
+}
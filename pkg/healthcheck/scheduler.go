@@ -0,0 +1,467 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Scheduler runs health checks against a set of endpoints, each on its own
+// interval
+type Scheduler struct {
+	Checker Checker
+	// Interval is used for endpoints without their own interval and as the
+	// cadence of the availability summary
+	Interval time.Duration
+	Store    *ResultStore
+	State    *StateTracker
+
+	// Logger receives state changes, warnings and errors. Defaults to the
+	// standard logger.
+	Logger *log.Logger
+	// Sinks receive every check result and the availability report at every
+	// interval. Defaults to a ConsoleSink writing results to the standard
+	// logger and the report to stdout.
+	Sinks []ResultSink
+	// Telemetry, when set, records every check as an OpenTelemetry span and metrics
+	Telemetry *Telemetry
+	// Alerter, when set, is notified of every state transition
+	Alerter *Alerter
+	// StateFile, when set, is where availability is saved after every
+	// summary and when Run returns
+	StateFile string
+	// Maintenance, when set, holds the maintenance windows during which
+	// failures are recorded separately and alerts are suppressed
+	Maintenance *Maintenance
+	// Regions, when set, compares the results of the endpoints from every
+	// region, the local checks being labeled with Region and the others
+	// reported by probe agents, and alerts on regional outages
+	Regions *RegionTracker
+	Region  string
+	// ProbeToken is the bearer token probe agents must present. Reports are
+	// accepted from anyone when empty.
+	ProbeToken string
+	// APIToken is the bearer token required to add or remove silences
+	// through the status API. They can't be changed when empty.
+	APIToken string
+	// Election, when set, is the leader election of an HA monitor pair,
+	// reported by the health endpoints
+	Election *Election
+
+	// Concurrency bounds the number of checks running at the same time.
+	// Unlimited when 0.
+	Concurrency int
+	// Jitter is the maximum random delay added before every scheduled check
+	// of endpoints without their own jitter, capped at half their interval
+	Jitter time.Duration
+	// Stagger spreads the schedules of the endpoints across their interval
+	// instead of starting them all at once
+	Stagger bool
+
+	mu        sync.RWMutex
+	endpoints []Configuration
+	slotsOnce sync.Once
+	slots     chan struct{}
+
+	budgetMu  sync.Mutex
+	exhausted map[string]bool
+	reloaded  chan struct{}
+	health    monitorHealth
+}
+
+// NewScheduler returns a Scheduler with availability tracking initialized per URL
+func NewScheduler(endpoints []Configuration, checker Checker, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		Checker:   checker,
+		Interval:  interval,
+		Store:     NewResultStore(endpoints),
+		State:     NewStateTracker(),
+		Logger:    log.Default(),
+		Sinks:     []ResultSink{&ConsoleSink{Logger: log.Default(), Summary: os.Stdout}},
+		endpoints: endpoints,
+		reloaded:  make(chan struct{}, 1),
+		health:    monitorHealth{started: time.Now()},
+	}
+}
+
+// Endpoints returns the endpoints currently being monitored
+func (s *Scheduler) Endpoints() []Configuration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.endpoints
+}
+
+// Reload replaces the monitored endpoints. Availability and state are kept
+// for endpoints that remain, new endpoints start with empty stats and are
+// checked right away, and removed endpoints are dropped.
+func (s *Scheduler) Reload(endpoints []Configuration) {
+	s.mu.Lock()
+	s.endpoints = endpoints
+	s.mu.Unlock()
+
+	s.Store.Retain(endpoints)
+	s.State.Retain(endpoints)
+
+	// Wake up Run to restart the per-endpoint schedules
+	select {
+	case s.reloaded <- struct{}{}:
+	default:
+	}
+}
+
+// RunCycle checks every endpoint concurrently, records the results and
+// writes the availability summary. When Concurrency is set the checks are run
+// by a pool of that many workers. Endpoints are checked after the endpoint
+// they depend on.
+func (s *Scheduler) RunCycle(ctx context.Context) []Result {
+	endpoints := s.Endpoints()
+	results := make([]Result, len(endpoints))
+	for _, wave := range dependencyWaves(endpoints) {
+		s.runWave(ctx, endpoints, wave, results)
+	}
+
+	s.writeSummary() // Log after all checks
+	return results
+}
+
+// runWave concurrently checks the endpoints at the given indexes and stores
+// their results at the same indexes
+func (s *Scheduler) runWave(ctx context.Context, endpoints []Configuration, wave []int, results []Result) {
+	workers := len(wave)
+	if s.Concurrency > 0 && s.Concurrency < workers {
+		workers = s.Concurrency
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.check(ctx, endpoints[i])
+			}
+		}()
+	}
+	for _, i := range wave {
+		if ctx.Err() != nil {
+			results[i] = Result{Endpoint: endpoints[i], Time: time.Now(), Err: ctx.Err()}
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait() // Wait for all health checks to complete
+}
+
+// Run performs an initial check of every endpoint and then keeps checking
+// each endpoint at its own interval until ctx is cancelled. The availability
+// summary is written at the scheduler interval. On cancellation in-flight
+// checks are abandoned, a final summary is written and the state is saved.
+func (s *Scheduler) Run(ctx context.Context) {
+	// Initial health check before starting the per-endpoint schedules
+	s.Logger.Println("Starting initial health check...")
+	s.RunCycle(ctx)
+
+	stopEndpoints := s.startEndpoints(ctx)
+
+	// Create a ticker to write the summary at the specified interval
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.writeSummary()
+			s.saveState()
+		case <-s.reloaded:
+			s.Logger.Printf("Reloaded configuration with %d endpoints", len(s.Endpoints()))
+			stopEndpoints()
+			stopEndpoints = s.startEndpoints(ctx)
+		case <-ctx.Done():
+			stopEndpoints()
+			s.Logger.Println("Shutting down, writing final availability report...")
+			s.writeSummary()
+			s.saveState()
+			return
+		}
+	}
+}
+
+// startEndpoints starts checking every endpoint on its own interval. The
+// returned function cancels the checks and waits for them to finish.
+func (s *Scheduler) startEndpoints(ctx context.Context) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	endpoints := s.Endpoints()
+
+	var wg sync.WaitGroup
+	wg.Add(len(endpoints))
+	for _, req := range endpoints {
+		go func(r Configuration) {
+			defer wg.Done()
+			s.runEndpoint(ctx, r)
+		}(req)
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// runEndpoint checks a single endpoint at its interval until ctx is cancelled.
+// Endpoints that have never been checked are checked immediately. With
+// Stagger the schedule starts at the endpoint's offset within the interval.
+func (s *Scheduler) runEndpoint(ctx context.Context, req Configuration) {
+	interval := req.intervalOr(s.Interval)
+	if stats := s.Store.Get(req.Url); stats.Total() == 0 && stats.MaintenanceFailures == 0 {
+		s.check(ctx, req)
+	}
+	if req.Schedule != "" {
+		s.runSchedule(ctx, req)
+		return
+	}
+	if s.Stagger && !sleepContext(ctx, untilSlot(time.Now(), req.Url, interval)) {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !sleepContext(ctx, jitter(req.jitterOr(s.Jitter), interval)) {
+				return
+			}
+			s.check(ctx, req)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runSchedule checks an endpoint at every run of its cron schedule until ctx
+// is cancelled. Jitter is capped at half the time to the following run.
+func (s *Scheduler) runSchedule(ctx context.Context, req Configuration) {
+	schedule, err := cron.ParseStandard(req.Schedule)
+	if err != nil {
+		s.Logger.Printf("Error scheduling %s (%s): %v", req.Name, req.Url, err)
+		return
+	}
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+		delay := jitter(req.jitterOr(s.Jitter), schedule.Next(next).Sub(next))
+		if !sleepContext(ctx, time.Until(next)+delay) {
+			return
+		}
+		s.check(ctx, req)
+	}
+}
+
+// check runs a single check and records its result. Checks interrupted by
+// cancellation are not recorded.
+func (s *Scheduler) check(ctx context.Context, req Configuration) Result {
+	if parent, skip := s.skipCheck(req); skip {
+		return s.skip(req, parent)
+	}
+
+	var span trace.Span
+	if s.Telemetry != nil {
+		ctx, span = s.Telemetry.start(ctx, req)
+	}
+	result := s.probe(ctx, req)
+	if s.Telemetry != nil {
+		s.Telemetry.end(span, result)
+	}
+	if ctx.Err() != nil {
+		return result
+	}
+	if s.Maintenance != nil {
+		if window, active := s.Maintenance.Active(req, result.Time); active {
+			result.Maintenance = window.Name
+		}
+	}
+	if s.Telemetry != nil {
+		s.Telemetry.observe(ctx, result)
+	}
+
+	s.Store.Record(result)
+	s.markCheck(result.Time)
+	stats := s.Store.Get(req.Url)
+	for _, sink := range s.Sinks {
+		sink.Observe(result, stats)
+	}
+
+	if req.SLO > 0 {
+		s.checkErrorBudget(req, result)
+	}
+	if s.Regions != nil {
+		s.recordRegion(s.Region, probeResult(result))
+	}
+
+	previous, state := s.State.Update(result)
+	if state.Status != previous.Status {
+		s.Logger.Printf("STATE CHANGE: %s (%s) %s -> %s", req.Name, req.Url, previous.Status, state.Status)
+	}
+
+	alert := Alert{
+		Endpoint:            req,
+		Previous:            previous.Status,
+		Current:             state.Status,
+		Result:              result,
+		ConsecutiveFailures: state.ConsecutiveFailures,
+	}
+	switch {
+	case state.Flapping && !previous.Flapping:
+		alert.Reason = fmt.Sprintf("started flapping (%.1f%% state change), alerts are suppressed until it stabilizes", state.StateChange)
+		s.Logger.Printf("FLAPPING: %s (%s) %s", req.Name, req.Url, alert.Reason)
+		s.sendAlert(alert)
+	case previous.Flapping && !state.Flapping:
+		alert.Reason = fmt.Sprintf("stopped flapping (%.1f%% state change), now %s", state.StateChange, state.Status)
+		s.Logger.Printf("FLAPPING: %s (%s) %s", req.Name, req.Url, alert.Reason)
+		s.sendAlert(alert)
+	case state.Flapping:
+		// Transitions of a flapping endpoint are not alerted
+	case state.Status != previous.Status:
+		s.sendAlert(alert)
+	case req.DetectChanges && previous.Checksum != "" && state.Checksum != previous.Checksum:
+		alert.Reason = changeReason(previous.Checksum, state.Checksum)
+		s.Logger.Printf("CHANGED: %s (%s) %s", req.Name, req.Url, alert.Reason)
+		s.sendAlert(alert)
+	case state.Degraded && !previous.Degraded:
+		alert.Current = StatusDegraded
+		alert.Reason = "latency anomaly, " + anomalyReason(result.Latency, state.Baseline)
+		s.Logger.Printf("DEGRADED: %s (%s) %s", req.Name, req.Url, alert.Reason)
+		s.sendAlert(alert)
+	case previous.Degraded && !state.Degraded:
+		alert.Previous = StatusDegraded
+		alert.Reason = "latency back to baseline, " + anomalyReason(result.Latency, state.Baseline)
+		s.Logger.Printf("RECOVERED: %s (%s) %s", req.Name, req.Url, alert.Reason)
+		s.sendAlert(alert)
+	case state.Status == StatusDown && s.Alerter != nil && result.Maintenance == "":
+		s.Alerter.Remind(alert)
+	}
+	return result
+}
+
+// sendAlert passes an alert to the Alerter, which holds it back if the
+// check ran during a maintenance window
+func (s *Scheduler) sendAlert(alert Alert) {
+	if s.Alerter == nil {
+		return
+	}
+	if alert.Result.Maintenance != "" {
+		s.Logger.Printf("Alert for %s (%s) suppressed by maintenance window '%s'", alert.Endpoint.Name, alert.Endpoint.Url, alert.Result.Maintenance)
+		s.Alerter.Hold(alert)
+		return
+	}
+	s.Alerter.Send(alert)
+}
+
+// checkErrorBudget warns once when the error budget of an endpoint becomes
+// exhausted and again after it has recovered and is exhausted anew
+func (s *Scheduler) checkErrorBudget(req Configuration, result Result) {
+	budget, exhausted := budgetExhausted(ErrorBudgets(req, s.Store.Get(req.Url), result.Time))
+
+	s.budgetMu.Lock()
+	if s.exhausted == nil {
+		s.exhausted = make(map[string]bool)
+	}
+	wasExhausted := s.exhausted[req.Url]
+	s.exhausted[req.Url] = exhausted
+	s.budgetMu.Unlock()
+
+	if !exhausted || wasExhausted {
+		return
+	}
+
+	reason := fmt.Sprintf("error budget for SLO %g%% exhausted over %s (burn rate %.2fx)", req.SLO, budget.Window, budget.BurnRate)
+	s.Logger.Printf("WARNING: %s (%s) %s", req.Name, req.Url, reason)
+	state := s.State.Get(req.Url)
+	s.sendAlert(Alert{
+		Endpoint:            req,
+		Previous:            state.Status,
+		Current:             state.Status,
+		Result:              result,
+		ConsecutiveFailures: state.ConsecutiveFailures,
+		Reason:              reason,
+	})
+}
+
+// writeSummary flushes the availability report of every endpoint to the sinks
+func (s *Scheduler) writeSummary() {
+	s.markCycle()
+	cycle := s.cycle()
+	for _, sink := range s.Sinks {
+		if err := sink.Flush(cycle); err != nil {
+			s.Logger.Printf("Error writing results to %T: %v", sink, err)
+		}
+	}
+}
+
+// cycle returns the current availability and state of every endpoint
+func (s *Scheduler) cycle() Cycle {
+	return Cycle{
+		Endpoints:    s.Endpoints(),
+		Availability: s.Store.Snapshot(),
+		States:       s.State.Snapshot(),
+	}
+}
+
+// probe runs the checker, retrying failed checks as configured on the
+// endpoint. The result of the last attempt is returned.
+func (s *Scheduler) probe(ctx context.Context, req Configuration) Result {
+	result := s.attempt(ctx, req)
+	for retry := 1; !result.Up && retry <= req.Retries && ctx.Err() == nil; retry++ {
+		timer := time.NewTimer(req.retryBackoff(retry))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result
+		}
+
+		result = s.attempt(ctx, req)
+		result.Retries = retry
+	}
+	return result
+}
+
+// attempt runs the checker once, waiting for a free slot when Concurrency is
+// set. The slot is released between retries.
+func (s *Scheduler) attempt(ctx context.Context, req Configuration) Result {
+	s.slotsOnce.Do(func() {
+		if s.Concurrency > 0 {
+			s.slots = make(chan struct{}, s.Concurrency)
+		}
+	})
+
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-ctx.Done():
+			return Result{Endpoint: req, Time: time.Now(), Err: ctx.Err()}
+		}
+	}
+	return s.Checker.Check(ctx, req)
+}
+
+// saveState persists availability to the state file, if configured
+func (s *Scheduler) saveState() {
+	if s.StateFile == "" {
+		return
+	}
+	if err := s.Store.SaveFile(s.StateFile); err != nil {
+		s.Logger.Printf("Error saving state: %v", err)
+	}
+}
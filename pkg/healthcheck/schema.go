@@ -0,0 +1,363 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// httpMethods are the methods accepted for HTTP checks and transaction steps
+var httpMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "TRACE", "CONNECT"}
+
+// ConfigError is a problem found in a configuration document, with its line
+// and column when known
+type ConfigError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ConfigErrors lists every problem found in a configuration document
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = "  " + err.Error()
+	}
+	return fmt.Sprintf("%d problems in the configuration:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// addf records a problem without a position, found after the document was
+// decoded
+func (e *ConfigErrors) addf(format string, args ...any) {
+	*e = append(*e, ConfigError{Message: fmt.Sprintf(format, args...)})
+}
+
+// schemaValidator checks a configuration document against the configuration
+// types before it is decoded: unknown fields, negative durations, invalid
+// methods and malformed URLs. Every problem is collected rather than the first.
+type schemaValidator struct {
+	errors ConfigErrors
+	// positions is unset for documents converted from JSON or TOML, whose
+	// lines don't match the original file
+	positions bool
+	// defaults is the defaults section, whose settings endpoints inherit
+	defaults *yaml.Node
+}
+
+// validateSchema checks the root node of a configuration document
+func validateSchema(root *yaml.Node, positions bool) ConfigErrors {
+	v := &schemaValidator{positions: positions, defaults: mappingValue(root, "defaults")}
+	if root.Kind == yaml.SequenceNode {
+		v.walk(root, reflect.TypeOf([]Configuration{}), "endpoints")
+	} else {
+		v.walk(root, reflect.TypeOf(Config{}), "")
+	}
+	return v.errors
+}
+
+// addf records a problem at a node
+func (v *schemaValidator) addf(node *yaml.Node, path, format string, args ...any) {
+	err := ConfigError{Message: fmt.Sprintf(format, args...)}
+	if path != "" {
+		err.Message = path + ": " + err.Message
+	}
+	if v.positions {
+		err.Line, err.Column = node.Line, node.Column
+	}
+	v.errors = append(v.errors, err)
+}
+
+// walk checks a node against the type it is decoded into. Type mismatches are
+// left to the decoder.
+func (v *schemaValidator) walk(node *yaml.Node, t reflect.Type, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		var d time.Duration
+		if node.Decode(&d) == nil && d < 0 {
+			v.addf(node, path, "negative duration %s", node.Value)
+		}
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		v.walkStruct(node, t, path)
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			v.walk(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.walk(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+	}
+}
+
+// walkStruct checks the keys of a mapping against the fields of a struct
+func (v *schemaValidator) walkStruct(node *yaml.Node, t reflect.Type, path string) {
+	fields := yamlFields(t)
+	switch {
+	case t == reflect.TypeOf(Configuration{}) && path == "defaults":
+		if value := mappingValue(node, "method"); value != nil {
+			v.checkMethod(value, path)
+		}
+	case t == reflect.TypeOf(Configuration{}):
+		path = v.checkEndpoint(node, path)
+	case t == reflect.TypeOf(Step{}):
+		v.checkStep(node, path)
+	}
+	v.walkFields(node, fields, path)
+}
+
+// walkFields checks the keys of a mapping, and of the mappings it merges,
+// against the fields of a struct
+func (v *schemaValidator) walkFields(node *yaml.Node, fields map[string]reflect.Type, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Tag == "!!merge" {
+			// Keys inherited through <<: *anchor
+			sources := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				sources = value.Content
+			}
+			for _, source := range sources {
+				v.walkFields(source, fields, path)
+			}
+			continue
+		}
+		field, known := fields[key.Value]
+		switch {
+		case known:
+			v.walk(value, field, joinPath(path, key.Value))
+		case path == "" && strings.HasPrefix(key.Value, "x-"):
+			// Top-level extension fields hold YAML anchors
+		default:
+			message := fmt.Sprintf("unknown field '%s'", key.Value)
+			if suggestion := closestField(key.Value, fields); suggestion != "" {
+				message += fmt.Sprintf(", did you mean '%s'?", suggestion)
+			}
+			v.addf(key, path, "%s", message)
+		}
+	}
+}
+
+// checkEndpoint validates the name, URL and method of an endpoint, with the
+// settings it inherits from the defaults, and returns the path its problems
+// are reported under
+func (v *schemaValidator) checkEndpoint(node *yaml.Node, path string) string {
+	setting := func(key string) *yaml.Node {
+		if value := mappingValue(node, key); value != nil || v.defaults == nil {
+			return value
+		}
+		return mappingValue(v.defaults, key)
+	}
+
+	name := mappingValue(node, "name")
+	if name == nil || name.Value == "" {
+		v.addf(node, path, "missing name")
+	} else {
+		path = fmt.Sprintf("endpoint '%s'", name.Value)
+	}
+
+	checkType := ""
+	if value := setting("type"); value != nil {
+		checkType = value.Value
+	}
+	if value := setting("url"); value != nil {
+		if checkType == "" || checkType == TypeHTTP {
+			v.checkHTTPURL(value, path)
+		} else if _, err := url.Parse(value.Value); err != nil {
+			v.addf(value, path, "invalid url: %v", err)
+		}
+	} else if checkType != TypeTransaction && checkType != TypeExec {
+		v.addf(node, path, "missing url")
+	}
+	if value := mappingValue(node, "method"); value != nil {
+		v.checkMethod(value, path)
+	}
+	return path
+}
+
+// checkStep validates the URL and method of a transaction step
+func (v *schemaValidator) checkStep(node *yaml.Node, path string) {
+	if value := mappingValue(node, "url"); value != nil {
+		v.checkHTTPURL(value, path)
+	} else {
+		v.addf(node, path, "missing url")
+	}
+	if value := mappingValue(node, "method"); value != nil {
+		v.checkMethod(value, path)
+	}
+}
+
+// checkHTTPURL reports URLs that aren't absolute http or https URLs. URLs
+// with environment variables or transaction variables are only checked once
+// expanded.
+func (v *schemaValidator) checkHTTPURL(node *yaml.Node, path string) {
+	if strings.Contains(node.Value, "${") || strings.Contains(node.Value, "{{") {
+		return
+	}
+	u, err := url.Parse(node.Value)
+	switch {
+	case err != nil:
+		v.addf(node, path, "invalid url: %v", err)
+	case u.Scheme != "http" && u.Scheme != "https":
+		v.addf(node, path, "invalid url '%s', expected an http:// or https:// URL", node.Value)
+	case u.Host == "":
+		v.addf(node, path, "invalid url '%s', missing host", node.Value)
+	}
+}
+
+// checkMethod reports methods other than the standard HTTP methods
+func (v *schemaValidator) checkMethod(node *yaml.Node, path string) {
+	if node.Value == "" || slices.Contains(httpMethods, node.Value) {
+		return
+	}
+	message := fmt.Sprintf("invalid method '%s', expected one of %s", node.Value, strings.Join(httpMethods, ", "))
+	if upper := strings.ToUpper(node.Value); slices.Contains(httpMethods, upper) {
+		message = fmt.Sprintf("invalid method '%s', did you mean '%s'?", node.Value, upper)
+	}
+	v.addf(node, path, "%s", message)
+}
+
+// typeErrors converts the errors of the YAML decoder, which mention their
+// line, into ConfigErrors
+func typeErrors(err *yaml.TypeError, positions bool) ConfigErrors {
+	var errors ConfigErrors
+	for _, message := range err.Errors {
+		var e ConfigError
+		if n, _ := fmt.Sscanf(message, "line %d:", &e.Line); n == 1 {
+			message = strings.TrimSpace(message[strings.Index(message, ":")+1:])
+		}
+		if !positions {
+			e.Line = 0
+		}
+		e.Message = message
+		errors = append(errors, e)
+	}
+	return errors
+}
+
+// tidyErrors orders problems by their position in the document and drops
+// duplicates, such as a setting inherited by several endpoints
+func tidyErrors(errors ConfigErrors) ConfigErrors {
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Line != errors[j].Line {
+			return errors[i].Line < errors[j].Line
+		}
+		return errors[i].Column < errors[j].Column
+	})
+	return slices.Compact(errors)
+}
+
+// yamlFields returns the types of the fields of a struct by YAML key,
+// including the fields of inlined structs
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+		case strings.Contains(options, "inline"):
+			for key, inlined := range yamlFields(field.Type) {
+				fields[key] = inlined
+			}
+		case name == "":
+			fields[strings.ToLower(field.Name)] = field.Type
+		default:
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// joinPath appends a key to a dotted path, after a colon following an
+// endpoint name
+func joinPath(path, key string) string {
+	switch {
+	case path == "":
+		return key
+	case strings.HasSuffix(path, "'"):
+		return path + ": " + key
+	default:
+		return path + "." + key
+	}
+}
+
+// closestField returns the known field closest to an unknown one, if it is
+// likely a typo
+func closestField(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if d := editDistance(key, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings, counting a
+// transposition of adjacent characters as one edit
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,47 @@
+package healthcheck
+
+import "fmt"
+
+// Endpoint severities, from the most to the least urgent
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// severityRanks orders the severities
+var severityRanks = map[string]int{
+	SeverityCritical: 3,
+	SeverityWarning:  2,
+	SeverityInfo:     1,
+}
+
+// validateSeverity checks that a severity is critical, warning or info
+func validateSeverity(severity string) error {
+	if _, ok := severityRanks[severity]; !ok {
+		return fmt.Errorf("invalid severity %q, expected critical, warning or info", severity)
+	}
+	return nil
+}
+
+// severity returns the severity of the endpoint. Endpoints without a severity
+// are critical so every outage counts unless stated otherwise.
+func (c Configuration) severity() string {
+	if c.Severity == "" {
+		return SeverityCritical
+	}
+	return c.Severity
+}
+
+// AtLeast reports whether the endpoint's severity is at least the given one
+func (c Configuration) AtLeast(severity string) bool {
+	return severityRanks[c.severity()] >= severityRanks[severity]
+}
+
+// severityDetail formats the severity of an endpoint, if set
+func severityDetail(req Configuration) string {
+	if req.Severity == "" {
+		return ""
+	}
+	return ", Severity: " + req.Severity
+}
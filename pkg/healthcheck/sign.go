@@ -0,0 +1,122 @@
+package healthcheck
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Signature defaults
+const (
+	DefaultSignatureHeader   = "X-Signature"
+	DefaultSignatureTemplate = "{{body}}"
+)
+
+// signatureHashes are the hash functions HMAC signatures can use
+var signatureHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Signature signs the requests of an endpoint with an HMAC, for services
+// that only accept signed requests such as webhook receivers. Template is the
+// string to sign, where {{method}}, {{host}}, {{path}}, {{query}}, {{body}}
+// and {{timestamp}} (Unix seconds) are replaced with the values of the
+// request.
+type Signature struct {
+	// Algorithm is sha1, sha256 (the default) or sha512
+	Algorithm string `yaml:"algorithm,omitempty"`
+	Secret    string `yaml:"secret,omitempty"`
+	SecretEnv string `yaml:"secret_env,omitempty"`
+	// Header receives the signature, X-Signature by default, after Prefix, e.g. "sha256="
+	Header   string `yaml:"header,omitempty"`
+	Prefix   string `yaml:"prefix,omitempty"`
+	Template string `yaml:"template,omitempty"`
+	// Encoding of the signature, hex (the default) or base64
+	Encoding string `yaml:"encoding,omitempty"`
+	// TimestampHeader, when set, receives the timestamp that was signed
+	TimestampHeader string `yaml:"timestamp_header,omitempty"`
+}
+
+// apply signs the request, whose body is passed separately as it is only
+// readable once
+func (s *Signature) apply(httpReq *http.Request, body string, now time.Time) error {
+	secret, err := credential(s.Secret, s.SecretEnv)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	message, err := substitute(s.template(), map[string]string{
+		"method":    httpReq.Method,
+		"host":      httpReq.URL.Host,
+		"path":      httpReq.URL.EscapedPath(),
+		"query":     httpReq.URL.RawQuery,
+		"body":      body,
+		"timestamp": timestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid signature template: %v", err)
+	}
+
+	mac := hmac.New(signatureHashes[s.algorithm()], []byte(secret))
+	mac.Write([]byte(message))
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if s.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	header := s.Header
+	if header == "" {
+		header = DefaultSignatureHeader
+	}
+	httpReq.Header.Set(header, s.Prefix+signature)
+	if s.TimestampHeader != "" {
+		httpReq.Header.Set(s.TimestampHeader, timestamp)
+	}
+	return nil
+}
+
+// validate checks the algorithm, encoding and template of the signature
+func (s *Signature) validate() error {
+	if _, ok := signatureHashes[s.algorithm()]; !ok {
+		return fmt.Errorf("unsupported signature algorithm %q, expected sha1, sha256 or sha512", s.Algorithm)
+	}
+	switch s.Encoding {
+	case "", "hex", "base64":
+	default:
+		return fmt.Errorf("unsupported signature encoding %q, expected hex or base64", s.Encoding)
+	}
+	if s.Secret == "" && s.SecretEnv == "" {
+		return fmt.Errorf("signature requires secret or secret_env")
+	}
+	variables := map[string]string{"method": "", "host": "", "path": "", "query": "", "body": "", "timestamp": ""}
+	if _, err := substitute(s.template(), variables); err != nil {
+		return fmt.Errorf("invalid signature template: %v", err)
+	}
+	return nil
+}
+
+// algorithm returns the hash function name, sha256 when unset
+func (s *Signature) algorithm() string {
+	if s.Algorithm == "" {
+		return "sha256"
+	}
+	return s.Algorithm
+}
+
+// template returns the string to sign, the body when unset
+func (s *Signature) template() string {
+	if s.Template == "" {
+		return DefaultSignatureTemplate
+	}
+	return s.Template
+}
@@ -0,0 +1,191 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+)
+
+// ResultSink receives check results and the end of cycle report. The
+// scheduler fans results out to every configured sink, so new export formats
+// only need to implement this interface.
+type ResultSink interface {
+	// Observe is called after every check with its result and the updated
+	// availability of the endpoint
+	Observe(r Result, stats Availability)
+	// Flush is called at every summary interval and when the scheduler stops
+	Flush(c Cycle) error
+}
+
+// Cycle is the state of every monitored endpoint at the end of a cycle
+type Cycle struct {
+	Endpoints []Configuration
+	// Availability and States are keyed by endpoint URL
+	Availability map[string]Availability
+	States       map[string]EndpointState
+}
+
+// ConsoleSink writes one line per check result to Logger and the availability
+// report to Summary
+type ConsoleSink struct {
+	// Logger receives one line per check result. Results are not logged when nil.
+	Logger *log.Logger
+	// Summary receives the availability report. The report is skipped when nil.
+	Summary io.Writer
+}
+
+// Observe logs a single UP or DOWN line for a check result
+func (c *ConsoleSink) Observe(r Result, stats Availability) {
+	if c.Logger == nil {
+		return
+	}
+
+	req := r.Endpoint
+	switch {
+	case r.Err != nil && r.StatusCode == 0 && r.Maintenance != "":
+		c.Logger.Printf("DOWN: %s (%s) - Maintenance: %s%s%s, Error: %v", req.Name, req.Url, r.Maintenance, retryDetail(r), severityDetail(req), r.Err)
+	case r.Err != nil && r.StatusCode == 0:
+		c.Logger.Printf("DOWN: %s (%s) - Error: %v%s%s", req.Name, req.Url, r.Err, retryDetail(r), severityDetail(req))
+		c.Logger.Println("Error occurred, check your connection or the target URL.")
+	case r.Up:
+		c.Logger.Printf("UP: %s (%s) - %s", req.Name, req.Url, resultDetail(r))
+	case r.Err != nil:
+		c.Logger.Printf("DOWN: %s (%s) - %s%s, Error: %v", req.Name, req.Url, resultDetail(r), severityDetail(req), r.Err)
+	default:
+		c.Logger.Printf("DOWN: %s (%s) - %s%s", req.Name, req.Url, resultDetail(r), severityDetail(req))
+	}
+}
+
+// Flush writes the availability report of every endpoint and tag, followed by
+// the endpoints that are skipped, flapping or degraded
+func (c *ConsoleSink) Flush(cycle Cycle) error {
+	if c.Summary == nil {
+		return nil
+	}
+
+	WriteSummary(c.Summary, cycle.Endpoints, cycle.Availability)
+	WriteTagSummary(c.Summary, cycle.Endpoints, cycle.Availability)
+	for _, req := range cycle.Endpoints {
+		if state := cycle.States[req.Url]; state.Skipped {
+			parent, _ := findEndpoint(cycle.Endpoints, req.DependsOn)
+			fmt.Fprintf(c.Summary, "%s (%s) is SKIPPED (depends on %s, which is %s)\n", req.Name, req.Url, req.DependsOn, cycle.States[parent.Url].Reported())
+		} else if state.Flapping {
+			fmt.Fprintf(c.Summary, "%s (%s) is FLAPPING (%.1f%% state change over the last %d checks)\n", req.Name, req.Url, state.StateChange, FlapHistory)
+		} else if state.Reported() == StatusDegraded {
+			fmt.Fprintf(c.Summary, "%s (%s) is DEGRADED (latency above its baseline of %.1fms ± %.1fms)\n", req.Name, req.Url, state.Baseline.Mean, state.Baseline.StdDev())
+		}
+	}
+	return nil
+}
+
+// JSONSink writes check results and availability summaries as structured records
+type JSONSink struct {
+	Logger *slog.Logger
+}
+
+// Observe writes a "check" record for a check result
+func (j *JSONSink) Observe(r Result, stats Availability) {
+	req := r.Endpoint
+	attrs := []any{
+		"name", req.Name,
+		"url", req.Url,
+		"status", r.Status(),
+		"latency_ms", milliseconds(r.Latency),
+		"status_code", r.StatusCode,
+	}
+	if r.Maintenance != "" {
+		attrs = append(attrs, "maintenance", r.Maintenance)
+	}
+	if req.Severity != "" {
+		attrs = append(attrs, "severity", req.Severity)
+	}
+	if r.Retries > 0 {
+		attrs = append(attrs, "retries", r.Retries)
+	}
+	if r.RemoteAddr != "" {
+		attrs = append(attrs, "remote_addr", r.RemoteAddr, "ip_version", r.IPVersion())
+	}
+	if r.Protocol != "" {
+		attrs = append(attrs, "protocol", r.Protocol)
+	}
+	if r.Offset != 0 {
+		attrs = append(attrs, "offset_ms", milliseconds(r.Offset))
+	}
+	if r.Phases.TTFB > 0 {
+		attrs = append(attrs, "phases", r.Phases.summary(), "size_bytes", r.Size, "throughput_bps", r.Throughput())
+	}
+	if r.compressed() {
+		attrs = append(attrs, "encoding", r.Encoding)
+	}
+	if r.CompressedSize > 0 {
+		attrs = append(attrs, "compressed_size_bytes", r.CompressedSize)
+	}
+	if cause := r.Cause(); cause != "" {
+		attrs = append(attrs, "cause", cause)
+	}
+	if r.Checksum != "" {
+		attrs = append(attrs, "sha256", r.Checksum)
+	}
+	if r.Err != nil {
+		attrs = append(attrs, "error", r.Err.Error())
+	}
+	j.Logger.Info("check", attrs...)
+}
+
+// Flush writes a "summary" record with the report of every endpoint and tag
+func (j *JSONSink) Flush(cycle Cycle) error {
+	j.Logger.Info("summary",
+		"endpoints", Summarize(cycle.Endpoints, cycle.Availability),
+		"tags", SummarizeTags(cycle.Endpoints, cycle.Availability))
+	return nil
+}
+
+// StatusPageSink writes the HTML status page to Dir at every summary
+type StatusPageSink struct {
+	Dir string
+}
+
+// Observe does nothing, the page is only rendered on Flush
+func (p *StatusPageSink) Observe(r Result, stats Availability) {}
+
+// Flush renders the status page
+func (p *StatusPageSink) Flush(cycle Cycle) error {
+	return writeStatusPage(p.Dir, cycle)
+}
+
+// resultDetail formats the status code and latency of a result. Checks that
+// are not HTTP based have no status code.
+func resultDetail(r Result) string {
+	detail := fmt.Sprintf("Latency: %v", r.Latency)
+	if r.StatusCode != 0 {
+		detail = fmt.Sprintf("Status: %d, %s", r.StatusCode, detail)
+	}
+	if r.Slow {
+		detail += " (over the latency threshold)"
+	}
+	if r.Protocol != "" {
+		detail += ", " + r.Protocol
+	}
+	if r.Phases.TTFB > 0 {
+		detail += ", Size: " + sizeDetail(r)
+	}
+	if r.Offset != 0 {
+		detail += fmt.Sprintf(", Offset: %v", r.Offset)
+	}
+	if version := r.IPVersion(); version != 0 {
+		detail += fmt.Sprintf(", IPv%d", version)
+	}
+	if r.Maintenance != "" {
+		detail += fmt.Sprintf(", Maintenance: %s", r.Maintenance)
+	}
+	return detail + retryDetail(r)
+}
+
+// retryDetail formats the number of retries of a result, if any
+func retryDetail(r Result) string {
+	if r.Retries == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", Retries: %d", r.Retries)
+}
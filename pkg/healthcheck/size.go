@@ -0,0 +1,119 @@
+package healthcheck
+
+import (
+	"fmt"
+	"time"
+)
+
+// Throughput returns the download rate of an HTTP check in bytes per second:
+// the response body size over the time from sending the request to reading
+// the last byte, like curl's speed_download. It is zero for checks without a
+// response.
+func (r Result) Throughput() float64 {
+	if r.Phases.TTFB == 0 {
+		return 0
+	}
+	return float64(r.received()) / r.downloadTime().Seconds()
+}
+
+// downloadTime is the time from sending the request to reading the last byte
+func (r Result) downloadTime() time.Duration {
+	return r.Latency + r.Phases.Transfer
+}
+
+// checksSize reports whether the endpoint asserts on the response body size
+func (c Configuration) checksSize() bool {
+	return c.MinSize > 0 || c.MaxSize > 0
+}
+
+// assertSize checks the response body size against the endpoint's size limits
+func assertSize(req Configuration, size int64) error {
+	if req.MinSize > 0 && size < req.MinSize {
+		return fmt.Errorf("response body is %s, expected at least %s", formatBytes(float64(size)), formatBytes(float64(req.MinSize)))
+	}
+	if req.MaxSize > 0 && size > req.MaxSize {
+		return fmt.Errorf("response body is %s, expected at most %s", formatBytes(float64(size)), formatBytes(float64(req.MaxSize)))
+	}
+	return nil
+}
+
+// validateSize checks the size limits of an endpoint
+func (c Configuration) validateSize() error {
+	if c.MinSize < 0 || c.MaxSize < 0 {
+		return fmt.Errorf("negative min_size or max_size")
+	}
+	if c.MinSize > 0 && c.MaxSize > 0 && c.MinSize > c.MaxSize {
+		return fmt.Errorf("min_size %d is greater than max_size %d", c.MinSize, c.MaxSize)
+	}
+	return nil
+}
+
+// TransferStats accumulates the response sizes and download time of an
+// endpoint's HTTP checks. Bytes is the total decompressed size and Received
+// the bytes actually received.
+type TransferStats struct {
+	Bytes    int64
+	Received int64
+	Duration time.Duration
+	Count    int
+	// Compressed counts the compressed responses. CompressedBytes and
+	// DecodedBytes are the sizes before and after decompression of those
+	// whose compressed size is known.
+	Compressed      int
+	CompressedBytes int64
+	DecodedBytes    int64
+}
+
+// Add records the response of a check
+func (s *TransferStats) Add(r Result) {
+	s.Bytes += r.Size
+	s.Received += r.received()
+	s.Duration += r.downloadTime()
+	s.Count++
+	if r.compressed() {
+		s.Compressed++
+	}
+	if r.CompressedSize > 0 {
+		s.CompressedBytes += r.CompressedSize
+		s.DecodedBytes += r.Size
+	}
+}
+
+// CompressionRatio returns the compressed size of the compressed responses
+// as a fraction of their decompressed size, zero when unknown
+func (s TransferStats) CompressionRatio() float64 {
+	if s.DecodedBytes == 0 {
+		return 0
+	}
+	return float64(s.CompressedBytes) / float64(s.DecodedBytes)
+}
+
+// AverageSize returns the mean response body size in bytes
+func (s TransferStats) AverageSize() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Bytes) / float64(s.Count)
+}
+
+// Throughput returns the overall download rate in bytes per second
+func (s TransferStats) Throughput() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Received) / s.Duration.Seconds()
+}
+
+// formatBytes formats a number of bytes with a binary unit, e.g. 12.3KiB
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	unit := 0
+	for n >= 1024 && unit < len(units)-1 {
+		n /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f%s", n, units[unit])
+	}
+	return fmt.Sprintf("%.1f%s", n, units[unit])
+}
@@ -0,0 +1,66 @@
+package healthcheck
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorBudget describes how much of an endpoint's error budget is left over a
+// reporting window
+type ErrorBudget struct {
+	Window string `json:"window"`
+	// Remaining is the fraction of the budget left. It is negative once the
+	// budget is overspent.
+	Remaining float64 `json:"remaining"`
+	// BurnRate is how fast the budget is consumed. At 1 the budget is used up
+	// exactly by the end of the window.
+	BurnRate float64 `json:"burn_rate"`
+	Checks   int     `json:"checks"`
+}
+
+// ErrorBudgets returns the error budget of an endpoint with an SLO over every
+// reporting window that has checks
+func ErrorBudgets(req Configuration, stats Availability, now time.Time) []ErrorBudget {
+	if req.SLO <= 0 || req.SLO >= 100 {
+		return nil
+	}
+	allowed := 1 - req.SLO/100
+
+	var budgets []ErrorBudget
+	for _, window := range Windows {
+		success, failure := stats.Windows.Counts(window.Duration, now)
+		total := success + failure
+		if total == 0 {
+			continue
+		}
+
+		burnRate := float64(failure) / float64(total) / allowed
+		budgets = append(budgets, ErrorBudget{
+			Window:    window.Label,
+			Remaining: 1 - burnRate,
+			BurnRate:  burnRate,
+			Checks:    total,
+		})
+	}
+	return budgets
+}
+
+// budgetExhausted reports whether the budget over the longest reporting
+// window, which is treated as the SLO period, is used up
+func budgetExhausted(budgets []ErrorBudget) (ErrorBudget, bool) {
+	if len(budgets) == 0 {
+		return ErrorBudget{}, false
+	}
+	longest := budgets[len(budgets)-1]
+	return longest, longest.Remaining <= 0
+}
+
+// formatErrorBudgets formats the remaining budget and burn rate per window
+func formatErrorBudgets(budgets []ErrorBudget) string {
+	parts := make([]string, len(budgets))
+	for i, budget := range budgets {
+		parts[i] = fmt.Sprintf("%s %.0f%% left (burn rate %.2fx)", budget.Window, budget.Remaining*100, budget.BurnRate)
+	}
+	return strings.Join(parts, ", ")
+}
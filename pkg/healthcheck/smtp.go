@@ -0,0 +1,159 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// TypeSMTP is a mail server check
+const TypeSMTP = "smtp"
+
+// SMTPChecker checks that a mail server greets with a 220 banner and accepts
+// EHLO, optionally upgrading the session with STARTTLS. smtps:// URLs use
+// implicit TLS.
+type SMTPChecker struct {
+	// LatencyThreshold is the maximum latency of the whole session for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the session unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeSMTP, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewSMTPChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewSMTPChecker returns an SMTPChecker using the given latency threshold and default timeout
+func NewSMTPChecker(latencyThreshold, timeout time.Duration) *SMTPChecker {
+	return &SMTPChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check greets the server, sends EHLO and QUIT, and measures the session latency
+func (c *SMTPChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	implicitTLS := strings.HasPrefix(req.Url, "smtps://")
+	defaultPort := "25"
+	if implicitTLS {
+		defaultPort = "465"
+	}
+	address := serviceAddress(req.Url, defaultPort)
+	host, _, _ := net.SplitHostPort(address)
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+
+	startTime := time.Now()
+	conn, err := c.session(ctx, req, address, host, implicitTLS, &result)
+	result.Latency = time.Since(startTime)
+	if conn != nil {
+		result.RemoteAddr = conn.RemoteAddr().String()
+		conn.Close()
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// session runs the SMTP conversation, recording the last reply code in result
+func (c *SMTPChecker) session(ctx context.Context, req Configuration, address, host string, implicitTLS bool, result *Result) (net.Conn, error) {
+	conn, err := dialService(ctx, req, address, implicitTLS)
+	if err != nil {
+		return nil, err
+	}
+	text := textproto.NewConn(conn)
+
+	// The banner is what body assertions are matched against
+	code, banner, err := text.ReadResponse(220)
+	result.StatusCode = code
+	if err != nil {
+		return conn, fmt.Errorf("unexpected banner: %v", err)
+	}
+	if err := assertBody(req, []byte(banner)); err != nil {
+		return conn, err
+	}
+
+	extensions, err := ehlo(text, result)
+	if err != nil {
+		return conn, err
+	}
+
+	if req.StartTLS && !implicitTLS {
+		if !extensions["STARTTLS"] {
+			return conn, fmt.Errorf("server does not offer STARTTLS")
+		}
+		if err := smtpCommand(text, result, 220, "STARTTLS"); err != nil {
+			return conn, err
+		}
+		tlsConn, err := startTLS(conn, req, host)
+		if err != nil {
+			return conn, err
+		}
+		conn = tlsConn
+		text = textproto.NewConn(conn)
+		if _, err := ehlo(text, result); err != nil {
+			return conn, err
+		}
+	}
+
+	return conn, smtpCommand(text, result, 221, "QUIT")
+}
+
+// ehlo greets the server and returns the extensions it advertises
+func ehlo(text *textproto.Conn, result *Result) (map[string]bool, error) {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		name = "localhost"
+	}
+	id, err := text.Cmd("EHLO %s", name)
+	if err != nil {
+		return nil, err
+	}
+	text.StartResponse(id)
+	defer text.EndResponse(id)
+
+	code, message, err := text.ReadResponse(250)
+	result.StatusCode = code
+	if err != nil {
+		return nil, fmt.Errorf("EHLO rejected: %v", err)
+	}
+
+	extensions := make(map[string]bool)
+	for _, line := range strings.Split(message, "\n")[1:] {
+		if keyword, _, _ := strings.Cut(line, " "); keyword != "" {
+			extensions[strings.ToUpper(keyword)] = true
+		}
+	}
+	return extensions, nil
+}
+
+// smtpCommand sends a command and expects the given reply code
+func smtpCommand(text *textproto.Conn, result *Result, expectCode int, command string) error {
+	id, err := text.Cmd("%s", command)
+	if err != nil {
+		return err
+	}
+	text.StartResponse(id)
+	defer text.EndResponse(id)
+
+	code, _, err := text.ReadResponse(expectCode)
+	result.StatusCode = code
+	if err != nil {
+		return fmt.Errorf("%s rejected: %v", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// SRVDiscoverer synthesizes an endpoint for every target of a DNS SRV
+// record, e.g. _http._tcp.api.example.com. Targets of _http and _https
+// services are checked over HTTP(S), all others over TCP.
+type SRVDiscoverer struct {
+	// Service is the full SRV name to resolve
+	Service string
+	// Path is the HTTP path checked on _http and _https targets
+	Path string
+	// Resolver is the DNS server to query as host[:port]. The system resolver when empty.
+	Resolver string
+}
+
+// NewSRVDiscoverer returns an SRVDiscoverer resolving the given SRV name
+func NewSRVDiscoverer(service string) *SRVDiscoverer {
+	return &SRVDiscoverer{Service: strings.TrimSuffix(service, "."), Path: "/"}
+}
+
+// Name identifies the discoverer in logs
+func (d *SRVDiscoverer) Name() string {
+	return "SRV " + d.Service
+}
+
+// Discover resolves the SRV name and returns an endpoint per host:port
+func (d *SRVDiscoverer) Discover(ctx context.Context) ([]Configuration, error) {
+	_, records, err := dnsResolver(d.Resolver).LookupSRV(ctx, "", "", d.Service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve SRV record %s: %v", d.Service, err)
+	}
+
+	scheme := srvScheme(d.Service)
+	endpoints := make([]Configuration, 0, len(records))
+	for _, srv := range records {
+		address := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+		req := Configuration{
+			Name: fmt.Sprintf("%s %s", d.Service, address),
+			Tags: []string{"srv", "srv:" + d.Service},
+		}
+		if scheme != "" {
+			path := d.Path
+			if !strings.HasPrefix(path, "/") {
+				path = "/" + path
+			}
+			req.Url = scheme + "://" + address + path
+		} else {
+			req.Type, req.Url = TypeTCP, "tcp://"+address
+		}
+		endpoints = append(endpoints, req)
+	}
+	return endpoints, nil
+}
+
+// srvScheme returns the URL scheme of an _http or _https SRV name
+func srvScheme(service string) string {
+	label, _, _ := strings.Cut(strings.ToLower(service), ".")
+	switch label {
+	case "_http":
+		return "http"
+	case "_https":
+		return "https"
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package healthcheck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// TypeSSH is an SSH server check
+const TypeSSH = "ssh"
+
+// maxBannerLines bounds the lines a server may send before its version
+const maxBannerLines = 20
+
+// errKeyExchanged stops the SSH handshake once the host key was received
+var errKeyExchanged = errors.New("key exchange completed")
+
+// SSHChecker checks that an SSH server sends a valid version banner,
+// optionally completing the key exchange. It never authenticates.
+type SSHChecker struct {
+	// LatencyThreshold is the maximum latency for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the check unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeSSH, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewSSHChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewSSHChecker returns an SSHChecker using the given latency threshold and default timeout
+func NewSSHChecker(latencyThreshold, timeout time.Duration) *SSHChecker {
+	return &SSHChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check reads the version banner of the server and measures the latency
+// until it was received, or until the key exchange completed
+func (c *SSHChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+	address := serviceAddress(req.Url, "22")
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+
+	startTime := time.Now()
+	conn, err := dialService(ctx, req, address, false)
+	if err != nil {
+		result.Latency = time.Since(startTime)
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	result.RemoteAddr = conn.RemoteAddr().String()
+
+	var banner string
+	if req.KeyExchange {
+		banner, err = keyExchange(conn, address)
+	} else {
+		banner, err = readBanner(bufio.NewReader(conn))
+	}
+	result.Latency = time.Since(startTime)
+	result.Protocol = banner
+	if err == nil {
+		err = assertBody(req, []byte(banner))
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// readBanner returns the version line of the server, skipping the lines
+// servers may send before it
+func readBanner(reader *bufio.Reader) (string, error) {
+	for i := 0; i < maxBannerLines; i++ {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "SSH-") {
+			if !strings.HasPrefix(line, "SSH-2.0-") && !strings.HasPrefix(line, "SSH-1.99-") {
+				return line, fmt.Errorf("unsupported SSH protocol version %q", line)
+			}
+			return line, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("no SSH banner received: %v", err)
+		}
+	}
+	return "", fmt.Errorf("no SSH banner in the first %d lines", maxBannerLines)
+}
+
+// keyExchange runs the SSH handshake until the server proved its host key,
+// and returns the banner the server sent
+func keyExchange(conn net.Conn, address string) (string, error) {
+	recorder := &bannerConn{Conn: conn}
+	config := &ssh.ClientConfig{
+		User: "healthcheck",
+		HostKeyCallback: func(string, net.Addr, ssh.PublicKey) error {
+			return errKeyExchanged
+		},
+	}
+	_, _, _, err := ssh.NewClientConn(recorder, address, config)
+	banner, bannerErr := readBanner(bufio.NewReader(bytes.NewReader(recorder.received.Bytes())))
+	if bannerErr != nil {
+		return banner, bannerErr
+	}
+	if !errors.Is(err, errKeyExchanged) {
+		return banner, fmt.Errorf("key exchange failed: %v", err)
+	}
+	return banner, nil
+}
+
+// bannerConn records the start of what the server sends, so the banner
+// consumed by the SSH handshake can be reported
+type bannerConn struct {
+	net.Conn
+	received bytes.Buffer
+}
+
+func (c *bannerConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if remaining := 4096 - c.received.Len(); remaining > 0 {
+		c.received.Write(p[:min(n, remaining)])
+	}
+	return n, err
+}
@@ -0,0 +1,158 @@
+package healthcheck
+
+import (
+	"sync"
+	"time"
+)
+
+// EndpointState is the current state of an endpoint after applying its
+// failure and success thresholds to the raw check results
+type EndpointState struct {
+	Status               Status
+	ConsecutiveFailures  int
+	ConsecutiveSuccesses int
+	// Since is when the endpoint entered its current status
+	Since time.Time
+	// Flapping is set while the endpoint oscillates between UP and DOWN.
+	// StateChange is the weighted percent of state changes it is based on.
+	Flapping    bool
+	StateChange float64
+	// Degraded is set while an UP endpoint with anomaly detection is
+	// consistently slower than its latency Baseline
+	Degraded bool
+	Baseline LatencyBaseline
+	// Skipped is set while the endpoint isn't checked because an endpoint it
+	// depends on is DOWN
+	Skipped bool
+	// Checksum is the SHA-256 of the last successful response body of an
+	// endpoint detecting content changes
+	Checksum string
+
+	history flapHistory
+}
+
+// Reported returns the status shown in reports: SKIPPED while the endpoint
+// isn't checked, FLAPPING while it is flapping, DEGRADED while it is UP with
+// anomalous latency, its status otherwise
+func (s EndpointState) Reported() Status {
+	if s.Skipped {
+		return StatusSkipped
+	}
+	if s.Flapping {
+		return StatusFlapping
+	}
+	if s.Degraded && s.Status == StatusUp {
+		return StatusDegraded
+	}
+	return s.Status
+}
+
+// StateTracker is a state machine tracking the status of every endpoint. It
+// is safe for concurrent use.
+type StateTracker struct {
+	mu     sync.RWMutex
+	states map[string]*EndpointState
+}
+
+// NewStateTracker returns a StateTracker with every endpoint in the UNKNOWN state
+func NewStateTracker() *StateTracker {
+	return &StateTracker{states: make(map[string]*EndpointState)}
+}
+
+// Update applies a check result to the state of its endpoint. The status only
+// changes once the endpoint's failure or success threshold is reached. It
+// returns the previous and the new state.
+func (t *StateTracker) Update(r Result) (EndpointState, EndpointState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.lookup(r.Endpoint.Url)
+	previous := *state
+	state.Skipped = false
+	state.updateFlapping(r.Up)
+	if r.Up && r.Checksum != "" {
+		state.Checksum = r.Checksum
+	}
+
+	if r.Up {
+		state.ConsecutiveSuccesses++
+		state.ConsecutiveFailures = 0
+		if state.Status != StatusUp && state.ConsecutiveSuccesses >= r.Endpoint.successThreshold() {
+			state.Status, state.Since = StatusUp, r.Time
+		}
+		if r.Endpoint.Anomaly != nil {
+			state.updateDegraded(*r.Endpoint.Anomaly, r.Latency)
+		}
+	} else {
+		state.ConsecutiveFailures++
+		state.ConsecutiveSuccesses = 0
+		if state.Status != StatusDown && state.ConsecutiveFailures >= r.Endpoint.failureThreshold() {
+			state.Status, state.Since = StatusDown, r.Time
+			// The outage supersedes the degradation
+			state.Degraded, state.Baseline.anomalies = false, 0
+		}
+	}
+
+	return previous, *state
+}
+
+// Skip marks the endpoint with the given URL as skipped until its next
+// Update and returns its previous state
+func (t *StateTracker) Skip(url string) EndpointState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.lookup(url)
+	previous := *state
+	state.Skipped = true
+	return previous
+}
+
+// Get returns the current state of the endpoint with the given URL
+func (t *StateTracker) Get(url string) EndpointState {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if state, exists := t.states[url]; exists {
+		return *state
+	}
+	return EndpointState{Status: StatusUnknown}
+}
+
+// Snapshot returns the current state of every endpoint, keyed by URL
+func (t *StateTracker) Snapshot() map[string]EndpointState {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	snapshot := make(map[string]EndpointState, len(t.states))
+	for url, state := range t.states {
+		snapshot[url] = *state
+	}
+	return snapshot
+}
+
+// Retain drops the state of every URL not in the given endpoints
+func (t *StateTracker) Retain(endpoints []Configuration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keep := make(map[string]bool, len(endpoints))
+	for _, req := range endpoints {
+		keep[req.Url] = true
+	}
+	for url := range t.states {
+		if !keep[url] {
+			delete(t.states, url)
+		}
+	}
+}
+
+// lookup returns the state for a URL, creating it if needed. Callers must hold the lock.
+func (t *StateTracker) lookup(url string) *EndpointState {
+	state, exists := t.states[url]
+	if !exists {
+		state = &EndpointState{Status: StatusUnknown}
+		t.states[url] = state
+	}
+	return state
+}
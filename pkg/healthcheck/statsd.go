@@ -0,0 +1,77 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultStatsDPrefix is prepended to every StatsD metric name
+const DefaultStatsDPrefix = "healthcheck."
+
+// StatsD emits check results as StatsD metrics over UDP in the DogStatsD
+// format, tagged with the endpoint name, domain and tags:
+//
+//	healthcheck.up       gauge, 1 or 0
+//	healthcheck.latency  timing in milliseconds
+//	healthcheck.checks   counter tagged with result:up|down|slow|maintenance
+//	healthcheck.failures counter tagged with cause:dns|connection_refused|tls|...
+type StatsD struct {
+	// Prefix is prepended to every metric name
+	Prefix string
+
+	conn net.Conn
+}
+
+// NewStatsD returns a StatsD sending metrics to the given host:port
+func NewStatsD(addr string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at '%s': %v", addr, err)
+	}
+	return &StatsD{Prefix: DefaultStatsDPrefix, conn: conn}, nil
+}
+
+// Observe sends the metrics of a check result in a single datagram. Send
+// errors are ignored as StatsD delivery is best effort.
+func (s *StatsD) Observe(r Result, stats Availability) {
+	tags := statsdTags(r.Endpoint)
+
+	up, outcome := 0, r.Outcome()
+	if r.Up {
+		up = 1
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sup:%d|g|#%s\n", s.Prefix, up, tags)
+	fmt.Fprintf(&b, "%schecks:1|c|#%s,result:%s\n", s.Prefix, tags, outcome)
+	if cause := r.Cause(); cause != "" {
+		fmt.Fprintf(&b, "%sfailures:1|c|#%s,cause:%s\n", s.Prefix, tags, cause)
+	}
+	if r.Err == nil || r.StatusCode != 0 {
+		fmt.Fprintf(&b, "%slatency:%g|ms|#%s\n", s.Prefix, milliseconds(r.Latency), tags)
+	}
+	s.conn.Write([]byte(strings.TrimSuffix(b.String(), "\n")))
+}
+
+// Flush does nothing, metrics are sent by Observe
+func (s *StatsD) Flush(c Cycle) error {
+	return nil
+}
+
+// Close closes the UDP socket
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+// statsdTags formats the DogStatsD tags of an endpoint
+func statsdTags(req Configuration) string {
+	tags := []string{"endpoint:" + statsdValue(req.Name), "domain:" + statsdValue(req.Domain())}
+	for _, tag := range req.Tags {
+		tags = append(tags, statsdValue(tag))
+	}
+	return strings.Join(tags, ",")
+}
+
+// statsdValue replaces the characters that delimit DogStatsD fields
+var statsdValue = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_", " ", "_").Replace
@@ -0,0 +1,155 @@
+package healthcheck
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sparkline dimensions in pixels
+const (
+	sparklineWidth  = 120
+	sparklineHeight = 24
+)
+
+// statusPageEndpoint is a row of the status page
+type statusPageEndpoint struct {
+	Name         string
+	Url          string
+	Status       Status
+	Availability int
+	Checks       int
+	Sparkline    string
+}
+
+// statusPageGroup holds the endpoints of a single domain
+type statusPageGroup struct {
+	Domain    string
+	Endpoints []statusPageEndpoint
+}
+
+var statusPageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Service Status</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; max-width: 960px; margin: 2em auto; color: #222; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: .3em; }
+table { width: 100%; border-collapse: collapse; }
+td { padding: .5em; border-bottom: 1px solid #eee; }
+.UP { color: #1a7f37; font-weight: bold; }
+.DOWN { color: #cf222e; font-weight: bold; }
+.UNKNOWN { color: #6e7781; font-weight: bold; }
+.FLAPPING { color: #bf8700; font-weight: bold; }
+.DEGRADED { color: #bc4c00; font-weight: bold; }
+.SKIPPED { color: #6e7781; }
+.url { color: #6e7781; font-size: .85em; }
+polyline { fill: none; stroke: #0969da; stroke-width: 1.5; }
+</style>
+</head>
+<body>
+<h1>Service Status</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}</p>
+{{range .Groups}}
+<h2>{{.Domain}}</h2>
+<table>
+{{range .Endpoints}}<tr>
+<td>{{.Name}}<br><span class="url">{{.Url}}</span></td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td>{{if .Checks}}{{.Availability}}% uptime{{else}}no data yet{{end}}</td>
+<td><svg width="` + fmt.Sprint(sparklineWidth) + `" height="` + fmt.Sprint(sparklineHeight) + `"><polyline points="{{.Sparkline}}"/></svg></td>
+</tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteStatusPage renders an HTML status page of every endpoint, grouped by
+// domain, to index.html in dir. The file is replaced atomically so it can be
+// served while being regenerated.
+func (s *Scheduler) WriteStatusPage(dir string) error {
+	return writeStatusPage(dir, s.cycle())
+}
+
+// writeStatusPage renders the status page of a cycle
+func writeStatusPage(dir string, cycle Cycle) error {
+	groups := make(map[string]*statusPageGroup)
+	for _, req := range cycle.Endpoints {
+		domain := req.Domain()
+		group, exists := groups[domain]
+		if !exists {
+			group = &statusPageGroup{Domain: domain}
+			groups[domain] = group
+		}
+
+		stats := cycle.Availability[req.Url]
+		group.Endpoints = append(group.Endpoints, statusPageEndpoint{
+			Name:         req.Name,
+			Url:          req.Url,
+			Status:       cycle.States[req.Url].Reported(),
+			Availability: stats.Percentage(),
+			Checks:       stats.Total(),
+			Sparkline:    sparkline(stats.Recent.Values()),
+		})
+	}
+
+	sorted := make([]*statusPageGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Domain < sorted[j].Domain })
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create status page directory '%s': %v", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "index.html.tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write status page: %v", err)
+	}
+	err = statusPageTemplate.Execute(tmp, map[string]any{
+		"Generated": time.Now(),
+		"Groups":    sorted,
+	})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write status page: %v", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, "index.html"))
+}
+
+// sparkline returns SVG polyline points plotting the latencies
+func sparkline(latencies []time.Duration) string {
+	if len(latencies) < 2 {
+		return ""
+	}
+
+	var max time.Duration
+	for _, latency := range latencies {
+		if latency > max {
+			max = latency
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+
+	points := make([]string, len(latencies))
+	step := float64(sparklineWidth) / float64(len(latencies)-1)
+	for i, latency := range latencies {
+		y := sparklineHeight - float64(latency)/float64(max)*(sparklineHeight-2) - 1
+		points[i] = fmt.Sprintf("%.1f,%.1f", float64(i)*step, y)
+	}
+	return strings.Join(points, " ")
+}
@@ -0,0 +1,74 @@
+package healthcheck
+
+import "sync"
+
+// ResultStore aggregates check results into per-URL availability. It is safe
+// for concurrent use by multiple goroutines.
+type ResultStore struct {
+	mu           sync.RWMutex
+	availability map[string]*Availability
+}
+
+// NewResultStore returns a store with availability tracking initialized per URL
+func NewResultStore(endpoints []Configuration) *ResultStore {
+	s := &ResultStore{availability: make(map[string]*Availability)}
+	for _, req := range endpoints {
+		if _, exists := s.availability[req.Url]; !exists {
+			s.availability[req.Url] = &Availability{}
+		}
+	}
+	return s
+}
+
+// Record adds a check result to the availability of its endpoint
+func (s *ResultStore) Record(r Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, exists := s.availability[r.Endpoint.Url]
+	if !exists {
+		stats = &Availability{}
+		s.availability[r.Endpoint.Url] = stats
+	}
+	stats.Record(r)
+}
+
+// Get returns a copy of the availability recorded for a URL
+func (s *ResultStore) Get(url string) Availability {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if stats, exists := s.availability[url]; exists {
+		return *stats
+	}
+	return Availability{}
+}
+
+// Snapshot returns a copy of the availability of every URL
+func (s *ResultStore) Snapshot() map[string]Availability {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[string]Availability, len(s.availability))
+	for url, stats := range s.availability {
+		snapshot[url] = *stats
+	}
+	return snapshot
+}
+
+// Retain keeps the availability of the given endpoints, initializing any new
+// ones, and drops every other URL
+func (s *ResultStore) Retain(endpoints []Configuration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	retained := make(map[string]*Availability, len(endpoints))
+	for _, req := range endpoints {
+		if stats, exists := s.availability[req.Url]; exists {
+			retained[req.Url] = stats
+		} else if _, exists := retained[req.Url]; !exists {
+			retained[req.Url] = &Availability{}
+		}
+	}
+	s.availability = retained
+}
@@ -0,0 +1,102 @@
+package healthcheck
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResultStoreRecord(t *testing.T) {
+	up := Configuration{Name: "up", Url: "https://up.example.com"}
+	down := Configuration{Name: "down", Url: "https://down.example.com"}
+	store := NewResultStore([]Configuration{up, down})
+
+	now := time.Now()
+	store.Record(Result{Endpoint: up, Up: true, StatusCode: 200, Latency: 100 * time.Millisecond, Time: now})
+	store.Record(Result{Endpoint: up, Up: true, StatusCode: 200, Latency: 300 * time.Millisecond, Time: now})
+	store.Record(Result{Endpoint: down, Err: errors.New("connection refused"), Time: now})
+
+	if got := store.Get(up.Url); got.Total() != 2 || got.Percentage() != 100 {
+		t.Errorf("up: got %d checks at %d%%, want 2 at 100%%", got.Total(), got.Percentage())
+	}
+	if got := store.Get(down.Url); got.Total() != 1 || got.Percentage() != 0 {
+		t.Errorf("down: got %d checks at %d%%, want 1 at 0%%", got.Total(), got.Percentage())
+	}
+	if got := store.Get("https://unknown.example.com"); got.Total() != 0 {
+		t.Errorf("unknown: got %d checks, want 0", got.Total())
+	}
+}
+
+func TestResultStoreRetain(t *testing.T) {
+	kept := Configuration{Name: "kept", Url: "https://kept.example.com"}
+	dropped := Configuration{Name: "dropped", Url: "https://dropped.example.com"}
+	added := Configuration{Name: "added", Url: "https://added.example.com"}
+	store := NewResultStore([]Configuration{kept, dropped})
+	store.Record(Result{Endpoint: kept, Up: true, StatusCode: 200, Time: time.Now()})
+
+	store.Retain([]Configuration{kept, added})
+
+	snapshot := store.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("got %d URLs, want 2", len(snapshot))
+	}
+	if _, ok := snapshot[dropped.Url]; ok {
+		t.Errorf("%s was not dropped", dropped.Url)
+	}
+	if got := snapshot[kept.Url].Total(); got != 1 {
+		t.Errorf("kept: got %d checks, want 1", got)
+	}
+	if _, ok := snapshot[added.Url]; !ok {
+		t.Errorf("%s was not added", added.Url)
+	}
+}
+
+// TestResultStoreConcurrent records and reads results from many goroutines
+// at once. Run with -race to detect unsynchronized access.
+func TestResultStoreConcurrent(t *testing.T) {
+	endpoints := []Configuration{
+		{Name: "a", Url: "https://a.example.com"},
+		{Name: "b", Url: "https://b.example.com"},
+	}
+	store := NewResultStore(endpoints)
+
+	const writers, readers, checks = 8, 4, 200
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := endpoints[i%len(endpoints)]
+			for j := range checks {
+				if j%4 == 0 {
+					store.Record(Result{Endpoint: req, Err: errors.New("timeout"), Latency: time.Second, Time: time.Now()})
+				} else {
+					store.Record(Result{Endpoint: req, Up: true, StatusCode: 200, Latency: time.Duration(j) * time.Millisecond, Time: time.Now()})
+				}
+			}
+		}()
+	}
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range checks {
+				for _, stats := range store.Snapshot() {
+					stats.Percentage()
+					stats.AverageLatency()
+				}
+				Summarize(endpoints, store.Snapshot())
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for _, stats := range store.Snapshot() {
+		total += stats.Total()
+	}
+	if total != writers*checks {
+		t.Errorf("got %d checks, want %d", total, writers*checks)
+	}
+}
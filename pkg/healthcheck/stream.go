@@ -0,0 +1,146 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// streamBuffer is the number of results waiting to be published before new
+// ones are dropped, so a slow or unreachable broker never blocks the checks
+const streamBuffer = 1000
+
+// streamBatch is the most results published at once
+const streamBatch = 100
+
+// StreamRecord is the JSON message published for every check result
+type StreamRecord struct {
+	ProbeResult
+	Domain          string   `json:"domain"`
+	Tags            []string `json:"tags,omitempty"`
+	Severity        string   `json:"severity,omitempty"`
+	Status          Status   `json:"status"`
+	Maintenance     string   `json:"maintenance,omitempty"`
+	AvailabilityPct int      `json:"availability_pct"`
+	TotalChecks     int      `json:"total_checks"`
+}
+
+// streamMessage is an encoded result with the endpoint it belongs to
+type streamMessage struct {
+	Endpoint string
+	Payload  []byte
+}
+
+// streamPublisher delivers messages to a message broker. It is only called
+// from the publishing goroutine of a Stream.
+type streamPublisher interface {
+	publish(ctx context.Context, messages []streamMessage) error
+	close() error
+	name() string
+}
+
+// Stream is a ResultSink publishing every check result as a JSON message to
+// a message broker as it comes in, so other systems can consume them in real
+// time. Results are published in the background; the failures since the
+// previous summary are reported by Flush.
+type Stream struct {
+	publisher streamPublisher
+	messages  chan streamMessage
+	done      chan struct{}
+
+	mu        sync.Mutex
+	dropped   int
+	failed    int
+	lastError error
+}
+
+// newStream starts publishing results with the given publisher
+func newStream(publisher streamPublisher) *Stream {
+	s := &Stream{
+		publisher: publisher,
+		messages:  make(chan streamMessage, streamBuffer),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Observe queues a check result for publishing, dropping it when the queue is full
+func (s *Stream) Observe(r Result, stats Availability) {
+	req := r.Endpoint
+	payload, err := json.Marshal(StreamRecord{
+		ProbeResult:     probeResult(r),
+		Domain:          req.Domain(),
+		Tags:            req.Tags,
+		Severity:        req.Severity,
+		Status:          r.Status(),
+		Maintenance:     r.Maintenance,
+		AvailabilityPct: stats.Percentage(),
+		TotalChecks:     stats.Total(),
+	})
+	if err != nil {
+		return
+	}
+	select {
+	case s.messages <- streamMessage{Endpoint: req.Name, Payload: payload}:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+}
+
+// Flush reports the results that couldn't be published since the previous summary
+func (s *Stream) Flush(cycle Cycle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dropped, failed, lastError := s.dropped, s.failed, s.lastError
+	s.dropped, s.failed, s.lastError = 0, 0, nil
+
+	switch {
+	case failed > 0:
+		return fmt.Errorf("failed to publish %d results to %s: %v", failed, s.publisher.name(), lastError)
+	case dropped > 0:
+		return fmt.Errorf("dropped %d results, %s isn't keeping up", dropped, s.publisher.name())
+	}
+	return nil
+}
+
+// Close publishes the queued results and disconnects from the broker
+func (s *Stream) Close() error {
+	close(s.messages)
+	<-s.done
+	return s.publisher.close()
+}
+
+// run publishes the queued results in batches until the stream is closed
+func (s *Stream) run() {
+	defer close(s.done)
+	for message := range s.messages {
+		batch := []streamMessage{message}
+	collect:
+		for len(batch) < streamBatch {
+			select {
+			case message, ok := <-s.messages:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, message)
+			default:
+				break collect
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := s.publisher.publish(ctx, batch)
+		cancel()
+		if err != nil {
+			s.mu.Lock()
+			s.failed += len(batch)
+			s.lastError = err
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TagSummary is the availability of every endpoint carrying a tag, combined
+type TagSummary struct {
+	Tag              string   `json:"tag"`
+	Endpoints        []string `json:"endpoints"`
+	Availability     float64  `json:"availability_pct"`
+	TotalChecks      int      `json:"total_checks"`
+	SuccessfulChecks int      `json:"successful_checks"`
+	FailedChecks     int      `json:"failed_checks"`
+	// WindowAvailability maps window labels (1h, 24h, 7d) to the combined
+	// availability over that window. Windows without checks are omitted.
+	WindowAvailability map[string]float64 `json:"window_availability_pct"`
+}
+
+// SummarizeTags returns the combined availability of the endpoints of every
+// tag, sorted by tag
+func SummarizeTags(requests []Configuration, availability map[string]Availability) []TagSummary {
+	now := time.Now()
+	byTag := make(map[string]*TagSummary)
+	windowCounts := make(map[string][]int) // tag -> success, failure per window
+
+	for _, req := range requests {
+		stats := availability[req.Url]
+		for _, tag := range req.Tags {
+			summary, exists := byTag[tag]
+			if !exists {
+				summary = &TagSummary{Tag: tag}
+				byTag[tag] = summary
+				windowCounts[tag] = make([]int, 2*len(Windows))
+			}
+			summary.Endpoints = append(summary.Endpoints, req.Name)
+			summary.SuccessfulChecks += stats.SuccessCount
+			summary.FailedChecks += stats.FailureCount
+
+			for i, window := range Windows {
+				success, failure := stats.Windows.Counts(window.Duration, now)
+				windowCounts[tag][2*i] += success
+				windowCounts[tag][2*i+1] += failure
+			}
+		}
+	}
+
+	summaries := make([]TagSummary, 0, len(byTag))
+	for tag, summary := range byTag {
+		summary.TotalChecks = summary.SuccessfulChecks + summary.FailedChecks
+		summary.Availability, _ = tagPercentage(summary.SuccessfulChecks, summary.FailedChecks)
+
+		summary.WindowAvailability = make(map[string]float64)
+		for i, window := range Windows {
+			if pct, ok := tagPercentage(windowCounts[tag][2*i], windowCounts[tag][2*i+1]); ok {
+				summary.WindowAvailability[window.Label] = pct
+			}
+		}
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Tag < summaries[j].Tag })
+	return summaries
+}
+
+// WriteTagSummary writes the combined availability of every tag. Nothing is
+// written when no endpoint is tagged.
+func WriteTagSummary(w io.Writer, requests []Configuration, availability map[string]Availability) {
+	summaries := SummarizeTags(requests, availability)
+	if len(summaries) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "Availability by tag:")
+	for _, summary := range summaries {
+		if summary.TotalChecks == 0 {
+			fmt.Fprintf(w, "   %s: no availability data yet (%d endpoints)\n", summary.Tag, len(summary.Endpoints))
+			continue
+		}
+
+		windows := make([]string, len(Windows))
+		for i, window := range Windows {
+			if pct, ok := summary.WindowAvailability[window.Label]; ok {
+				windows[i] = fmt.Sprintf("%s %.1f%%", window.Label, pct)
+			} else {
+				windows[i] = fmt.Sprintf("%s N/A", window.Label)
+			}
+		}
+		fmt.Fprintf(w, "   %s: %.1f%% (%d endpoints, %d checks; %s)\n",
+			summary.Tag, summary.Availability, len(summary.Endpoints), summary.TotalChecks, strings.Join(windows, ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+// tagPercentage returns the share of successful checks rounded to one decimal
+func tagPercentage(success, failure int) (float64, bool) {
+	total := success + failure
+	if total == 0 {
+		return 0, false
+	}
+	return math.Round(float64(success)/float64(total)*1000) / 10, true
+}
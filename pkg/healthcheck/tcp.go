@@ -0,0 +1,99 @@
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TCPChecker checks that a TCP connection can be established to an endpoint
+type TCPChecker struct {
+	// LatencyThreshold is the maximum connect latency for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the connection attempt unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeTCP, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewTCPChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewTCPChecker returns a TCPChecker using the given latency threshold and default timeout
+func NewTCPChecker(latencyThreshold, timeout time.Duration) *TCPChecker {
+	return &TCPChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check dials the endpoint address and measures the connect latency
+func (c *TCPChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	dialer := net.Dialer{Timeout: req.timeoutOr(c.Timeout)}
+	startTime := time.Now()
+	conn, err := dialer.DialContext(ctx, req.network("tcp"), tcpAddress(req.Url))
+	result.Latency = time.Since(startTime)
+
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.RemoteAddr = conn.RemoteAddr().String()
+	conn.Close()
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// tcpAddress returns the host:port to dial, accepting both tcp://host:port and plain host:port
+func tcpAddress(rawUrl string) string {
+	if !strings.Contains(rawUrl, "://") {
+		return rawUrl
+	}
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	return parsedUrl.Host
+}
+
+// serviceAddress returns the host:port to dial for a scheme://host[:port] URL
+// or a plain host[:port], using defaultPort when the URL has none
+func serviceAddress(rawUrl, defaultPort string) string {
+	address := tcpAddress(rawUrl)
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return net.JoinHostPort(strings.Trim(address, "[]"), defaultPort)
+	}
+	return address
+}
+
+// dialService connects to the address of a service check, bounding the
+// connection by the deadline of ctx. useTLS performs the TLS handshake right
+// away.
+func dialService(ctx context.Context, req Configuration, address string, useTLS bool) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, req.network("tcp"), address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if !useTLS {
+		return conn, nil
+	}
+
+	host, _, _ := net.SplitHostPort(address)
+	tlsConn, err := startTLS(conn, req, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,122 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer and meter of the health checker
+const instrumentationName = "github.com/alchmst333/SRE_Healthcheck/pkg/healthcheck"
+
+// Telemetry records every check as an OpenTelemetry span, following the HTTP
+// client semantic conventions for HTTP checks, and as OpenTelemetry metrics.
+type Telemetry struct {
+	tracer  trace.Tracer
+	checks  metric.Int64Counter
+	latency metric.Float64Histogram
+	up      metric.Int64Gauge
+}
+
+// NewTelemetry returns a Telemetry creating spans and instruments from the
+// given providers
+func NewTelemetry(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) (*Telemetry, error) {
+	meter := meterProvider.Meter(instrumentationName)
+
+	checks, err := meter.Int64Counter("healthcheck.checks",
+		metric.WithDescription("Number of health checks by result"),
+		metric.WithUnit("{check}"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checks counter: %v", err)
+	}
+	latency, err := meter.Float64Histogram("healthcheck.latency",
+		metric.WithDescription("Latency of health checks"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create latency histogram: %v", err)
+	}
+	up, err := meter.Int64Gauge("healthcheck.up",
+		metric.WithDescription("Whether the last check of the endpoint succeeded"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create up gauge: %v", err)
+	}
+
+	return &Telemetry{
+		tracer:  tracerProvider.Tracer(instrumentationName),
+		checks:  checks,
+		latency: latency,
+		up:      up,
+	}, nil
+}
+
+// start begins the span of a check
+func (t *Telemetry) start(ctx context.Context, req Configuration) (context.Context, trace.Span) {
+	attrs := endpointAttributes(req)
+
+	name := req.Type + " check"
+	switch req.Type {
+	case "", TypeHTTP:
+		method := req.Method
+		if method == "" {
+			method = "GET"
+		}
+		name = method
+		attrs = append(attrs, semconv.HTTPRequestMethodKey.String(method), semconv.URLFull(req.Url))
+	}
+
+	return t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// end records the outcome of a check on its span and ends it
+func (t *Telemetry) end(span trace.Span, r Result) {
+	if r.StatusCode != 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(r.StatusCode))
+	}
+	span.SetAttributes(attribute.Bool("healthcheck.up", r.Up))
+
+	switch {
+	case r.Err != nil:
+		span.RecordError(r.Err)
+		span.SetAttributes(semconv.ErrorTypeKey.String(fmt.Sprintf("%T", r.Err)))
+		span.SetStatus(codes.Error, r.Err.Error())
+	case !r.Up:
+		span.SetStatus(codes.Error, string(r.Status()))
+	}
+	if cause := r.Cause(); cause != "" {
+		span.SetAttributes(attribute.String("healthcheck.failure_cause", cause))
+	}
+	span.End()
+}
+
+// observe records a check result in the metrics
+func (t *Telemetry) observe(ctx context.Context, r Result) {
+	attrs := endpointAttributes(r.Endpoint)
+
+	up, outcome := int64(0), r.Outcome()
+	if r.Up {
+		up = 1
+	}
+
+	t.up.Record(ctx, up, metric.WithAttributes(attrs...))
+	t.checks.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("result", outcome))...))
+	if r.Err == nil || r.StatusCode != 0 {
+		t.latency.Record(ctx, r.Latency.Seconds(), metric.WithAttributes(attrs...))
+	}
+}
+
+// endpointAttributes identifies an endpoint on spans and metrics
+func endpointAttributes(req Configuration) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("healthcheck.endpoint", req.Name),
+		semconv.ServerAddress(req.Domain()),
+	}
+	if len(req.Tags) > 0 {
+		attrs = append(attrs, attribute.StringSlice("healthcheck.tags", req.Tags))
+	}
+	return attrs
+}
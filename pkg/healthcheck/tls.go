@@ -0,0 +1,72 @@
+package healthcheck
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+)
+
+// TLSConfig holds the TLS options of an endpoint: a client certificate for
+// mutual TLS and a custom CA bundle for internal PKI
+type TLSConfig struct {
+	CertFile           string `yaml:"cert_file,omitempty"`
+	KeyFile            string `yaml:"key_file,omitempty"`
+	CAFile             string `yaml:"ca_file,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// load builds a tls.Config from the configured files
+func (t *TLSConfig) load() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file '%s': %v", t.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file '%s'", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// tlsConfig returns the TLS configuration of an endpoint connecting to the
+// given server name, for checks that negotiate TLS themselves
+func (c Configuration) tlsConfig(serverName string) (*tls.Config, error) {
+	config := &tls.Config{}
+	if c.TLS != nil {
+		var err error
+		if config, err = c.TLS.load(); err != nil {
+			return nil, err
+		}
+	}
+	config.ServerName = serverName
+	return config, nil
+}
+
+// startTLS performs the TLS handshake over an established connection
+func startTLS(conn net.Conn, req Configuration, host string) (net.Conn, error) {
+	config, err := req.tlsConfig(host)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %v", err)
+	}
+	return tlsConn, nil
+}
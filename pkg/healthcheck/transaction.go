@@ -0,0 +1,308 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TypeTransaction is a multi-step HTTP check
+const TypeTransaction = "transaction"
+
+// Step is a single HTTP request of a transaction check. Its URL, headers and
+// body may reference variables extracted by earlier steps as {{name}}.
+type Step struct {
+	Name        string            `yaml:"name,omitempty"`
+	Url         string            `yaml:"url"`
+	Method      string            `yaml:"method,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Body        string            `yaml:"body,omitempty"`
+	ContentType string            `yaml:"content_type,omitempty"`
+
+	// Response assertions, as on endpoints
+	ExpectedStatus        []int             `yaml:"expected_status,omitempty"`
+	ExpectBodyContains    string            `yaml:"expect_body_contains,omitempty"`
+	ExpectBodyRegex       string            `yaml:"expect_body_regex,omitempty"`
+	ExpectBodyNotContains []string          `yaml:"expect_body_not_contains,omitempty"`
+	MinSize               int64             `yaml:"min_size,omitempty"`
+	MaxSize               int64             `yaml:"max_size,omitempty"`
+	ExpectHeaders         map[string]string `yaml:"expect_headers,omitempty"`
+	ExpectHeadersRegex    map[string]string `yaml:"expect_headers_regex,omitempty"`
+
+	// Extract maps variable names to the part of the response they are read from
+	Extract map[string]Extraction `yaml:"extract,omitempty"`
+
+	// bodyRegex and headersRegex are ExpectBodyRegex and ExpectHeadersRegex
+	// compiled when the configuration is parsed
+	bodyRegex    *regexp.Regexp
+	headersRegex map[string]*regexp.Regexp
+}
+
+// Extraction reads a variable from a response. Exactly one source is set.
+type Extraction struct {
+	// JSON is a dot-separated path into a JSON body, e.g. data.items.0.id
+	JSON string `yaml:"json,omitempty"`
+	// Header is the name of a response header
+	Header string `yaml:"header,omitempty"`
+	// Regex is matched against the body. The first capture group is used, or
+	// the whole match when there is none.
+	Regex string `yaml:"regex,omitempty"`
+}
+
+// variablePattern matches {{name}} references to extracted variables
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// TransactionChecker runs the steps of a transaction in order, sharing
+// cookies between them. The transaction is DOWN as soon as a step fails and
+// its latency is the total of every step.
+type TransactionChecker struct {
+	// HTTP sends the request of every step
+	HTTP *HTTPChecker
+	// LatencyThreshold is the maximum total latency for a transaction to count as UP
+	LatencyThreshold time.Duration
+}
+
+func init() {
+	Register(TypeTransaction, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewTransactionChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewTransactionChecker returns a TransactionChecker using the given latency
+// threshold and default timeout for each step
+func NewTransactionChecker(latencyThreshold, timeout time.Duration) *TransactionChecker {
+	return &TransactionChecker{
+		HTTP:             NewHTTPChecker(latencyThreshold, timeout),
+		LatencyThreshold: latencyThreshold,
+	}
+}
+
+// Check runs every step of the transaction and stops at the first failure
+func (c *TransactionChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	// Cookies are shared by the steps, and kept for the next run with cookie_jar
+	var jar http.CookieJar
+	var err error
+	if req.CookieJar {
+		jar, err = c.HTTP.sessionJar(req.Url)
+	} else {
+		jar, err = cookiejar.New(nil)
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	variables := make(map[string]string)
+	for i, step := range req.Steps {
+		stepReq, err := req.step(step, variables)
+		if err == nil {
+			var stepResult Result
+			var resp *response
+			stepResult, resp = c.HTTP.exchange(ctx, stepReq, jar, len(step.Extract) > 0)
+			result.Latency += stepResult.Latency
+			result.Phases = result.Phases.add(stepResult.Phases)
+			result.RemoteAddr = stepResult.RemoteAddr
+			result.Protocol = stepResult.Protocol
+			result.StatusCode = stepResult.StatusCode
+			switch {
+			case stepResult.Err != nil:
+				err = stepResult.Err
+			case !stepResult.Up:
+				err = fmt.Errorf("%w %d", errUnexpectedStatus, stepResult.StatusCode)
+			default:
+				err = extract(step.Extract, resp, variables)
+			}
+		}
+		if err != nil {
+			result.Err = fmt.Errorf("step %d (%s): %v", i+1, step.label(), err)
+			return result
+		}
+	}
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// step returns the endpoint settings of a step with variables substituted.
+// Authentication, TLS, redirects and the timeout are inherited from the
+// transaction, and the transaction headers are sent with every step.
+func (c Configuration) step(step Step, variables map[string]string) (Configuration, error) {
+	req := c
+	req.Steps = nil
+	req.Method = step.Method
+	req.ContentType = step.ContentType
+	req.ExpectedStatus = step.ExpectedStatus
+	req.ExpectBodyContains = step.ExpectBodyContains
+	req.ExpectBodyRegex = step.ExpectBodyRegex
+	req.ExpectBodyNotContains = step.ExpectBodyNotContains
+	req.MinSize = step.MinSize
+	req.MaxSize = step.MaxSize
+	req.ExpectSHA256, req.DetectChanges = "", false
+	req.Cache = nil
+	req.ExpectHeaders = step.ExpectHeaders
+	req.ExpectHeadersRegex = step.ExpectHeadersRegex
+	req.bodyRegex, req.headersRegex = step.bodyRegex, step.headersRegex
+
+	var err error
+	if req.Url, err = substitute(step.Url, variables); err != nil {
+		return req, err
+	}
+	if req.Body, err = substitute(step.Body, variables); err != nil {
+		return req, err
+	}
+
+	req.Headers = make(map[string]string, len(c.Headers)+len(step.Headers))
+	for key, value := range c.Headers {
+		req.Headers[key] = value
+	}
+	for key, value := range step.Headers {
+		if req.Headers[key], err = substitute(value, variables); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+// label names a step in errors
+func (s Step) label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Url
+}
+
+// substitute replaces {{name}} references with the extracted variables
+func substitute(s string, variables map[string]string) (string, error) {
+	var missing string
+	substituted := variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := variablePattern.FindStringSubmatch(ref)[1]
+		value, ok := variables[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("variable %s is not defined", missing)
+	}
+	return substituted, nil
+}
+
+// extract reads the variables of a step from its response
+func extract(extractions map[string]Extraction, resp *response, variables map[string]string) error {
+	for name, extraction := range extractions {
+		var value string
+		var err error
+		switch {
+		case extraction.JSON != "":
+			value, err = extractJSON(resp.Body, extraction.JSON)
+		case extraction.Header != "":
+			value = resp.Header.Get(extraction.Header)
+			if value == "" {
+				err = fmt.Errorf("response has no %s header", extraction.Header)
+			}
+		case extraction.Regex != "":
+			value, err = extractRegex(resp.Body, extraction.Regex)
+		}
+		if err != nil {
+			return fmt.Errorf("extracting %s: %v", name, err)
+		}
+		variables[name] = value
+	}
+	return nil
+}
+
+// extractJSON returns the value at a dot-separated path of a JSON document.
+// Strings are returned as is and other values as JSON.
+func extractJSON(body []byte, path string) (string, error) {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", fmt.Errorf("response is not JSON: %v", err)
+	}
+
+	for _, key := range strings.Split(strings.TrimPrefix(path, "$."), ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			child, exists := node[key]
+			if !exists {
+				return "", fmt.Errorf("no %q in %s", key, path)
+			}
+			value = child
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", fmt.Errorf("no index %q in %s", key, path)
+			}
+			value = node[index]
+		default:
+			return "", fmt.Errorf("no %q in %s", key, path)
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+// extractRegex returns the first capture group of the pattern in body, or the
+// whole match when the pattern has no group
+func extractRegex(body []byte, pattern string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex %q: %v", pattern, err)
+	}
+	match := re.FindSubmatch(body)
+	switch {
+	case match == nil:
+		return "", fmt.Errorf("response body does not match %q", pattern)
+	case len(match) > 1:
+		return string(match[1]), nil
+	default:
+		return string(match[0]), nil
+	}
+}
+
+// validateSteps checks that a transaction has steps and that every
+// extraction reads from exactly one source
+func (c Configuration) validateSteps() error {
+	if len(c.Steps) == 0 {
+		return fmt.Errorf("transaction has no steps")
+	}
+	for i, step := range c.Steps {
+		if step.Url == "" {
+			return fmt.Errorf("step %d has no url", i+1)
+		}
+		if err := (Configuration{MinSize: step.MinSize, MaxSize: step.MaxSize}).validateSize(); err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+		for name, extraction := range step.Extract {
+			sources := 0
+			for _, source := range []string{extraction.JSON, extraction.Header, extraction.Regex} {
+				if source != "" {
+					sources++
+				}
+			}
+			if sources != 1 {
+				return fmt.Errorf("step %d: extract %s must set exactly one of json, header or regex", i+1, name)
+			}
+		}
+	}
+	return nil
+}
+
+// transactionUrl is the URL identifying a transaction without one configured
+func transactionUrl(name string) string {
+	return TypeTransaction + "://" + url.PathEscape(name)
+}
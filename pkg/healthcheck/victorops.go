@@ -0,0 +1,61 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VictorOpsConfig configures alerts sent to a Splunk On-Call (VictorOps)
+// REST endpoint integration
+type VictorOpsConfig struct {
+	// URL is the REST endpoint of the integration, including its API key:
+	// https://alert.victorops.com/integrations/generic/20131114/alert/<api key>
+	URL        string `yaml:"url"`
+	RoutingKey string `yaml:"routing_key"`
+}
+
+// VictorOpsNotifier opens a Splunk On-Call incident when an endpoint goes
+// DOWN and resolves it when the endpoint recovers. Incidents are keyed by the
+// endpoint URL.
+type VictorOpsNotifier struct {
+	Config VictorOpsConfig
+	Client *http.Client
+}
+
+// NewVictorOpsNotifier returns a VictorOpsNotifier for the given configuration
+func NewVictorOpsNotifier(config VictorOpsConfig) (*VictorOpsNotifier, error) {
+	if config.URL == "" || config.RoutingKey == "" {
+		return nil, fmt.Errorf("victorops alerting requires url and routing_key")
+	}
+	return &VictorOpsNotifier{
+		Config: config,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// Notify sends a CRITICAL message for DOWN alerts and a RECOVERY message when
+// the endpoint recovers. Other alerts are sent as INFO, which doesn't page.
+func (n *VictorOpsNotifier) Notify(a Alert) error {
+	messageType := "INFO"
+	switch {
+	case a.Opens():
+		messageType = "CRITICAL"
+	case a.Resolves():
+		messageType = "RECOVERY"
+	}
+
+	payload := map[string]any{
+		"message_type":        messageType,
+		"entity_id":           "healthcheck:" + a.Endpoint.Url,
+		"entity_display_name": fmt.Sprintf("%s is %s", a.Endpoint.Name, a.Current),
+		"state_message":       alertMessage(a),
+		"monitoring_tool":     "healthcheck",
+	}
+	for key, value := range alertDetails(a) {
+		payload[key] = value
+	}
+	return postJSON(n.Client, strings.TrimSuffix(n.Config.URL, "/")+"/"+url.PathEscape(n.Config.RoutingKey), nil, payload)
+}
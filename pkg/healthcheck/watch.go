@@ -0,0 +1,94 @@
+package healthcheck
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce coalesces the burst of events editors emit when saving a file
+const watchDebounce = 500 * time.Millisecond
+
+// WatchConfig calls onChange whenever the file at path is written, created or
+// replaced, until ctx is cancelled. The parent directory is watched so that
+// editors that save by renaming a temporary file are handled.
+func WatchConfig(ctx context.Context, path string, onChange func()) error {
+	return WatchConfigFiles(ctx, []string{path}, onChange)
+}
+
+// WatchConfigFiles is like WatchConfig for several files or glob patterns,
+// such as the Sources of a configuration. Files created later that match a
+// pattern also trigger onChange.
+func WatchConfigFiles(ctx context.Context, patterns []string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	targets := make([]string, len(patterns))
+	watched := make(map[string]bool)
+	for i, pattern := range patterns {
+		targets[i] = filepath.Clean(pattern)
+		dirs := []string{filepath.Dir(targets[i])}
+		if hasGlobMeta(dirs[0]) {
+			dirs, _ = filepath.Glob(dirs[0])
+		}
+		for _, dir := range dirs {
+			if watched[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				watcher.Close()
+				return err
+			}
+			watched[dir] = true
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if matchesAny(targets, filepath.Clean(event.Name)) && event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) {
+					debounce = time.After(watchDebounce)
+				}
+			case <-debounce:
+				debounce = nil
+				onChange()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Error watching configuration files '%s': %v", strings.Join(patterns, "', '"), err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// matchesAny reports whether a file is one of the targets or matches one of
+// their glob patterns
+func matchesAny(targets []string, file string) bool {
+	for _, target := range targets {
+		if target == file {
+			return true
+		}
+		if matched, _ := filepath.Match(target, file); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package healthcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier posts alerts as Slack-compatible JSON to a webhook URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier returns a WebhookNotifier posting to the given URL
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Notify posts the alert message to the webhook
+func (n *WebhookNotifier) Notify(a Alert) error {
+	return postJSON(n.Client, n.URL, nil, map[string]string{"text": alertMessage(a)})
+}
+
+// NotifyDigest posts the digest text to the webhook
+func (n *WebhookNotifier) NotifyDigest(d Digest) error {
+	return postJSON(n.Client, n.URL, nil, map[string]string{"text": d.Text()})
+}
+
+// postJSON posts a JSON payload with the given headers and expects a 2xx response
+func postJSON(client *http.Client, url string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+// alertMessage formats an alert as a single line of text
+func alertMessage(a Alert) string {
+	if a.Reason != "" {
+		return fmt.Sprintf("%s: %s (%s) - %s", a.Current, a.Endpoint.Name, a.Endpoint.Url, a.Reason)
+	}
+	msg := fmt.Sprintf("%s: %s (%s) - %s", a.Current, a.Endpoint.Name, a.Endpoint.Url, resultDetail(a.Result))
+	if a.Result.Err != nil {
+		msg += fmt.Sprintf(", Error: %v", a.Result.Err)
+	}
+	if a.Current == StatusDown {
+		msg += fmt.Sprintf(", Consecutive failures: %d", a.ConsecutiveFailures) + severityDetail(a.Endpoint)
+	}
+	return msg
+}
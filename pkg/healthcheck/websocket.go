@@ -0,0 +1,196 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TypeWebSocket is a WebSocket upgrade check
+const TypeWebSocket = "websocket"
+
+// errPong stops reading once the awaited pong arrived
+var errPong = errors.New("pong received")
+
+// WebSocketChecker checks that an endpoint accepts the WebSocket upgrade
+// handshake. It optionally sends a ping and a message and waits for the pong
+// and a reply matching the body assertions.
+type WebSocketChecker struct {
+	// LatencyThreshold is the maximum handshake latency for a check to count as UP
+	LatencyThreshold time.Duration
+	// Timeout bounds the handshake and the exchange unless the endpoint sets its own
+	Timeout time.Duration
+}
+
+func init() {
+	Register(TypeWebSocket, func(latencyThreshold, timeout time.Duration) Checker {
+		return NewWebSocketChecker(latencyThreshold, timeout)
+	})
+}
+
+// NewWebSocketChecker returns a WebSocketChecker using the given latency threshold and default timeout
+func NewWebSocketChecker(latencyThreshold, timeout time.Duration) *WebSocketChecker {
+	return &WebSocketChecker{
+		LatencyThreshold: latencyThreshold,
+		Timeout:          timeout,
+	}
+}
+
+// Check performs the upgrade handshake and measures its latency
+func (c *WebSocketChecker) Check(ctx context.Context, req Configuration) Result {
+	result := Result{Endpoint: req, Time: time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, req.timeoutOr(c.Timeout))
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	header, err := handshakeHeader(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	dialer, err := webSocketDialer(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	startTime := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, req.Url, header)
+	result.Latency = time.Since(startTime)
+
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			err = fmt.Errorf("upgrade rejected with status %d", resp.StatusCode)
+		}
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	result.RemoteAddr = conn.RemoteAddr().String()
+
+	if err := converse(conn, req, deadline); err != nil {
+		result.Err = err
+		return result
+	}
+
+	// Close cleanly so the server doesn't log an abnormal closure
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+
+	result.Up = true
+	result.applyLatencyThreshold(req.latencyThresholdOr(c.LatencyThreshold))
+	return result
+}
+
+// handshakeHeader returns the headers and credentials of the upgrade request
+func handshakeHeader(req Configuration) (http.Header, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, req.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	if req.Auth != nil {
+		if err := req.Auth.apply(httpReq); err != nil {
+			return nil, err
+		}
+	}
+	return httpReq.Header, nil
+}
+
+// webSocketDialer returns a dialer honoring the TLS, proxy and IP version
+// settings of the endpoint
+func webSocketDialer(req Configuration) (*websocket.Dialer, error) {
+	netDialer := &net.Dialer{}
+	dialer := &websocket.Dialer{
+		Proxy: http.ProxyFromEnvironment,
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return netDialer.DialContext(ctx, req.network(network), addr)
+		},
+	}
+	switch req.Proxy {
+	case "":
+	case ProxyDirect:
+		dialer.Proxy = nil
+	default:
+		proxyUrl, err := url.Parse(req.Proxy)
+		if err != nil || proxyUrl.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL '%s'", req.Proxy)
+		}
+		dialer.Proxy = http.ProxyURL(proxyUrl)
+	}
+	if req.TLS != nil {
+		tlsConfig, err := req.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		dialer.TLSClientConfig = tlsConfig
+	}
+	return dialer, nil
+}
+
+// converse sends the ping and the body of the endpoint, then waits for the
+// pong and for a message when the endpoint asserts on it
+func converse(conn *websocket.Conn, req Configuration, deadline time.Time) error {
+	if req.Ping {
+		if err := conn.WriteControl(websocket.PingMessage, []byte("healthcheck"), deadline); err != nil {
+			return err
+		}
+	}
+	if req.Body != "" {
+		conn.SetWriteDeadline(deadline)
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(req.Body)); err != nil {
+			return err
+		}
+	}
+
+	awaitPong, awaitMessage := req.Ping, req.wantsBody()
+	if !awaitPong && !awaitMessage {
+		return nil
+	}
+
+	conn.SetReadDeadline(deadline)
+	conn.SetPongHandler(func(string) error {
+		awaitPong = false
+		if !awaitMessage {
+			return errPong
+		}
+		return nil
+	})
+
+	var lastErr error
+	for awaitPong || awaitMessage {
+		_, message, err := conn.ReadMessage()
+		if errors.Is(err, errPong) {
+			return nil
+		}
+		if err != nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			if awaitPong {
+				return fmt.Errorf("no pong received: %v", err)
+			}
+			return fmt.Errorf("no message received: %v", err)
+		}
+		if !awaitMessage {
+			continue
+		}
+
+		// Servers may push other messages first, any matching one will do
+		if lastErr = assertBody(req, message); lastErr == nil {
+			awaitMessage = false
+		}
+	}
+	return nil
+}
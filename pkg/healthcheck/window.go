@@ -0,0 +1,86 @@
+package healthcheck
+
+import "time"
+
+// Reporting windows for rolling availability
+var Windows = []struct {
+	Label    string
+	Duration time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// Bucket layout: one-minute buckets cover the last hour precisely, hourly
+// buckets cover up to a week
+const (
+	minuteBuckets = 60
+	hourBuckets   = 7 * 24
+)
+
+// windowBucket counts the checks recorded in one time slot
+type windowBucket struct {
+	Start   int64 `json:"start"`
+	Success int   `json:"success"`
+	Failure int   `json:"failure"`
+}
+
+// RollingWindows tracks check outcomes in time buckets so availability can be
+// reported over recent windows. It is a plain value so it can be copied and
+// persisted.
+type RollingWindows struct {
+	Minutes [minuteBuckets]windowBucket `json:"minutes"`
+	Hours   [hourBuckets]windowBucket   `json:"hours"`
+}
+
+// Add records a check outcome at time t
+func (w *RollingWindows) Add(t time.Time, up bool) {
+	addToBucket(w.Minutes[:], int64(time.Minute/time.Second), t, up)
+	addToBucket(w.Hours[:], int64(time.Hour/time.Second), t, up)
+}
+
+// Availability returns the percentage of successful checks within the window
+// ending at now, rounded to the nearest whole number, and whether any checks
+// were recorded in it.
+func (w RollingWindows) Availability(window time.Duration, now time.Time) (int, bool) {
+	success, failure := w.Counts(window, now)
+	total := success + failure
+	if total == 0 {
+		return 0, false
+	}
+	return int(float64(success)/float64(total)*100 + 0.5), true
+}
+
+// Counts returns the number of successful and failed checks within the
+// window ending at now. Windows up to an hour use minute buckets, longer ones
+// use hourly buckets.
+func (w RollingWindows) Counts(window time.Duration, now time.Time) (success, failure int) {
+	buckets, width := w.Hours[:], int64(time.Hour/time.Second)
+	if window <= time.Hour {
+		buckets, width = w.Minutes[:], int64(time.Minute/time.Second)
+	}
+
+	since := now.Add(-window).Unix()/width*width + width
+	for _, bucket := range buckets {
+		if bucket.Start >= since && bucket.Start <= now.Unix() {
+			success += bucket.Success
+			failure += bucket.Failure
+		}
+	}
+	return success, failure
+}
+
+// addToBucket records an outcome in the ring of buckets of the given width in seconds
+func addToBucket(buckets []windowBucket, width int64, t time.Time, up bool) {
+	start := t.Unix() / width * width
+	bucket := &buckets[(start/width)%int64(len(buckets))]
+	if bucket.Start != start {
+		*bucket = windowBucket{Start: start}
+	}
+	if up {
+		bucket.Success++
+	} else {
+		bucket.Failure++
+	}
+}